@@ -0,0 +1,76 @@
+// Command fximport loads exchange rates from a local file into the fx_rates
+// table, e.g. a downloaded ECB history:
+//
+//	go run ./cmd/fximport -file eurofxref-hist.xml
+//	go run ./cmd/fximport -file rates.csv   # date,base,quote,rate
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	repo "github.com/EftikharAzim/ledgerx/internal/repo"
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+	service "github.com/EftikharAzim/ledgerx/internal/service"
+)
+
+func main() {
+	path := flag.String("file", "", "rates file (.xml = ECB format, anything else = CSV)")
+	format := flag.String("format", "", "force the format: ecb or csv")
+	flag.Parse()
+	if *path == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	_ = godotenv.Load()
+
+	if err := run(*path, *format); err != nil {
+		fmt.Fprintln(os.Stderr, "fximport:", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, format string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if format == "" {
+		format = "csv"
+		if strings.EqualFold(filepath.Ext(path), ".xml") {
+			format = "ecb"
+		}
+	}
+	var parse func(io.Reader) ([]service.RateRow, error)
+	switch format {
+	case "ecb":
+		parse = service.ParseECBXML
+	case "csv":
+		parse = service.ParseRatesCSV
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	rows, err := parse(f)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	pool := repo.MustOpenPool(ctx)
+	defer pool.Close()
+	n, err := service.NewFXService(pool, sqlc.New(pool)).Import(ctx, rows, format)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d rates from %s\n", n, path)
+	return nil
+}
@@ -67,13 +67,18 @@ func main() {
 		log.Fatal("storage init", zap.Error(err))
 	}
 
+	// ---- Services shared by the API and the workers ----
+	summarySvc := service.NewSummaryService(q, rdb)
+	txSvc := service.NewTransactionService(pool, q, summarySvc)
+	recurringSvc := service.NewRecurringService(q, txSvc)
+
 	// ---- Workers ----
 	// Runtime errors from the worker/scheduler flow into errCh so the main
 	// goroutine can shut everything down through the normal path (a Fatal
 	// inside a goroutine would skip the deferred cleanup).
 	errCh := make(chan error, 2)
 
-	wrk := worker.NewServer(redisAddr, q, store)
+	wrk := worker.NewServer(redisAddr, q, store, recurringSvc)
 	go func() {
 		if err := wrk.Start(); err != nil {
 			errCh <- err
@@ -103,8 +108,11 @@ func main() {
 	httptransport.HealthRoutes(r, pool, rdb)
 	r.Handle("/metrics", observability.MetricsHandler())
 
+	fxSvc := service.NewFXService(pool, q)
+	periodSvc := service.NewPeriodService(pool, q)
+
 	// Unprotected routes
-	admin := httptransport.NewAdminAPI(redisAddr)
+	admin := httptransport.NewAdminAPI(redisAddr, fxSvc, periodSvc)
 	admin.Routes(r)
 
 	// Auth endpoints get a per-IP limiter to slow credential stuffing.
@@ -129,22 +137,48 @@ func main() {
 		users := httptransport.NewUsersAPI(q)
 		users.Routes(rt)
 
-		accounts := httptransport.NewAccountsAPI(q)
+		accounts := httptransport.NewAccountsAPI(service.NewAccountService(pool, q))
 		accounts.Routes(rt)
 
-		summarySvc := service.NewSummaryService(q, rdb)
-		txSvc := service.NewTransactionService(pool, q, summarySvc)
 		tx := httptransport.NewTransactionsAPI(q, txSvc)
 		tx.Routes(rt)
 
-		summary := httptransport.NewSummaryAPI(q, summarySvc)
+		attachments := httptransport.NewAttachmentsAPI(service.NewAttachmentService(q, store))
+		attachments.Routes(rt)
+
+		holdSvc := service.NewHoldService(pool, q, txSvc)
+		holds := httptransport.NewHoldsAPI(q, holdSvc)
+		holds.Routes(rt)
+
+		recurring := httptransport.NewRecurringAPI(recurringSvc)
+		recurring.Routes(rt)
+
+		categories := httptransport.NewCategoriesAPI(service.NewCategoryService(pool, q))
+		categories.Routes(rt)
+
+		budgets := httptransport.NewBudgetsAPI(service.NewBudgetService(q))
+		budgets.Routes(rt)
+
+		reports := httptransport.NewReportsAPI(service.NewReportService(q))
+		reports.Routes(rt)
+
+		periods := httptransport.NewPeriodsAPI(periodSvc)
+		periods.Routes(rt)
+
+		summary := httptransport.NewSummaryAPI(q, summarySvc, fxSvc)
 		summary.Routes(rt)
 
 		exp := httptransport.NewExportsAPI(q, redisAddr, store)
 		exp.Routes(rt)
 
+		statements := httptransport.NewStatementsAPI(service.NewStatementService(q, store), redisAddr)
+		statements.Routes(rt)
+
+		reconciliations := httptransport.NewReconciliationsAPI(service.NewReconciliationService(pool, q))
+		reconciliations.Routes(rt)
+
 		balSvc := service.NewBalanceService(q)
-		balance := httptransport.NewBalanceAPI(q, balSvc)
+		balance := httptransport.NewBalanceAPI(q, balSvc, fxSvc)
 		balance.Routes(rt)
 	})
 
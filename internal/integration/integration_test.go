@@ -14,6 +14,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -28,6 +30,7 @@ import (
 
 	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
 	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/EftikharAzim/ledgerx/internal/storage"
 	"github.com/EftikharAzim/ledgerx/internal/worker"
 )
 
@@ -127,7 +130,7 @@ func newUserWithAccount(t *testing.T, currency string) (int64, int64) {
 		t.Fatal(err)
 	}
 	a, err := q.CreateAccount(context.Background(), sqlc.CreateAccountParams{
-		UserID: u.ID, Name: fmt.Sprintf("acc-%d", userSeq), Currency: currency,
+		UserID: u.ID, Name: fmt.Sprintf("acc-%d", userSeq), Currency: currency, Type: "asset",
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -152,7 +155,7 @@ func TestTriggerRejectsUnbalancedAtCommit(t *testing.T) {
 		t.Fatal(err)
 	}
 	if _, err := tx.Exec(ctx,
-		`INSERT INTO postings (transaction_id, account_id, amount_minor) VALUES ($1,$2,999)`,
+		`INSERT INTO postings (transaction_id, account_id, amount_minor, currency) VALUES ($1,$2,999,'USD')`,
 		txID, accID); err != nil {
 		t.Fatal(err)
 	}
@@ -257,7 +260,7 @@ func TestConcurrentSameKeyCreatesOnce(t *testing.T) {
 func TestTransferAndBalances(t *testing.T) {
 	ctx := context.Background()
 	uid, acc1 := newUserWithAccount(t, "USD")
-	acc2, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "second", Currency: "USD"})
+	acc2, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "second", Currency: "USD", Type: "asset"})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -465,3 +468,1748 @@ func TestMonthlySummaryAndCacheInvalidation(t *testing.T) {
 		t.Fatalf("stale summary after invalidation: %+v", s2)
 	}
 }
+
+func TestJournalEntrySplitsAtomically(t *testing.T) {
+	ctx := context.Background()
+	uid, checking := newUserWithAccount(t, "USD")
+	savings, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "savings", Currency: "USD", Type: "asset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tax, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "tax", Currency: "USD", Type: "asset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: checking, AmountMinor: 10000, Currency: "USD", OccurredAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dto, err := txSvc.Journal(ctx, service.JournalInput{
+		UserID: uid, Currency: "USD", OccurredAt: time.Now().UTC(), Note: "paycheck split",
+		Legs: []service.Leg{
+			{AccountID: checking, AmountMinor: -5000},
+			{AccountID: savings.ID, AmountMinor: 3000},
+			{AccountID: tax.ID, AmountMinor: 2000},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dto.Postings) != 3 {
+		t.Fatalf("want 3 postings, got %d", len(dto.Postings))
+	}
+
+	// An unbalanced journal is rejected before anything is written.
+	if _, err := txSvc.Journal(ctx, service.JournalInput{
+		UserID: uid, Currency: "USD", OccurredAt: time.Now().UTC(),
+		Legs: []service.Leg{
+			{AccountID: checking, AmountMinor: -5000},
+			{AccountID: savings.ID, AmountMinor: 4000},
+		},
+	}); !errors.Is(err, service.ErrUnbalanced) {
+		t.Fatalf("want ErrUnbalanced, got %v", err)
+	}
+
+	for acc, want := range map[int64]int64{checking: 5000, savings.ID: 3000, tax.ID: 2000} {
+		b, err := balSvc.CurrentBalance(ctx, acc, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Balance != want {
+			t.Fatalf("account %d balance = %d, want %d", acc, b.Balance, want)
+		}
+	}
+}
+
+func TestCrossCurrencyTransferBalancesPerCurrency(t *testing.T) {
+	ctx := context.Background()
+	uid, usd := newUserWithAccount(t, "USD")
+	eur, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "eur savings", Currency: "EUR", Type: "asset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: usd, AmountMinor: 5000, Currency: "USD", OccurredAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dto, err := txSvc.Transfer(ctx, service.TransferInput{
+		UserID: uid, FromAccountID: usd, ToAccountID: eur.ID, AmountMinor: 1000,
+		Currency: "USD", ToCurrency: "EUR", FXRate: "0.92", OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dto.FXRate != "0.92" || len(dto.Postings) != 4 {
+		t.Fatalf("unexpected fx transfer: rate=%q postings=%d", dto.FXRate, len(dto.Postings))
+	}
+
+	b, err := balSvc.CurrentBalance(ctx, eur.ID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Balance != 920 {
+		t.Fatalf("eur balance = %d, want 920", b.Balance)
+	}
+
+	// Without a rate the currencies cannot be bridged.
+	if _, err := txSvc.Transfer(ctx, service.TransferInput{
+		UserID: uid, FromAccountID: usd, ToAccountID: eur.ID, AmountMinor: 1000,
+		Currency: "USD", ToCurrency: "EUR", OccurredAt: time.Now().UTC(),
+	}); !errors.Is(err, service.ErrInvalidRate) {
+		t.Fatalf("want ErrInvalidRate, got %v", err)
+	}
+
+	// The reversal negates every leg in its own currency.
+	if _, err := txSvc.Reverse(ctx, service.ReverseInput{UserID: uid, TransactionID: dto.ID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTriggerRejectsCrossCurrencyImbalance(t *testing.T) {
+	ctx := context.Background()
+	uid, usd := newUserWithAccount(t, "USD")
+	eur, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "eur", Currency: "EUR", Type: "asset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	var txID int64
+	if err := tx.QueryRow(ctx,
+		`INSERT INTO transactions (user_id, currency, occurred_at) VALUES ($1,'USD',now()) RETURNING id`,
+		uid).Scan(&txID); err != nil {
+		t.Fatal(err)
+	}
+	// Sums to zero globally but not per currency.
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO postings (transaction_id, account_id, amount_minor, currency) VALUES ($1,$2,-100,'USD'), ($1,$3,100,'EUR')`,
+		txID, usd, eur.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(ctx); err == nil {
+		t.Fatal("per-currency imbalance must fail at commit")
+	}
+}
+
+func TestFXRateLookupInverseAndCross(t *testing.T) {
+	ctx := context.Background()
+	fx := service.NewFXService(pool, q)
+	day := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	if _, err := fx.Import(ctx, []service.RateRow{
+		{Date: day, Base: "EUR", Quote: "USD", Rate: "1.25"},
+		{Date: day, Base: "EUR", Quote: "GBP", Rate: "0.8"},
+	}, "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A bad row rolls back the whole file.
+	if _, err := fx.Import(ctx, []service.RateRow{
+		{Date: day.AddDate(0, 0, 1), Base: "EUR", Quote: "GBP", Rate: "0.9"},
+		{Date: day.AddDate(0, 0, 1), Base: "EUR", Quote: "JPY", Rate: "-1"},
+	}, "test"); err == nil {
+		t.Fatal("import with a bad row must fail")
+	}
+	if r, err := fx.RateOn(ctx, "EUR", "GBP", day.AddDate(0, 0, 1)); err != nil || r.Rate != "0.8" {
+		t.Fatalf("partial import kept: %+v %v", r, err)
+	}
+
+	// A later day uses the latest rate published on or before it.
+	inv, err := fx.RateOn(ctx, "USD", "EUR", day.AddDate(0, 0, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Rate != "0.8" || inv.Via != "inverse" || inv.RateDate != "2026-03-31" {
+		t.Fatalf("unexpected inverse rate: %+v", inv)
+	}
+
+	cross, err := fx.RateOn(ctx, "USD", "GBP", day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cross.Rate != "0.64" || cross.Via != "EUR" {
+		t.Fatalf("unexpected cross rate: %+v", cross)
+	}
+
+	if _, err := fx.RateOn(ctx, "USD", "EUR", day.AddDate(0, 0, -1)); !errors.Is(err, service.ErrRateNotFound) {
+		t.Fatalf("want ErrRateNotFound before the first rate, got %v", err)
+	}
+
+	total, err := fx.ConvertTotal(ctx, []service.CurrencyAmount{
+		{Currency: "USD", AmountMinor: 1000},
+		{Currency: "EUR", AmountMinor: 500},
+	}, "EUR", day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.AmountMinor != 1300 || len(total.Rates) != 1 {
+		t.Fatalf("unexpected converted total: %+v", total)
+	}
+}
+
+func TestHoldCaptureVoidAndAvailableBalance(t *testing.T) {
+	ctx := context.Background()
+	uid, accID := newUserWithAccount(t, "USD")
+	holdSvc := service.NewHoldService(pool, q, txSvc)
+
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: accID, AmountMinor: 10000, Currency: "USD", OccurredAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	h1, err := holdSvc.Place(ctx, service.PlaceHoldInput{UserID: uid, AccountID: accID, AmountMinor: 3000, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := holdSvc.Place(ctx, service.PlaceHoldInput{UserID: uid, AccountID: accID, AmountMinor: 1000, Currency: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := balSvc.CurrentBalance(ctx, accID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Ledger != 10000 || b.Available != 6000 {
+		t.Fatalf("ledger=%d available=%d, want 10000/6000", b.Ledger, b.Available)
+	}
+
+	// Partial capture posts 2500 and releases the other 500.
+	res, err := holdSvc.Capture(ctx, service.CaptureHoldInput{UserID: uid, HoldID: h1.ID, AmountMinor: 2500, IdempotencyKey: "cap-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Hold.Status != "captured" || res.Hold.TransactionID != res.Transaction.ID {
+		t.Fatalf("unexpected capture result: %+v", res.Hold)
+	}
+	replay, err := holdSvc.Capture(ctx, service.CaptureHoldInput{UserID: uid, HoldID: h1.ID, AmountMinor: 2500, IdempotencyKey: "cap-1"})
+	if err != nil || replay.Transaction.ID != res.Transaction.ID {
+		t.Fatalf("replay should return the original capture, got %+v, %v", replay.Transaction, err)
+	}
+	if _, err := holdSvc.Capture(ctx, service.CaptureHoldInput{UserID: uid, HoldID: h1.ID}); !errors.Is(err, service.ErrHoldNotPending) {
+		t.Fatalf("want ErrHoldNotPending on second capture, got %v", err)
+	}
+
+	if _, err := holdSvc.Void(ctx, uid, h2.ID); err != nil {
+		t.Fatal(err)
+	}
+	b, err = balSvc.CurrentBalance(ctx, accID, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Ledger != 7500 || b.Available != 7500 {
+		t.Fatalf("ledger=%d available=%d, want 7500/7500", b.Ledger, b.Available)
+	}
+
+	// An expired hold stops counting at once and the sweep marks it expired.
+	h3, err := holdSvc.Place(ctx, service.PlaceHoldInput{
+		UserID: uid, AccountID: accID, AmountMinor: 500, Currency: "USD", ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(2 * time.Hour)
+	if b, _ := balSvc.CurrentBalance(ctx, accID, later); b.Available != 7500 {
+		t.Fatalf("expired hold still counted: available=%d", b.Available)
+	}
+	if _, err := q.ExpireHolds(ctx, later); err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := q.GetHoldForUser(ctx, sqlc.GetHoldForUserParams{ID: h3.ID, UserID: uid}); h.Status != "expired" {
+		t.Fatalf("status = %q, want expired", h.Status)
+	}
+}
+
+func TestBalanceFloorsHoldUnderConcurrentTransfers(t *testing.T) {
+	ctx := context.Background()
+	uid, src := newUserWithAccount(t, "USD")
+	dst, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "savings", Currency: "USD", Type: "asset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: src, AmountMinor: 1000, Currency: "USD", OccurredAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := balSvc.SetLimits(ctx, src, service.Limits{NeverNegative: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ten concurrent 300-cent transfers out of a 1000-cent account: exactly
+	// three fit above zero.
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = txSvc.Transfer(ctx, service.TransferInput{
+				UserID: uid, FromAccountID: src, ToAccountID: dst.ID, AmountMinor: 300,
+				Currency: "USD", OccurredAt: time.Now().UTC(),
+			})
+		}(i)
+	}
+	wg.Wait()
+	var okCount int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			okCount++
+		case errors.Is(err, service.ErrInsufficientFunds):
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if okCount != 3 {
+		t.Fatalf("%d transfers succeeded, want 3", okCount)
+	}
+	b, err := balSvc.CurrentBalance(ctx, src, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Ledger != 100 {
+		t.Fatalf("ledger = %d, want 100", b.Ledger)
+	}
+
+	// Holds count against the floor, and incoming money is never blocked.
+	holdSvc := service.NewHoldService(pool, q, txSvc)
+	if _, err := holdSvc.Place(ctx, service.PlaceHoldInput{UserID: uid, AccountID: src, AmountMinor: 200, Currency: "USD"}); !errors.Is(err, service.ErrInsufficientFunds) {
+		t.Fatalf("want ErrInsufficientFunds for an oversized hold, got %v", err)
+	}
+	overdraft := int64(500)
+	if _, err := balSvc.SetLimits(ctx, src, service.Limits{OverdraftLimitMinor: &overdraft}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := holdSvc.Place(ctx, service.PlaceHoldInput{UserID: uid, AccountID: src, AmountMinor: 500, Currency: "USD"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: src, AmountMinor: -200, Currency: "USD", OccurredAt: time.Now().UTC(),
+	}); !errors.Is(err, service.ErrInsufficientFunds) {
+		t.Fatalf("want ErrInsufficientFunds past the overdraft, got %v", err)
+	}
+}
+
+func TestReversalRespectsBalanceFloor(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	deposit, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: 1000, Currency: "USD", OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spend, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -800, Currency: "USD", OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := balSvc.SetLimits(ctx, acc, service.Limits{NeverNegative: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The deposit is already spent: reversing it would leave -800.
+	if _, err := txSvc.Reverse(ctx, service.ReverseInput{UserID: uid, TransactionID: deposit.ID}); !errors.Is(err, service.ErrInsufficientFunds) {
+		t.Fatalf("want ErrInsufficientFunds, got %v", err)
+	}
+	// Reversing the spending puts money back, which is always allowed.
+	if _, err := txSvc.Reverse(ctx, service.ReverseInput{UserID: uid, TransactionID: spend.ID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecurringRuleCatchesUpWithoutDoubleBooking(t *testing.T) {
+	ctx := context.Background()
+	uid, accID := newUserWithAccount(t, "USD")
+	recSvc := service.NewRecurringService(q, txSvc)
+
+	jan1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rule, err := recSvc.Create(ctx, service.RuleInput{
+		UserID: uid, Kind: "create", AccountID: accID, AmountMinor: -120000, Currency: "USD", Note: "rent",
+		Schedule: service.Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 1, Start: jan1},
+	}, jan1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	countTx := func() int {
+		var n int
+		if err := pool.QueryRow(ctx, `SELECT count(*) FROM transactions WHERE user_id=$1 AND note='rent'`, uid).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Jan, Feb and Mar are due by mid-March.
+	mar15 := time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+	if _, err := recSvc.RunDue(ctx, mar15); err != nil {
+		t.Fatal(err)
+	}
+	if n := countTx(); n != 3 {
+		t.Fatalf("booked %d occurrences, want 3", n)
+	}
+	got, err := recSvc.Get(ctx, uid, rule.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.NextRunDate != "2026-04-01" {
+		t.Fatalf("next_run_date = %s, want 2026-04-01", got.NextRunDate)
+	}
+
+	// Simulate a crash after booking but before advancing: rewind the rule
+	// and rerun. The per-occurrence keys replay instead of booking again.
+	if _, err := pool.Exec(ctx, `UPDATE recurring_rules SET next_run_date='2026-01-01' WHERE id=$1`, rule.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recSvc.RunDue(ctx, mar15); err != nil {
+		t.Fatal(err)
+	}
+	if n := countTx(); n != 3 {
+		t.Fatalf("rerun double-booked: %d occurrences, want 3", n)
+	}
+
+	// Skip drops April; pausing stops the rule entirely.
+	if got, err = recSvc.Skip(ctx, uid, rule.ID); err != nil || got.NextRunDate != "2026-05-01" {
+		t.Fatalf("skip: next_run_date = %s, err = %v", got.NextRunDate, err)
+	}
+	if _, err := recSvc.SetPaused(ctx, uid, rule.ID, true, mar15); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recSvc.RunDue(ctx, time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if n := countTx(); n != 3 {
+		t.Fatalf("paused rule booked: %d occurrences, want 3", n)
+	}
+}
+
+func TestCategoriesAssignAndRecategorize(t *testing.T) {
+	ctx := context.Background()
+	uid, accID := newUserWithAccount(t, "USD")
+	catSvc := service.NewCategoryService(pool, q)
+
+	food, err := catSvc.Create(ctx, uid, "Food", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groceries, err := catSvc.Create(ctx, uid, "Groceries", food.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := catSvc.Update(ctx, uid, food.ID, "Food", groceries.ID); !errors.Is(err, service.ErrCategoryCycle) {
+		t.Fatalf("want ErrCategoryCycle, got %v", err)
+	}
+
+	// Another user's category is invisible.
+	otherUID, otherAcc := newUserWithAccount(t, "USD")
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: otherUID, AccountID: otherAcc, AmountMinor: -100, Currency: "USD",
+		OccurredAt: time.Now().UTC(), CategoryID: food.ID,
+	}); !errors.Is(err, service.ErrCategoryNotFound) {
+		t.Fatalf("want ErrCategoryNotFound for a foreign category, got %v", err)
+	}
+
+	dto, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: accID, AmountMinor: -4200, Currency: "USD",
+		OccurredAt: time.Now().UTC(), CategoryID: groceries.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dto.CategoryID != groceries.ID {
+		t.Fatalf("category_id = %d, want %d", dto.CategoryID, groceries.ID)
+	}
+
+	if _, err := txSvc.Recategorize(ctx, service.RecategorizeInput{UserID: uid, TransactionID: dto.ID, CategoryID: food.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txSvc.Recategorize(ctx, service.RecategorizeInput{UserID: uid, TransactionID: dto.ID}); err != nil {
+		t.Fatal(err)
+	}
+	hist, err := txSvc.CategoryHistory(ctx, uid, dto.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != 2 || hist[0].OldCategoryID != groceries.ID || hist[0].NewCategoryID != food.ID || hist[1].NewCategoryID != 0 {
+		t.Fatalf("unexpected history: %+v", hist)
+	}
+
+	// Groceries appears in the history, so it cannot be deleted.
+	if err := catSvc.Delete(ctx, uid, groceries.ID); !errors.Is(err, service.ErrCategoryInUse) {
+		t.Fatalf("want ErrCategoryInUse, got %v", err)
+	}
+	unused, err := catSvc.Create(ctx, uid, "Unused", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := catSvc.Delete(ctx, uid, unused.ID); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBudgetRolloverAndExceededEvent(t *testing.T) {
+	ctx := context.Background()
+	uid, accID := newUserWithAccount(t, "USD")
+	catSvc := service.NewCategoryService(pool, q)
+	budgetSvc := service.NewBudgetService(q)
+
+	food, err := catSvc.Create(ctx, uid, "Food", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groceries, err := catSvc.Create(ctx, uid, "Groceries", food.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jan := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	b, err := budgetSvc.Create(ctx, service.BudgetInput{
+		UserID: uid, CategoryID: food.ID, Currency: "USD", AmountMinor: 10000, Rollover: true, StartMonth: jan,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spend := func(amount int64, at time.Time) {
+		t.Helper()
+		if _, err := txSvc.Create(ctx, service.CreateInput{
+			UserID: uid, AccountID: accID, AmountMinor: -amount, Currency: "USD", OccurredAt: at, CategoryID: groceries.ID,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exceeded := func() int {
+		t.Helper()
+		var n int
+		if err := pool.QueryRow(ctx,
+			`SELECT count(*) FROM outbox WHERE event_type = 'BudgetExceeded' AND (payload->>'budget_id')::bigint = $1`, b.ID).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// January leaves 4000 unspent, which rolls into February's 14000.
+	spend(6000, jan)
+	feb := jan.AddDate(0, 1, 0)
+	spend(13000, feb)
+	if n := exceeded(); n != 0 {
+		t.Fatalf("want no BudgetExceeded yet, got %d", n)
+	}
+	spend(2000, feb) // crosses the line
+	spend(500, feb)  // already over: stays quiet
+	if n := exceeded(); n != 1 {
+		t.Fatalf("want exactly 1 BudgetExceeded, got %d", n)
+	}
+
+	months, err := budgetSvc.Month(ctx, uid, feb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(months) != 1 {
+		t.Fatalf("want 1 budget, got %d", len(months))
+	}
+	st := months[0]
+	if st.BudgetedMinor != 14000 || st.RolledOverMinor != 4000 || st.SpentMinor != 15500 || st.RemainingMinor != -1500 || !st.Exceeded {
+		t.Fatalf("unexpected February status: %+v", st)
+	}
+}
+
+func TestSplitTransactionAggregatesByLineCategory(t *testing.T) {
+	ctx := context.Background()
+	uid, accID := newUserWithAccount(t, "USD")
+	catSvc := service.NewCategoryService(pool, q)
+
+	food, err := catSvc.Create(ctx, uid, "Food", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	household, err := catSvc.Create(ctx, uid, "Household", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2025, 3, 5, 12, 0, 0, 0, time.UTC)
+	if _, err := service.NewBudgetService(q).Create(ctx, service.BudgetInput{
+		UserID: uid, CategoryID: household.ID, Currency: "USD", AmountMinor: 2000, StartMonth: at,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// One receipt: 60.00 food, 30.00 household (over its 20.00 budget).
+	dto, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: accID, AmountMinor: -9000, Currency: "USD", OccurredAt: at, CategoryID: food.ID,
+		Splits: []service.Split{{AmountMinor: -6000}, {AmountMinor: -3000, CategoryID: household.ID}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dto.Postings) != 3 {
+		t.Fatalf("want 2 split lines + external leg, got %+v", dto.Postings)
+	}
+
+	totals, err := sumSvc.GetCategorySummary(ctx, uid, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[int64]int64{}
+	for _, c := range totals {
+		got[c.CategoryID] = c.Outflow
+	}
+	if got[food.ID] != 6000 || got[household.ID] != 3000 {
+		t.Fatalf("unexpected category totals: %+v", totals)
+	}
+
+	var exceeded int
+	if err := pool.QueryRow(ctx,
+		`SELECT count(*) FROM outbox WHERE event_type = 'BudgetExceeded' AND (payload->>'transaction_id')::bigint = $1`, dto.ID).Scan(&exceeded); err != nil {
+		t.Fatal(err)
+	}
+	if exceeded != 1 {
+		t.Fatalf("want 1 BudgetExceeded for the household line, got %d", exceeded)
+	}
+}
+
+func TestAttachmentsUploadDownloadDelete(t *testing.T) {
+	ctx := context.Background()
+	uid, accID := newUserWithAccount(t, "USD")
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	attSvc := service.NewAttachmentService(q, store)
+
+	dto, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: accID, AmountMinor: -1200, Currency: "USD", OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdf := []byte("%PDF-1.7\n% receipt\n")
+	att, err := attSvc.Upload(ctx, service.UploadInput{UserID: uid, TransactionID: dto.ID, Filename: "receipt.pdf", Body: pdf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.ContentType != "application/pdf" || att.SizeBytes != int64(len(pdf)) || len(att.SHA256) != 64 {
+		t.Fatalf("unexpected attachment: %+v", att)
+	}
+	if _, err := attSvc.Upload(ctx, service.UploadInput{UserID: uid, TransactionID: dto.ID, Filename: "x.txt", Body: []byte("hello")}); !errors.Is(err, service.ErrAttachmentType) {
+		t.Fatalf("want ErrAttachmentType, got %v", err)
+	}
+
+	// Another user sees neither the transaction nor the attachment.
+	otherUID, _ := newUserWithAccount(t, "USD")
+	if _, err := attSvc.List(ctx, otherUID, dto.ID); !errors.Is(err, service.ErrTxNotFound) {
+		t.Fatalf("want ErrTxNotFound, got %v", err)
+	}
+	if _, _, err := attSvc.Open(ctx, otherUID, dto.ID, att.ID); !errors.Is(err, service.ErrAttachmentNotFound) {
+		t.Fatalf("want ErrAttachmentNotFound, got %v", err)
+	}
+
+	_, body, err := attSvc.Open(ctx, uid, dto.ID, att.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(body)
+	_ = body.Close()
+	if string(got) != string(pdf) {
+		t.Fatalf("downloaded %q, want %q", got, pdf)
+	}
+
+	if err := attSvc.Delete(ctx, uid, dto.ID, att.ID); err != nil {
+		t.Fatal(err)
+	}
+	list, err := attSvc.List(ctx, uid, dto.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("want no attachments after delete, got %+v", list)
+	}
+}
+
+func TestSearchTransactionsFiltersAndPages(t *testing.T) {
+	ctx := context.Background()
+	uid, checking := newUserWithAccount(t, "USD")
+	savings, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "savings", Currency: "USD", Type: "asset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	food, err := service.NewCategoryService(pool, q).Create(ctx, uid, "Food", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	create := func(acc, amount int64, day int, note string, cat int64) service.TransactionDTO {
+		t.Helper()
+		dto, err := txSvc.Create(ctx, service.CreateInput{
+			UserID: uid, AccountID: acc, AmountMinor: amount, Currency: "USD",
+			OccurredAt: base.AddDate(0, 0, day), Note: note, CategoryID: cat,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dto
+	}
+	groceries := create(checking, -4500, 0, "Groceries 100%", food.ID)
+	create(checking, 250000, 1, "Salary", 0)
+	refund := create(savings.ID, 1999, 2, "Amazon refund", 0)
+	if _, err := txSvc.Reverse(ctx, service.ReverseInput{UserID: uid, TransactionID: groceries.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(f service.SearchFilter) []service.SearchHit {
+		t.Helper()
+		f.UserID = uid
+		f.CursorOccurredAt, f.CursorID = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), math.MaxInt64
+		if f.Limit == 0 {
+			f.Limit = 20
+		}
+		txs, err := txSvc.Search(ctx, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return txs
+	}
+	ids := func(txs []service.SearchHit) []int64 {
+		out := make([]int64, 0, len(txs))
+		for _, tx := range txs {
+			out = append(out, tx.ID)
+		}
+		return out
+	}
+
+	if got := search(service.SearchFilter{AccountIDs: []int64{savings.ID}}); len(got) != 1 || got[0].ID != refund.ID || len(got[0].Postings) != 2 {
+		t.Fatalf("account filter: %+v", got)
+	}
+	if got := search(service.SearchFilter{Note: "100%"}); len(got) != 1 || got[0].ID != groceries.ID {
+		t.Fatalf("note filter must match %% literally: %v", ids(got))
+	}
+	minAmt, maxAmt := int64(1000), int64(5000)
+	if got := search(service.SearchFilter{MinAmount: &minAmt, MaxAmount: &maxAmt, Reversal: "none"}); len(got) != 1 || got[0].ID != refund.ID {
+		t.Fatalf("amount + reversal filter: %v", ids(got))
+	}
+	if got := search(service.SearchFilter{Reversal: "reversed"}); len(got) != 1 || got[0].ID != groceries.ID {
+		t.Fatalf("reversed filter: %v", ids(got))
+	}
+	// The reversal inherits the category, so both show up under Food.
+	if got := search(service.SearchFilter{CategoryID: food.ID, From: base, To: base.AddDate(0, 0, 1)}); len(got) != 1 || got[0].ID != groceries.ID {
+		t.Fatalf("category + date filter: %v", ids(got))
+	}
+
+	// Two pages of two cover all four transactions exactly once.
+	first := search(service.SearchFilter{Limit: 2})
+	last := first[len(first)-1]
+	second, err := txSvc.Search(ctx, service.SearchFilter{UserID: uid, CursorOccurredAt: last.OccurredAt, CursorID: last.ID, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := append(ids(first), ids(second)...)
+	seen := map[int64]bool{}
+	for _, id := range all {
+		seen[id] = true
+	}
+	if len(all) != 4 || len(seen) != 4 {
+		t.Fatalf("pagination: %v", all)
+	}
+}
+
+func TestTransactionDetailLinksReversalAndEvents(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	food, err := service.NewCategoryService(pool, q).Create(ctx, uid, "Food", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -1200, Currency: "USD",
+		OccurredAt: time.Now().UTC(), CategoryID: food.ID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev, err := txSvc.Reverse(ctx, service.ReverseInput{UserID: uid, TransactionID: orig.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := txSvc.Detail(ctx, uid, orig.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Reversals) != 1 || d.Reversals[0] != rev.ID || len(d.Postings) != 2 {
+		t.Fatalf("detail: reversals=%v postings=%d", d.Reversals, len(d.Postings))
+	}
+	if d.Category == nil || d.Category.ID != food.ID {
+		t.Fatalf("category: %+v", d.Category)
+	}
+	if len(d.Events) == 0 || d.Events[0].EventType != "TransactionCreated" {
+		t.Fatalf("events: %+v", d.Events)
+	}
+
+	if d, err := txSvc.Detail(ctx, uid, rev.ID); err != nil || d.ReversalOf != orig.ID || len(d.Reversals) != 0 {
+		t.Fatalf("reversal detail: %+v, %v", d, err)
+	}
+	other, _ := newUserWithAccount(t, "USD")
+	if _, err := txSvc.Detail(ctx, other, orig.ID); !errors.Is(err, service.ErrTxNotFound) {
+		t.Fatalf("want ErrTxNotFound for another user, got %v", err)
+	}
+}
+
+func TestFullTextSearchRanksPayeeAndNote(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	create := func(amount int64, day int, payee, note string) service.TransactionDTO {
+		t.Helper()
+		dto, err := txSvc.Create(ctx, service.CreateInput{
+			UserID: uid, AccountID: acc, AmountMinor: amount, Currency: "USD",
+			OccurredAt: at.AddDate(0, 0, day), Payee: payee, Note: note,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dto
+	}
+	refund := create(1999, 0, "Amazon", "refund for headphones")
+	noteOnly := create(-500, 1, "Corner shop", "paid back a friend, amazon gift card refunds")
+	create(-4500, 2, "Whole Foods", "groceries")
+
+	search := func(f service.SearchFilter) []service.SearchHit {
+		t.Helper()
+		f.UserID = uid
+		f.CursorRank = float32(math.Inf(1))
+		f.CursorOccurredAt, f.CursorID = time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC), math.MaxInt64
+		if f.Limit == 0 {
+			f.Limit = 20
+		}
+		hits, err := txSvc.Search(ctx, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hits
+	}
+
+	// Prefixes match, stemming folds "refunds" into "refund", and the payee
+	// match outranks the note-only match despite being older.
+	hits := search(service.SearchFilter{Query: "amaz refund"})
+	if len(hits) != 2 || hits[0].ID != refund.ID || hits[1].ID != noteOnly.ID {
+		t.Fatalf("ranked hits: %+v", hits)
+	}
+	if hits[0].Rank <= hits[1].Rank || !strings.Contains(hits[0].Highlight, "<mark>") || hits[0].Payee != "Amazon" {
+		t.Fatalf("rank/highlight: %+v", hits[0])
+	}
+
+	// Paging by relevance resumes after the first hit.
+	next := search(service.SearchFilter{Query: "amaz refund", Limit: 1})
+	f := service.SearchFilter{UserID: uid, Query: "amaz refund", Limit: 1,
+		CursorRank: next[0].Rank, CursorOccurredAt: next[0].OccurredAt, CursorID: next[0].ID}
+	page, err := txSvc.Search(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 1 || page[0].ID != noteOnly.ID {
+		t.Fatalf("second page: %+v", page)
+	}
+
+	if got := search(service.SearchFilter{Query: "groceries"}); len(got) != 1 || got[0].Highlight == "" {
+		t.Fatalf("groceries: %+v", got)
+	}
+	// Payee and note are user text: the highlight escapes them, so only
+	// the <mark> tags are markup.
+	create(-100, 3, `<script>alert("x")</script> Evil & Co`, "")
+	got := search(service.SearchFilter{Query: "evil"})
+	if len(got) != 1 || strings.Contains(got[0].Highlight, "<script>") ||
+		!strings.Contains(got[0].Highlight, "&lt;script&gt;") || !strings.Contains(got[0].Highlight, "<mark>") {
+		t.Fatalf("escaped highlight: %+v", got)
+	}
+	if got := search(service.SearchFilter{Query: "netflix"}); len(got) != 0 {
+		t.Fatalf("want no hits, got %+v", got)
+	}
+	if _, err := txSvc.Search(ctx, service.SearchFilter{UserID: uid, Query: "the and", Limit: 20}); !errors.Is(err, service.ErrSearchQuery) {
+		t.Fatalf("want ErrSearchQuery for stop words only, got %v", err)
+	}
+}
+
+func TestAmendReversesAndReplacesAtomically(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	orig, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -4500, Currency: "USD",
+		OccurredAt: time.Now().UTC(), Note: "dinner",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	note := "dinner, with tip"
+	in := service.AmendInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 5400, Note: &note, IdempotencyKey: "amend-1"}
+	res, err := txSvc.Amend(ctx, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.OriginalID != orig.ID || res.Reversal.ReversalOf != orig.ID || res.Replacement.Amends != orig.ID {
+		t.Fatalf("links: %+v", res)
+	}
+	if res.Replacement.Note != note || !res.Replacement.OccurredAt.Equal(orig.OccurredAt) {
+		t.Fatalf("replacement must keep unset fields: %+v", res.Replacement)
+	}
+	var own int64
+	for _, p := range res.Replacement.Postings {
+		if p.AccountID == acc {
+			own += p.AmountMinor
+		}
+	}
+	if own != -5400 {
+		t.Fatalf("replacement posts %d to the account, want -5400", own)
+	}
+	if b, err := balSvc.CurrentBalance(ctx, acc, time.Now()); err != nil || b.Balance != -5400 {
+		t.Fatalf("balance = %+v, %v; want -5400", b, err)
+	}
+
+	// Replaying the key returns the same correction without booking another.
+	again, err := txSvc.Amend(ctx, in)
+	if err != nil || again.Replacement.ID != res.Replacement.ID {
+		t.Fatalf("replay: %+v, %v", again, err)
+	}
+	if _, err := txSvc.Amend(ctx, service.AmendInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 1}); !errors.Is(err, service.ErrAlreadyReversed) {
+		t.Fatalf("second amend of the original: want ErrAlreadyReversed, got %v", err)
+	}
+
+	d, err := txSvc.Detail(ctx, uid, orig.ID)
+	if err != nil || len(d.Reversals) != 1 || d.Reversals[0] != res.Reversal.ID || d.AmendedBy != res.Replacement.ID {
+		t.Fatalf("original detail: %+v, %v", d, err)
+	}
+	rd, err := txSvc.Detail(ctx, uid, res.Replacement.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var amended int
+	for _, e := range rd.Events {
+		if e.EventType == "TransactionAmended" {
+			amended++
+		}
+	}
+	if amended != 1 || len(rd.Events) != 1 {
+		t.Fatalf("want exactly one TransactionAmended event, got %+v", rd.Events)
+	}
+}
+
+func TestAmendRefusesRefundedTransaction(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	orig, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -100, Currency: "USD", OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txSvc.Refund(ctx, service.RefundInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 30}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-booking at 80 after reversing the remaining 70 would silently
+	// drop the refund, so the amend is refused and nothing changes.
+	if _, err := txSvc.Amend(ctx, service.AmendInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 80}); !errors.Is(err, service.ErrAmendRefunded) {
+		t.Fatalf("want ErrAmendRefunded, got %v", err)
+	}
+	if b, err := balSvc.CurrentBalance(ctx, acc, time.Now()); err != nil || b.Balance != -70 {
+		t.Fatalf("balance = %+v, %v; want -70", b, err)
+	}
+	d, err := txSvc.Detail(ctx, uid, orig.ID)
+	if err != nil || len(d.Reversals) != 1 || d.AmendedBy != 0 {
+		t.Fatalf("original detail: %+v, %v", d, err)
+	}
+}
+
+func TestPartialRefundsCannotExceedOriginal(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	orig, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -5000, Currency: "USD",
+		OccurredAt: time.Now().UTC(), Note: "order #1234",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := txSvc.Refund(ctx, service.RefundInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 1200, IdempotencyKey: "refund-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ReversalOf != orig.ID || len(first.Postings) != 2 {
+		t.Fatalf("refund: %+v", first)
+	}
+	if again, err := txSvc.Refund(ctx, service.RefundInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 1200, IdempotencyKey: "refund-1"}); err != nil || again.ID != first.ID {
+		t.Fatalf("replay: %+v, %v", again, err)
+	}
+	if _, err := txSvc.Refund(ctx, service.RefundInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 3801}); !errors.Is(err, service.ErrOverRefund) {
+		t.Fatalf("want ErrOverRefund, got %v", err)
+	}
+	if _, err := txSvc.Refund(ctx, service.RefundInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 800}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A full reversal now negates only the 30.00 that is left.
+	rest, err := txSvc.Reverse(ctx, service.ReverseInput{UserID: uid, TransactionID: orig.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range rest.Postings {
+		if p.AccountID == acc && p.AmountMinor != 3000 {
+			t.Fatalf("reversal of the remainder posts %d, want 3000", p.AmountMinor)
+		}
+	}
+	if _, err := txSvc.Reverse(ctx, service.ReverseInput{UserID: uid, TransactionID: orig.ID}); !errors.Is(err, service.ErrAlreadyReversed) {
+		t.Fatalf("want ErrAlreadyReversed once nothing is left, got %v", err)
+	}
+	if b, err := balSvc.CurrentBalance(ctx, acc, time.Now()); err != nil || b.Balance != 0 {
+		t.Fatalf("balance = %+v, %v; want 0", b, err)
+	}
+
+	d, err := txSvc.Detail(ctx, uid, orig.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Reversals) != 3 || d.Reversals[0] != first.ID || d.Reversals[2] != rest.ID {
+		t.Fatalf("reversals: %v", d.Reversals)
+	}
+	for _, p := range d.Postings {
+		if p.AmountMinor+p.ReversedMinor != 0 {
+			t.Fatalf("posting %d not fully reversed: %+v", p.ID, p)
+		}
+	}
+}
+
+func TestClosedPeriodRefusesBackdatedPostings(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	periods := service.NewPeriodService(pool, q)
+	lastMonth := time.Now().UTC().AddDate(0, -1, 0)
+
+	orig, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -2500, Currency: "USD", OccurredAt: lastMonth,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := periods.Close(ctx, uid, lastMonth, service.UserActor(uid)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := periods.Close(ctx, uid, lastMonth, service.UserActor(uid)); !errors.Is(err, service.ErrPeriodAlreadyClosed) {
+		t.Fatalf("second close: want ErrPeriodAlreadyClosed, got %v", err)
+	}
+
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -100, Currency: "USD", OccurredAt: lastMonth,
+	}); !errors.Is(err, service.ErrPeriodClosed) {
+		t.Fatalf("backdated create: want ErrPeriodClosed, got %v", err)
+	}
+	cat, err := service.NewCategoryService(pool, q).Create(ctx, uid, "Dining", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txSvc.Recategorize(ctx, service.RecategorizeInput{UserID: uid, TransactionID: orig.ID, CategoryID: cat.ID}); !errors.Is(err, service.ErrPeriodClosed) {
+		t.Fatalf("recategorize in a closed month: want ErrPeriodClosed, got %v", err)
+	}
+	// Corrections land in the open period.
+	res, err := txSvc.Amend(ctx, service.AmendInput{UserID: uid, TransactionID: orig.ID, AmountMinor: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if monthOf := func(t time.Time) string { return t.UTC().Format("2006-01") }; monthOf(res.Replacement.OccurredAt) != monthOf(time.Now()) {
+		t.Fatalf("replacement dated %v, want the current month", res.Replacement.OccurredAt)
+	}
+
+	if err := periods.Reopen(ctx, uid, lastMonth, service.UserActor(uid)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -100, Currency: "USD", OccurredAt: lastMonth,
+	}); err != nil {
+		t.Fatalf("after reopen: %v", err)
+	}
+	_, history, err := periods.List(ctx, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Action != "reopen" || history[1].Action != "close" || history[1].Actor != service.UserActor(uid) {
+		t.Fatalf("history: %+v", history)
+	}
+
+	// A global close binds every user, and only the admin can lift it.
+	old := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := periods.Close(ctx, 0, old, service.AdminActor); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = periods.Reopen(ctx, 0, old, service.AdminActor) }()
+	if _, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -100, Currency: "USD", OccurredAt: old.AddDate(0, 0, 3),
+	}); !errors.Is(err, service.ErrPeriodClosed) {
+		t.Fatalf("global close: want ErrPeriodClosed, got %v", err)
+	}
+	if err := periods.Reopen(ctx, uid, old, service.UserActor(uid)); !errors.Is(err, service.ErrPeriodNotClosed) {
+		t.Fatalf("user reopening a global close: want ErrPeriodNotClosed, got %v", err)
+	}
+}
+
+func TestPeriodLockIsPerUser(t *testing.T) {
+	ctx := context.Background()
+	closer, _ := newUserWithAccount(t, "USD")
+	uid, acc := newUserWithAccount(t, "USD")
+
+	// Hold the first user's close lock as an in-flight Close would.
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	if err := q.WithTx(tx).LockUserPeriodsExclusive(ctx, closer); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another user's posting must not wait for it.
+	wctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if _, err := txSvc.Create(wctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -100, Currency: "USD", OccurredAt: time.Now().UTC(),
+	}); err != nil {
+		t.Fatalf("posting blocked by another user's close: %v", err)
+	}
+}
+
+func TestDefaultCounterpartsAndLiabilities(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	income, err := q.CreateCounterpartAccount(ctx, sqlc.CreateCounterpartAccountParams{
+		UserID: uid, Name: "Salary", Currency: "USD", Type: service.AccountIncome,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	card, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "card", Currency: "USD", Type: service.AccountLiability})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Income goes against the default income account; spending, with no
+	// default expense account, against the catch-all.
+	pay, err := txSvc.Create(ctx, service.CreateInput{UserID: uid, AccountID: acc, AmountMinor: 100000, Currency: "USD", OccurredAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasPosting(pay, income.ID, -100000) {
+		t.Fatalf("income counterpart not used: %+v", pay.Postings)
+	}
+	spend, err := txSvc.Create(ctx, service.CreateInput{UserID: uid, AccountID: card.ID, AmountMinor: -5000, Currency: "USD", OccurredAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext, err := q.GetExternalAccount(ctx, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasPosting(spend, ext.ID, 5000) {
+		t.Fatalf("catch-all counterpart not used: %+v", spend.Postings)
+	}
+
+	nw, err := balSvc.NetWorth(ctx, uid, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nw.Totals[0].AmountMinor != 95000 || nw.Assets[0].AmountMinor != 100000 || nw.Liabilities[0].AmountMinor != 5000 {
+		t.Fatalf("net worth: %+v", nw)
+	}
+}
+
+func hasPosting(dto service.TransactionDTO, accountID, amount int64) bool {
+	for _, p := range dto.Postings {
+		if p.AccountID == accountID && p.AmountMinor == amount {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAccountTreeRollsUpBalances(t *testing.T) {
+	ctx := context.Background()
+	uid, _ := newUserWithAccount(t, "USD")
+	accounts := service.NewAccountService(pool, q)
+	mk := func(name string, parent int64) int64 {
+		t.Helper()
+		a, err := accounts.Create(ctx, service.CreateAccountInput{UserID: uid, Name: name, Currency: "USD", ParentID: parent})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return a.ID
+	}
+	assets := mk("Assets", 0)
+	bank := mk("Bank", assets)
+	checking := mk("Checking", bank)
+	cash := mk("Cash", assets)
+
+	// A snapshot on one account must be combined with its later postings.
+	day := time.Now().UTC().AddDate(0, 0, -1).Truncate(24 * time.Hour)
+	if err := q.UpsertBalanceSnapshot(ctx, sqlc.UpsertBalanceSnapshotParams{AccountID: checking, AsOfDate: day, BalanceMinor: 0}); err != nil {
+		t.Fatal(err)
+	}
+	for acc, amt := range map[int64]int64{checking: 7000, cash: 300, bank: 25} {
+		if _, err := txSvc.Create(ctx, service.CreateInput{UserID: uid, AccountID: acc, AmountMinor: amt, Currency: "USD", OccurredAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := balSvc.SubtreeBalances(ctx, assets, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Ledger != 7325 || got[0].Accounts != 4 {
+		t.Fatalf("Assets rollup: %+v", got)
+	}
+	got, err = balSvc.SubtreeBalances(ctx, bank, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Ledger != 7025 {
+		t.Fatalf("Bank rollup: %+v", got)
+	}
+	sums, err := sumSvc.GetSubtreeSummary(ctx, assets, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != 1 || sums[0].Inflow != 7325 {
+		t.Fatalf("Assets summary: %+v", sums)
+	}
+
+	if _, err := accounts.SetParent(ctx, uid, assets, checking); !errors.Is(err, service.ErrAccountCycle) {
+		t.Fatalf("cycle: want ErrAccountCycle, got %v", err)
+	}
+	if _, err := accounts.Create(ctx, service.CreateAccountInput{
+		UserID: uid, Name: "Card", Type: service.AccountLiability, ParentID: assets,
+	}); !errors.Is(err, service.ErrAccountParent) {
+		t.Fatalf("type mismatch: want ErrAccountParent, got %v", err)
+	}
+	if _, err := accounts.SetParent(ctx, uid, cash, bank); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := accounts.Tree(ctx, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	var walk func([]*service.AccountNode)
+	walk = func(ns []*service.AccountNode) {
+		for _, n := range ns {
+			paths = append(paths, n.Path)
+			walk(n.Children)
+		}
+	}
+	walk(tree)
+	want := "Assets Assets:Bank Assets:Bank:Cash Assets:Bank:Checking"
+	if !strings.Contains(strings.Join(paths, " "), want) {
+		t.Fatalf("tree paths: %v", paths)
+	}
+}
+
+func TestConcurrentReparentingCannotFormCycle(t *testing.T) {
+	ctx := context.Background()
+	uid, _ := newUserWithAccount(t, "USD")
+	accounts := service.NewAccountService(pool, q)
+	var ids [2]int64
+	for i, name := range []string{"A", "B"} {
+		a, err := accounts.Create(ctx, service.CreateAccountInput{UserID: uid, Name: name, Currency: "USD"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = a.ID
+	}
+
+	// A under B and B under A: each passes the cycle check alone, so only
+	// one may win.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = accounts.SetParent(ctx, uid, ids[i], ids[1-i])
+		}(i)
+	}
+	wg.Wait()
+
+	ok := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case !errors.Is(err, service.ErrAccountCycle):
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if ok != 1 {
+		t.Fatalf("want exactly one move to succeed, got %d (%v)", ok, errs)
+	}
+}
+
+func TestConcurrentCategoryMovesCannotFormCycle(t *testing.T) {
+	ctx := context.Background()
+	uid, _ := newUserWithAccount(t, "USD")
+	catSvc := service.NewCategoryService(pool, q)
+	var cats [2]service.CategoryDTO
+	for i, name := range []string{"A", "B"} {
+		c, err := catSvc.Create(ctx, uid, name, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cats[i] = c
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range cats {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = catSvc.Update(ctx, uid, cats[i].ID, cats[i].Name, cats[1-i].ID)
+		}(i)
+	}
+	wg.Wait()
+
+	ok := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case !errors.Is(err, service.ErrCategoryCycle):
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if ok != 1 {
+		t.Fatalf("want exactly one move to succeed, got %d (%v)", ok, errs)
+	}
+}
+
+func TestTrialBalanceNetsToZero(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	card, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "card", Currency: "USD", Type: service.AccountLiability})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eur, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "eur", Currency: "EUR", Type: service.AccountAsset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastWeek := time.Now().UTC().AddDate(0, 0, -7)
+	for _, in := range []service.CreateInput{
+		{UserID: uid, AccountID: acc, AmountMinor: 50000, Currency: "USD", OccurredAt: lastWeek},
+		{UserID: uid, AccountID: card.ID, AmountMinor: -1200, Currency: "USD", OccurredAt: lastWeek},
+		{UserID: uid, AccountID: eur.ID, AmountMinor: 3000, Currency: "EUR", OccurredAt: lastWeek},
+		{UserID: uid, AccountID: acc, AmountMinor: -700, Currency: "USD", OccurredAt: time.Now().UTC()},
+	} {
+		if _, err := txSvc.Create(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := txSvc.Transfer(ctx, service.TransferInput{
+		UserID: uid, FromAccountID: acc, ToAccountID: card.ID, AmountMinor: 1200, Currency: "USD", OccurredAt: lastWeek,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reports := service.NewReportService(q)
+	tb, err := reports.TrialBalance(ctx, uid, lastWeek.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tot := range tb.Totals {
+		if !tot.Balanced || tot.Balance != 0 {
+			t.Fatalf("total %+v", tot)
+		}
+	}
+	lines := map[int64]service.TrialBalanceLine{}
+	for _, l := range tb.Accounts {
+		lines[l.AccountID] = l
+	}
+	// Today's expense is after the cutoff.
+	if l := lines[acc]; l.Debit != 50000 || l.Credit != 1200 || l.Balance != 48800 {
+		t.Fatalf("checking line: %+v", l)
+	}
+	if l := lines[card.ID]; l.Balance != 0 || l.NormalBalance != "credit" {
+		t.Fatalf("card line: %+v", l)
+	}
+	if len(tb.Totals) != 2 {
+		t.Fatalf("want USD and EUR totals, got %+v", tb.Totals)
+	}
+}
+
+func TestBalanceSheetAndIncomeStatement(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	for _, typ := range []string{service.AccountIncome, service.AccountExpense} {
+		if _, err := q.CreateCounterpartAccount(ctx, sqlc.CreateCounterpartAccountParams{
+			UserID: uid, Name: typ, Currency: "USD", Type: typ,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	card, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "card", Currency: "USD", Type: service.AccountLiability})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jan := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	feb := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	for _, in := range []service.CreateInput{
+		{UserID: uid, AccountID: acc, AmountMinor: 500000, Currency: "USD", OccurredAt: jan},
+		{UserID: uid, AccountID: card.ID, AmountMinor: -12000, Currency: "USD", OccurredAt: jan},
+		{UserID: uid, AccountID: acc, AmountMinor: 500000, Currency: "USD", OccurredAt: feb},
+		{UserID: uid, AccountID: acc, AmountMinor: -80000, Currency: "USD", OccurredAt: feb},
+	} {
+		if _, err := txSvc.Create(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reports := service.NewReportService(q)
+	bs, err := reports.BalanceSheet(ctx, uid, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	usd := bs.Currencies[0]
+	if usd.TotalAssets != 920000 || usd.TotalLiabilities != 12000 || usd.TotalEquity != 908000 || !usd.Balanced {
+		t.Fatalf("balance sheet: %+v", usd)
+	}
+
+	is, err := reports.IncomeStatement(ctx, uid, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(is.Months) != 2 || len(is.Currencies) != 1 {
+		t.Fatalf("income statement: %+v", is)
+	}
+	net := is.Currencies[0].NetIncome
+	if net.Months[0] != 488000 || net.Months[1] != 420000 || net.Total != usd.TotalEquity {
+		t.Fatalf("net income: %+v", net)
+	}
+}
+
+func TestCashFlowIgnoresInternalTransfers(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	savings, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "savings", Currency: "USD", Type: service.AccountAsset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feb := time.Date(2025, 2, 10, 12, 0, 0, 0, time.UTC)
+	mar := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, in := range []service.CreateInput{
+		{UserID: uid, AccountID: acc, AmountMinor: 300000, Currency: "USD", OccurredAt: feb},
+		{UserID: uid, AccountID: acc, AmountMinor: 300000, Currency: "USD", OccurredAt: mar},
+		{UserID: uid, AccountID: acc, AmountMinor: -45000, Currency: "USD", OccurredAt: mar},
+	} {
+		if _, err := txSvc.Create(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := txSvc.Transfer(ctx, service.TransferInput{
+		UserID: uid, FromAccountID: acc, ToAccountID: savings.ID, AmountMinor: 100000, Currency: "USD", OccurredAt: mar,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	reports := service.NewReportService(q)
+	cf, err := reports.CashFlow(ctx, service.CashFlowInput{
+		UserID: uid, From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cf.Currencies) != 1 || len(cf.Currencies[0].Buckets) != 1 {
+		t.Fatalf("cash flow: %+v", cf)
+	}
+	usd := cf.Currencies[0]
+	if got := usd.Buckets[0].MonthlySummary; got != (service.MonthlySummary{Inflow: 300000, Outflow: 45000, Net: 255000}) {
+		t.Fatalf("March: %+v", got)
+	}
+	if usd.PreviousTotal.Inflow != 300000 || usd.Change.Outflow != 45000 {
+		t.Fatalf("trend: %+v", usd)
+	}
+
+	// Selecting savings alone: the transfer into it is still not income.
+	cf, err = reports.CashFlow(ctx, service.CashFlowInput{
+		UserID: uid, From: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		AccountIDs: []int64{savings.ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cf.Currencies) != 0 {
+		t.Fatalf("savings cash flow: %+v", cf.Currencies)
+	}
+}
+
+func TestBalanceAsOfBothBases(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	now := time.Now().UTC()
+	for _, in := range []service.CreateInput{
+		{UserID: uid, AccountID: acc, AmountMinor: 1000, Currency: "USD", OccurredAt: now.AddDate(0, 0, -10)},
+		{UserID: uid, AccountID: acc, AmountMinor: 500, Currency: "USD", OccurredAt: now.AddDate(0, 0, 3)},
+	} {
+		if _, err := txSvc.Create(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Five days ago the backdated entry had happened but was not yet known.
+	check := func(asOf time.Time, basis string, want int64, snapshot string) {
+		t.Helper()
+		b, err := balSvc.BalanceAsOf(ctx, acc, asOf, basis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Balance != want || b.SnapshotDate != snapshot {
+			t.Fatalf("%s as of %s: %+v, want %d from snapshot %q", basis, asOf, b, want, snapshot)
+		}
+	}
+	check(now.AddDate(0, 0, -5), service.BasisOccurred, 1000, "")
+	check(now.AddDate(0, 0, -5), service.BasisRecorded, 0, "")
+
+	// A snapshot of today counts both postings; the occurred basis takes the
+	// future-dated one back out.
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if err := q.UpsertBalanceSnapshot(ctx, sqlc.UpsertBalanceSnapshotParams{AccountID: acc, AsOfDate: today, BalanceMinor: 1500}); err != nil {
+		t.Fatal(err)
+	}
+	tomorrow := today.AddDate(0, 0, 1).Add(time.Hour)
+	check(tomorrow, service.BasisOccurred, 1000, today.Format("2006-01-02"))
+	check(tomorrow, service.BasisRecorded, 1500, today.Format("2006-01-02"))
+
+	if _, err := balSvc.BalanceAsOf(ctx, acc, now, "booked"); !errors.Is(err, service.ErrBalanceBasis) {
+		t.Fatalf("want ErrBalanceBasis, got %v", err)
+	}
+}
+
+func TestBalanceAndNetWorthHistory(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	card, err := q.CreateAccount(ctx, sqlc.CreateAccountParams{UserID: uid, Name: "card", Currency: "USD", Type: service.AccountLiability})
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 12, 0, 0, 0, time.UTC) }
+	for _, in := range []service.CreateInput{
+		{UserID: uid, AccountID: acc, AmountMinor: 1000, Currency: "USD", OccurredAt: day(2)},
+		{UserID: uid, AccountID: acc, AmountMinor: -200, Currency: "USD", OccurredAt: day(4)},
+		{UserID: uid, AccountID: card.ID, AmountMinor: -300, Currency: "USD", OccurredAt: day(4)},
+	} {
+		if _, err := txSvc.Create(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	in := service.HistoryInput{From: day(1), To: day(5), Basis: service.BasisOccurred}
+	h, err := balSvc.History(ctx, acc, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, p := range h.Points {
+		got = append(got, p.Balance)
+	}
+	if fmt.Sprint(got) != "[0 1000 1000 800 800]" || h.Points[0].Date != "2025-01-01" {
+		t.Fatalf("history: %+v", h.Points)
+	}
+
+	nw, err := balSvc.NetWorthHistory(ctx, uid, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := nw.Points[len(nw.Points)-1]; len(last.Totals) != 1 || last.Totals[0].AmountMinor != 500 {
+		t.Fatalf("net worth history: %+v", nw.Points)
+	}
+
+	// A range starting mid-history opens at BalanceAsOf's figure.
+	open, err := balSvc.BalanceAsOf(ctx, acc, day(3).Truncate(24*time.Hour), service.BasisOccurred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err = balSvc.History(ctx, acc, service.HistoryInput{From: day(3), To: day(4), Basis: service.BasisOccurred})
+	if err != nil || open.Ledger != 1000 || h.Points[0].Balance != open.Ledger {
+		t.Fatalf("opening: %+v %+v %v", open, h.Points, err)
+	}
+
+	// Recorded basis: everything was recorded today, after the range.
+	in.Basis = service.BasisRecorded
+	if h, err = balSvc.History(ctx, acc, in); err != nil || h.Points[4].Balance != 0 {
+		t.Fatalf("recorded history: %+v %v", h.Points, err)
+	}
+}
+
+func TestStatementGenerate(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stSvc := service.NewStatementService(q, store)
+	day := func(m time.Month, d int) time.Time { return time.Date(2025, m, d, 12, 0, 0, 0, time.UTC) }
+	for _, in := range []service.CreateInput{
+		{UserID: uid, AccountID: acc, AmountMinor: 1000, Currency: "USD", OccurredAt: day(2, 20)},
+		{UserID: uid, AccountID: acc, AmountMinor: 250, Currency: "USD", OccurredAt: day(3, 5)},
+		{UserID: uid, AccountID: acc, AmountMinor: -400, Currency: "USD", OccurredAt: day(3, 31)},
+		{UserID: uid, AccountID: acc, AmountMinor: -50, Currency: "USD", OccurredAt: day(4, 1)},
+	} {
+		if _, err := txSvc.Create(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st, err := stSvc.Request(ctx, uid, acc, day(3, 1), day(3, 31))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stSvc.Open(ctx, uid, st.ID, service.StatementCSV); !errors.Is(err, service.ErrStatementNotReady) {
+		t.Fatalf("want ErrStatementNotReady, got %v", err)
+	}
+	if err := stSvc.Generate(ctx, st.ID); err != nil {
+		t.Fatal(err)
+	}
+	if st, err = stSvc.Get(ctx, uid, st.ID); err != nil {
+		t.Fatal(err)
+	}
+	if st.Status != "done" || st.Opening != 1000 || st.Closing != 850 || st.Inflow != 250 || st.Outflow != 400 || st.Entries != 2 {
+		t.Fatalf("statement: %+v", st)
+	}
+
+	body, err := stSvc.Open(ctx, uid, st.ID, service.StatementCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = body.Close() }()
+	b, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "Closing balance") {
+		t.Fatalf("csv:\n%s", b)
+	}
+
+	other, _ := newUserWithAccount(t, "USD")
+	if _, err := stSvc.Get(ctx, other, st.ID); !errors.Is(err, service.ErrStatementNotFound) {
+		t.Fatalf("want ErrStatementNotFound, got %v", err)
+	}
+}
+
+func TestReconciliationWorkflow(t *testing.T) {
+	ctx := context.Background()
+	uid, acc := newUserWithAccount(t, "USD")
+	recSvc := service.NewReconciliationService(pool, q)
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.UTC) }
+	book := func(amount int64, on time.Time) {
+		t.Helper()
+		if _, err := txSvc.Create(ctx, service.CreateInput{
+			UserID: uid, AccountID: acc, AmountMinor: amount, Currency: "USD", OccurredAt: on,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	book(1000, day(2))
+	book(-250, day(5))
+	book(-80, day(9)) // a cheque the bank has not cleared yet
+	book(-40, day(12))
+
+	r, err := recSvc.Create(ctx, service.ReconciliationInput{
+		UserID: uid, AccountID: acc, StatementDate: day(15), ClosingBalance: 705, WindowDays: 3,
+		Lines: []service.BankLine{
+			{Date: day(3), AmountMinor: 1000, Description: "deposit"},
+			{Date: day(5), AmountMinor: -250, Description: "rent"},
+			{Date: day(14), AmountMinor: -45, Description: "card fee"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.UnmatchedLines != 1 || *r.ClearedBalance != 750 || *r.Difference != -45 || len(r.Outstanding) != 2 {
+		t.Fatalf("after auto-match: %+v", r)
+	}
+
+	// Matching the fee to the 40 posting by hand leaves a difference of -5.
+	var fee int64
+	for _, p := range r.Outstanding {
+		if p.AmountMinor == -40 {
+			fee = p.PostingID
+		}
+	}
+	line := r.Lines[2].ID
+	if r, err = recSvc.Match(ctx, uid, r.ID, line, fee); err != nil || *r.Difference != -5 {
+		t.Fatalf("manual match: %+v %v", r, err)
+	}
+	if _, err := recSvc.Complete(ctx, uid, r.ID); !errors.Is(err, service.ErrReconciliationDifference) {
+		t.Fatalf("want ErrReconciliationDifference, got %v", err)
+	}
+
+	// Booking the real fee and matching again balances the session.
+	if r, err = recSvc.Unmatch(ctx, uid, r.ID, line); err != nil {
+		t.Fatal(err)
+	}
+	book(-45, day(14))
+	if r, err = recSvc.AutoMatch(ctx, uid, r.ID, 3); err != nil || r.UnmatchedLines != 0 || *r.Difference != 0 {
+		t.Fatalf("auto-match after booking: %+v %v", r, err)
+	}
+	if r, err = recSvc.Complete(ctx, uid, r.ID); err != nil || r.Status != "completed" {
+		t.Fatalf("complete: %+v %v", r, err)
+	}
+	if _, err := recSvc.Unmatch(ctx, uid, r.ID, line); !errors.Is(err, service.ErrReconciliationCompleted) {
+		t.Fatalf("want ErrReconciliationCompleted, got %v", err)
+	}
+
+	// The next statement starts from the cleared 705; reconciled postings
+	// are neither outstanding nor matchable.
+	next, err := recSvc.Create(ctx, service.ReconciliationInput{
+		UserID: uid, AccountID: acc, StatementDate: day(31), ClosingBalance: 625,
+		Lines: []service.BankLine{{Date: day(20), AmountMinor: -80}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.UnmatchedLines != 1 || *next.ClearedBalance != 705 || len(next.Outstanding) != 2 {
+		t.Fatalf("next session: %+v", next)
+	}
+	matched := *r.Lines[0].PostingID
+	if _, err := recSvc.Match(ctx, uid, next.ID, next.Lines[0].ID, matched); !errors.Is(err, service.ErrPostingMatched) {
+		t.Fatalf("want ErrPostingMatched, got %v", err)
+	}
+
+	// A posting dated after the statement cannot clear it.
+	late, err := txSvc.Create(ctx, service.CreateInput{
+		UserID: uid, AccountID: acc, AmountMinor: -80, Currency: "USD", OccurredAt: day(31).AddDate(0, 0, 1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var latePosting int64
+	for _, p := range late.Postings {
+		if p.AccountID == acc {
+			latePosting = p.ID
+		}
+	}
+	if _, err := recSvc.Match(ctx, uid, next.ID, next.Lines[0].ID, latePosting); !errors.Is(err, service.ErrInvalidReconciliation) {
+		t.Fatalf("late posting: want ErrInvalidReconciliation, got %v", err)
+	}
+
+	other, _ := newUserWithAccount(t, "USD")
+	if _, err := recSvc.Get(ctx, other, next.ID); !errors.Is(err, service.ErrReconciliationNotFound) {
+		t.Fatalf("want ErrReconciliationNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+-- name: UpsertAccountLimits :one
+INSERT INTO account_limits (account_id, min_balance_minor, overdraft_limit_minor, never_negative)
+VALUES (sqlc.arg(account_id), sqlc.narg(min_balance_minor), sqlc.narg(overdraft_limit_minor), sqlc.arg(never_negative))
+ON CONFLICT (account_id) DO UPDATE
+SET min_balance_minor     = EXCLUDED.min_balance_minor,
+    overdraft_limit_minor = EXCLUDED.overdraft_limit_minor,
+    never_negative        = EXCLUDED.never_negative,
+    updated_at            = now()
+RETURNING account_id, min_balance_minor, overdraft_limit_minor, never_negative, updated_at;
+
+-- name: GetAccountLimits :one
+SELECT account_id, min_balance_minor, overdraft_limit_minor, never_negative, updated_at
+FROM account_limits
+WHERE account_id = sqlc.arg(account_id);
+
+-- Locks the limits rows of the given accounts in id order, so two
+-- transactions touching the same accounts always lock them in the same
+-- order and cannot deadlock. Accounts without limits return no row.
+-- name: LockAccountLimits :many
+SELECT account_id, min_balance_minor, overdraft_limit_minor, never_negative, updated_at
+FROM account_limits
+WHERE account_id = ANY(sqlc.arg(account_ids)::bigint[])
+ORDER BY account_id
+FOR UPDATE;
@@ -1,29 +1,118 @@
 -- name: CreateAccount :one
-INSERT INTO accounts (user_id, name, currency)
-VALUES ($1, $2, $3)
-RETURNING id, user_id, name, currency, is_active, kind, created_at;
+INSERT INTO accounts (user_id, name, currency, type, parent_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at;
 
+-- The catch-all counterpart for a user's dealings with the outside world.
 -- name: CreateExternalAccount :one
-INSERT INTO accounts (user_id, name, currency, kind)
-VALUES ($1, 'External', $2, 'external')
-RETURNING id, user_id, name, currency, is_active, kind, created_at;
+INSERT INTO accounts (user_id, name, currency, kind, type)
+VALUES ($1, 'External', $2, 'external', 'equity')
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at;
 
 -- name: GetExternalAccount :one
-SELECT id, user_id, name, currency, is_active, kind, created_at
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
 FROM accounts
-WHERE user_id = $1 AND kind = 'external';
+WHERE user_id = $1 AND kind = 'external' AND type = 'equity';
+
+-- An external income or expense account that takes the counterpart of
+-- income or spending instead of the catch-all.
+-- name: CreateCounterpartAccount :one
+INSERT INTO accounts (user_id, name, currency, kind, type)
+VALUES ($1, $2, $3, 'external', $4)
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at;
+
+-- name: GetCounterpartAccount :one
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+FROM accounts
+WHERE user_id = $1 AND kind = 'external' AND type = $2;
+
+-- name: CreateFXAccount :one
+INSERT INTO accounts (user_id, name, currency, kind, type)
+VALUES ($1, 'FX Trading', $2, 'fx', 'equity')
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at;
+
+-- name: GetFXAccount :one
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+FROM accounts
+WHERE user_id = $1 AND kind = 'fx';
 
 -- name: ListAccountsByUser :many
-SELECT id, user_id, name, currency, is_active, kind, created_at
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
 FROM accounts
 WHERE user_id = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
 -- name: GetAccount :one
-SELECT id, user_id, name, currency, is_active, kind, created_at
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
 FROM accounts
 WHERE id = $1;
 
 -- name: DeactivateAccount :exec
 UPDATE accounts SET is_active = FALSE WHERE id = $1;
+
+-- name: ListNormalAccountsByUser :many
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+FROM accounts
+WHERE user_id = $1 AND kind = 'normal'
+ORDER BY id;
+
+-- name: ListAllAccountsByUser :many
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+FROM accounts
+WHERE user_id = $1
+ORDER BY name, id;
+
+-- name: SetAccountParent :one
+UPDATE accounts SET parent_id = sqlc.narg(parent_id)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at;
+
+-- Serializes re-parenting within one user's chart of accounts, so two
+-- concurrent moves cannot each pass the cycle check and together form a
+-- loop.
+-- name: LockAccountTree :exec
+SELECT pg_advisory_xact_lock(hashtextextended('ledgerx.account_tree:' || sqlc.arg(user_id)::bigint, 0));
+
+-- True when candidate is ancestor itself or sits anywhere below it; used to
+-- refuse re-parenting that would create a cycle. The subtree walks use
+-- UNION so a cycle in the data still terminates.
+-- name: AccountIsInSubtree :one
+WITH RECURSIVE subtree AS (
+  SELECT a.id FROM accounts a WHERE a.id = sqlc.arg(ancestor_id)
+  UNION
+  SELECT a.id FROM accounts a JOIN subtree s ON a.parent_id = s.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE id = sqlc.arg(candidate_id)) AS in_subtree;
+
+-- Ledger balance and pending holds of an account and every account below
+-- it, per currency. Each account counts as in CurrentBalance: its latest
+-- snapshot plus the postings created after the snapshot's day, so the
+-- whole subtree costs one round trip.
+-- name: SubtreeBalances :many
+WITH RECURSIVE subtree AS (
+  SELECT a.id, a.currency FROM accounts a WHERE a.id = sqlc.arg(account_id)
+  UNION
+  SELECT a.id, a.currency FROM accounts a JOIN subtree s ON a.parent_id = s.id
+),
+latest AS (
+  SELECT DISTINCT ON (bs.account_id) bs.account_id, bs.as_of_date, bs.balance_minor
+  FROM balance_snapshots bs
+  WHERE bs.account_id IN (SELECT id FROM subtree)
+  ORDER BY bs.account_id, bs.as_of_date DESC
+)
+SELECT s.currency,
+       count(*)::int AS accounts,
+       COALESCE(SUM(COALESCE(l.balance_minor, 0) + (
+         SELECT COALESCE(SUM(p.amount_minor), 0) FROM postings p
+         WHERE p.account_id = s.id
+           AND (l.as_of_date IS NULL OR p.created_at >= (l.as_of_date + 1)::timestamp AT TIME ZONE 'UTC')
+       )), 0)::bigint AS ledger_minor,
+       COALESCE(SUM((
+         SELECT COALESCE(SUM(h.amount_minor), 0) FROM holds h
+         WHERE h.account_id = s.id AND h.status = 'pending' AND h.expires_at > sqlc.arg(now)::timestamptz
+       )), 0)::bigint AS held_minor
+FROM subtree s
+LEFT JOIN latest l ON l.account_id = s.id
+GROUP BY s.currency
+ORDER BY s.currency;
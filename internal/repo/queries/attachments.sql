@@ -0,0 +1,20 @@
+-- name: CreateAttachment :one
+INSERT INTO attachments (transaction_id, user_id, filename, content_type, size_bytes, sha256, storage_key)
+VALUES (sqlc.arg(transaction_id), sqlc.arg(user_id), sqlc.arg(filename), sqlc.arg(content_type),
+        sqlc.arg(size_bytes), sqlc.arg(sha256), sqlc.arg(storage_key))
+RETURNING id, transaction_id, user_id, filename, content_type, size_bytes, sha256, storage_key, created_at;
+
+-- name: GetAttachmentForUser :one
+SELECT id, transaction_id, user_id, filename, content_type, size_bytes, sha256, storage_key, created_at
+FROM attachments
+WHERE id = sqlc.arg(id) AND transaction_id = sqlc.arg(transaction_id) AND user_id = sqlc.arg(user_id);
+
+-- name: ListAttachmentsByTransaction :many
+SELECT id, transaction_id, user_id, filename, content_type, size_bytes, sha256, storage_key, created_at
+FROM attachments
+WHERE transaction_id = sqlc.arg(transaction_id)
+ORDER BY id;
+
+-- name: DeleteAttachment :execrows
+DELETE FROM attachments
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
@@ -23,3 +23,112 @@ SELECT COALESCE(SUM(amount_minor), 0)::bigint AS delta
 FROM postings
 WHERE account_id = sqlc.arg(account_id)
   AND created_at > sqlc.arg(since);
+
+-- The newest snapshot taken for a day on or before as_of_date.
+-- name: GetSnapshotOnOrBefore :one
+SELECT id, account_id, as_of_date, balance_minor, created_at
+FROM balance_snapshots
+WHERE account_id = sqlc.arg(account_id) AND as_of_date <= sqlc.arg(as_of_date)
+ORDER BY as_of_date DESC
+LIMIT 1;
+
+-- Postings recorded in (since, before): what the system learned between a
+-- snapshot cutoff and a later instant.
+-- name: SumPostingsRecordedBetween :one
+SELECT COALESCE(SUM(amount_minor), 0)::bigint AS delta
+FROM postings
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at > sqlc.arg(since)
+  AND created_at < sqlc.arg(before);
+
+-- Turns a recorded-basis snapshot cut at since into an occurred-basis
+-- balance for entries dated before before: adds postings recorded after the
+-- snapshot but dated before the cutoff, and takes out postings the snapshot
+-- counted that are dated at or after it.
+-- name: SumPostingsOccurredDelta :one
+SELECT COALESCE(SUM(CASE WHEN p.created_at > sqlc.arg(since) THEN p.amount_minor ELSE -p.amount_minor END), 0)::bigint AS delta
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.account_id = sqlc.arg(account_id)
+  AND ((p.created_at > sqlc.arg(since) AND t.occurred_at < sqlc.arg(before)::timestamptz)
+    OR (p.created_at <= sqlc.arg(since) AND t.occurred_at >= sqlc.arg(before)::timestamptz));
+
+-- Balance history reads each series for a whole set of accounts at once,
+-- so a net-worth history costs the same round trips as one account's.
+-- name: ListSnapshotsBetween :many
+SELECT id, account_id, as_of_date, balance_minor, created_at
+FROM balance_snapshots
+WHERE account_id = ANY(sqlc.arg(account_ids)::bigint[])
+  AND as_of_date >= sqlc.arg(from_date) AND as_of_date <= sqlc.arg(to_date)
+ORDER BY account_id, as_of_date;
+
+-- Net postings per account and UTC day of recording (created_at) in
+-- [from, to); days without postings are absent.
+-- name: DailyPostingsRecorded :many
+SELECT account_id,
+       (created_at AT TIME ZONE 'UTC')::date AS day,
+       SUM(amount_minor)::bigint AS delta
+FROM postings
+WHERE account_id = ANY(sqlc.arg(account_ids)::bigint[])
+  AND created_at >= sqlc.arg(from_time) AND created_at < sqlc.arg(to_time)
+GROUP BY 1, 2
+ORDER BY 1, 2;
+
+-- DailyPostingsRecorded by accounting date (occurred_at) instead.
+-- name: DailyPostingsOccurred :many
+SELECT p.account_id,
+       (t.occurred_at AT TIME ZONE 'UTC')::date AS day,
+       SUM(p.amount_minor)::bigint AS delta
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.account_id = ANY(sqlc.arg(account_ids)::bigint[])
+  AND t.occurred_at >= sqlc.arg(from_time)::timestamptz AND t.occurred_at < sqlc.arg(to_time)::timestamptz
+GROUP BY 1, 2
+ORDER BY 1, 2;
+
+-- BalanceAsOf on the recorded basis for several accounts: each account's
+-- newest snapshot up to snapshot_date, plus the postings recorded from the
+-- end of that day until as_of. Accounts without a snapshot sum every
+-- posting recorded before as_of.
+-- name: OpeningBalancesRecorded :many
+WITH latest AS (
+  SELECT DISTINCT ON (bs.account_id) bs.account_id, bs.balance_minor,
+         (bs.as_of_date + 1)::timestamp AT TIME ZONE 'UTC' AS cutoff
+  FROM balance_snapshots bs
+  WHERE bs.account_id = ANY(sqlc.arg(account_ids)::bigint[])
+    AND bs.as_of_date <= sqlc.arg(snapshot_date)
+  ORDER BY bs.account_id, bs.as_of_date DESC
+)
+SELECT a.id AS account_id,
+       (COALESCE(l.balance_minor, 0) + (
+         SELECT COALESCE(SUM(p.amount_minor), 0) FROM postings p
+         WHERE p.account_id = a.id
+           AND p.created_at >= COALESCE(l.cutoff, '-infinity')
+           AND p.created_at < sqlc.arg(as_of)::timestamptz
+       ))::bigint AS ledger_minor
+FROM unnest(sqlc.arg(account_ids)::bigint[]) AS a(id)
+LEFT JOIN latest l ON l.account_id = a.id;
+
+-- OpeningBalancesRecorded on the occurred basis, moving the postings whose
+-- accounting date and recording fall on opposite sides of as_of, as
+-- SumPostingsOccurredDelta does for one account.
+-- name: OpeningBalancesOccurred :many
+WITH latest AS (
+  SELECT DISTINCT ON (bs.account_id) bs.account_id, bs.balance_minor,
+         (bs.as_of_date + 1)::timestamp AT TIME ZONE 'UTC' AS cutoff
+  FROM balance_snapshots bs
+  WHERE bs.account_id = ANY(sqlc.arg(account_ids)::bigint[])
+    AND bs.as_of_date <= sqlc.arg(snapshot_date)
+  ORDER BY bs.account_id, bs.as_of_date DESC
+)
+SELECT a.id AS account_id,
+       (COALESCE(l.balance_minor, 0) + (
+         SELECT COALESCE(SUM(CASE WHEN p.created_at >= COALESCE(l.cutoff, '-infinity') THEN p.amount_minor ELSE -p.amount_minor END), 0)
+         FROM postings p
+         JOIN transactions t ON t.id = p.transaction_id
+         WHERE p.account_id = a.id
+           AND ((p.created_at >= COALESCE(l.cutoff, '-infinity') AND t.occurred_at < sqlc.arg(as_of)::timestamptz)
+             OR (p.created_at < COALESCE(l.cutoff, '-infinity') AND t.occurred_at >= sqlc.arg(as_of)::timestamptz))
+       ))::bigint AS ledger_minor
+FROM unnest(sqlc.arg(account_ids)::bigint[]) AS a(id)
+LEFT JOIN latest l ON l.account_id = a.id;
@@ -0,0 +1,80 @@
+-- name: CreateBudget :one
+INSERT INTO budgets (user_id, category_id, currency, amount_minor, rollover, start_month)
+VALUES (sqlc.arg(user_id), sqlc.arg(category_id), sqlc.arg(currency), sqlc.arg(amount_minor),
+        sqlc.arg(rollover), sqlc.arg(start_month))
+RETURNING id, user_id, category_id, currency, amount_minor, rollover, start_month, created_at, updated_at;
+
+-- name: GetBudgetForUser :one
+SELECT id, user_id, category_id, currency, amount_minor, rollover, start_month, created_at, updated_at
+FROM budgets
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
+
+-- Budgets already in effect in month (start_month on or before it).
+-- name: ListBudgetsForMonth :many
+SELECT id, user_id, category_id, currency, amount_minor, rollover, start_month, created_at, updated_at
+FROM budgets
+WHERE user_id = sqlc.arg(user_id) AND start_month <= sqlc.arg(month)::date
+ORDER BY id;
+
+-- name: UpdateBudget :one
+UPDATE budgets
+SET amount_minor = sqlc.arg(amount_minor), rollover = sqlc.arg(rollover), updated_at = now()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING id, user_id, category_id, currency, amount_minor, rollover, start_month, created_at, updated_at;
+
+-- name: DeleteBudget :execrows
+DELETE FROM budgets
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
+
+-- Budgets in effect in month on any of category_ids or their ancestors,
+-- locked so concurrent postings into the same budget check it one at a time.
+-- name: LockBudgetsCoveringCategories :many
+WITH RECURSIVE ancestors AS (
+  SELECT c.id, c.parent_id FROM categories c WHERE c.id = ANY(sqlc.arg(category_ids)::bigint[])
+  UNION
+  SELECT c.id, c.parent_id FROM categories c JOIN ancestors a ON c.id = a.parent_id
+)
+SELECT b.id, b.user_id, b.category_id, b.currency, b.amount_minor, b.rollover, b.start_month, b.created_at, b.updated_at
+FROM budgets b
+WHERE b.category_id IN (SELECT id FROM ancestors) AND b.start_month <= sqlc.arg(month)::date
+ORDER BY b.id
+FOR UPDATE OF b;
+
+-- Net outflow from the user's asset and liability accounts, per month, of
+-- postings whose line category (the header's when the line has none) is
+-- category_id or any category below it. Refunds into the category reduce
+-- spending; months without activity are absent.
+-- name: BudgetSpendByMonth :many
+WITH RECURSIVE subtree AS (
+  SELECT c.id FROM categories c WHERE c.id = sqlc.arg(category_id)
+  UNION
+  SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT date_trunc('month', t.occurred_at AT TIME ZONE 'UTC')::date AS month,
+       COALESCE(SUM(-p.amount_minor), 0)::bigint AS spent
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+JOIN accounts a ON a.id = p.account_id
+WHERE COALESCE(p.category_id, t.category_id) IN (SELECT id FROM subtree)
+  AND a.kind = 'normal' AND a.type IN ('asset', 'liability')
+  AND p.currency = sqlc.arg(currency)
+  AND t.occurred_at >= sqlc.arg(from_time)::timestamptz
+  AND t.occurred_at < sqlc.arg(to_time)::timestamptz
+GROUP BY 1
+ORDER BY 1;
+
+-- What one transaction adds to a budget's spending (see BudgetSpendByMonth).
+-- name: TransactionSpend :one
+WITH RECURSIVE subtree AS (
+  SELECT c.id FROM categories c WHERE c.id = sqlc.arg(category_id)
+  UNION
+  SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT COALESCE(SUM(-p.amount_minor), 0)::bigint AS spent
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+JOIN accounts a ON a.id = p.account_id
+WHERE p.transaction_id = sqlc.arg(transaction_id)
+  AND COALESCE(p.category_id, t.category_id) IN (SELECT id FROM subtree)
+  AND a.kind = 'normal' AND a.type IN ('asset', 'liability')
+  AND p.currency = sqlc.arg(currency);
@@ -0,0 +1,61 @@
+-- name: CreateCategory :one
+INSERT INTO categories (user_id, name, parent_id)
+VALUES (sqlc.arg(user_id), sqlc.arg(name), sqlc.narg(parent_id))
+RETURNING id, user_id, name, created_at, parent_id;
+
+-- name: GetCategoryForUser :one
+SELECT id, user_id, name, created_at, parent_id
+FROM categories
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
+
+-- name: ListCategoriesByUser :many
+SELECT id, user_id, name, created_at, parent_id
+FROM categories
+WHERE user_id = sqlc.arg(user_id)
+ORDER BY id;
+
+-- name: UpdateCategory :one
+UPDATE categories
+SET name = sqlc.arg(name), parent_id = sqlc.narg(parent_id)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING id, user_id, name, created_at, parent_id;
+
+-- name: DeleteCategory :execrows
+DELETE FROM categories
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
+
+-- A category is in use while a transaction or split line, a child category
+-- or the re-categorization history refers to it.
+-- name: CategoryInUse :one
+SELECT EXISTS (SELECT 1 FROM transactions WHERE category_id = sqlc.arg(id))
+    OR EXISTS (SELECT 1 FROM postings WHERE category_id = sqlc.arg(id))
+    OR EXISTS (SELECT 1 FROM categories WHERE parent_id = sqlc.arg(id))
+    OR EXISTS (SELECT 1 FROM transaction_category_changes
+               WHERE old_category_id = sqlc.arg(id) OR new_category_id = sqlc.arg(id)) AS in_use;
+
+-- Serializes re-parenting within one user's category tree, like
+-- LockAccountTree.
+-- name: LockCategoryTree :exec
+SELECT pg_advisory_xact_lock(hashtextextended('ledgerx.category_tree:' || sqlc.arg(user_id)::bigint, 0));
+
+-- True when candidate is ancestor itself or sits anywhere below it; used to
+-- refuse re-parenting that would create a cycle. UNION keeps the walk
+-- finite even if a cycle exists.
+-- name: CategoryIsInSubtree :one
+WITH RECURSIVE subtree AS (
+  SELECT c.id FROM categories c WHERE c.id = sqlc.arg(ancestor_id)
+  UNION
+  SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT EXISTS (SELECT 1 FROM subtree WHERE id = sqlc.arg(candidate_id)) AS in_subtree;
+
+-- name: CreateCategoryChange :one
+INSERT INTO transaction_category_changes (transaction_id, user_id, old_category_id, new_category_id)
+VALUES (sqlc.arg(transaction_id), sqlc.arg(user_id), sqlc.narg(old_category_id), sqlc.narg(new_category_id))
+RETURNING id, transaction_id, user_id, old_category_id, new_category_id, changed_at;
+
+-- name: ListCategoryChanges :many
+SELECT id, transaction_id, user_id, old_category_id, new_category_id, changed_at
+FROM transaction_category_changes
+WHERE transaction_id = sqlc.arg(transaction_id)
+ORDER BY id;
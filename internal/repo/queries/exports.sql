@@ -3,6 +3,11 @@ INSERT INTO exports (user_id, month, status)
 VALUES ($1, $2, 'pending')
 RETURNING *;
 
+-- name: CreateReportExport :one
+INSERT INTO exports (user_id, month, status, kind, range_start, range_end)
+VALUES ($1, $2, 'pending', $3, $4, $5)
+RETURNING *;
+
 -- name: UpdateExportStatus :exec
 UPDATE exports
 SET status = $2, file_path = $3, updated_at = now()
@@ -12,16 +17,21 @@ WHERE id = $1;
 SELECT * FROM exports WHERE id = $1;
 
 -- Export rows are postings on the user's normal accounts; the offsetting
--- external legs would only duplicate every line.
+-- external legs would only duplicate every line. category_id is the line
+-- category, falling back to the header's; attachment_ids lists the
+-- transaction's attachments as ';'-separated ids.
 -- name: ListPostingsForMonth :many
 SELECT t.id AS transaction_id,
        p.account_id,
        a.name AS account_name,
-       t.category_id,
+       a.type AS account_type,
+       COALESCE(p.category_id, t.category_id) AS category_id,
        p.amount_minor,
-       t.currency,
+       p.currency,
        t.occurred_at,
-       t.note
+       t.note,
+       COALESCE((SELECT string_agg(at.id::text, ';' ORDER BY at.id)
+                 FROM attachments at WHERE at.transaction_id = t.id), '')::text AS attachment_ids
 FROM postings p
 JOIN transactions t ON t.id = p.transaction_id
 JOIN accounts a ON a.id = p.account_id
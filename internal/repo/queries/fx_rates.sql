@@ -0,0 +1,13 @@
+-- name: UpsertFXRate :exec
+INSERT INTO fx_rates (base, quote, rate_date, rate, source)
+VALUES (sqlc.arg(base), sqlc.arg(quote), sqlc.arg(rate_date), sqlc.arg(rate)::text::numeric, sqlc.arg(source))
+ON CONFLICT (base, quote, rate_date)
+DO UPDATE SET rate = EXCLUDED.rate, source = EXCLUDED.source;
+
+-- The rate effective on a day is the latest one published on or before it.
+-- name: GetFXRateOnOrBefore :one
+SELECT base, quote, rate_date, rate::text AS rate, source
+FROM fx_rates
+WHERE base = sqlc.arg(base) AND quote = sqlc.arg(quote) AND rate_date <= sqlc.arg(on_date)
+ORDER BY rate_date DESC
+LIMIT 1;
@@ -0,0 +1,58 @@
+-- name: CreateHold :one
+INSERT INTO holds (user_id, account_id, amount_minor, currency, note, expires_at)
+VALUES (sqlc.arg(user_id), sqlc.arg(account_id), sqlc.arg(amount_minor), sqlc.arg(currency),
+        sqlc.narg(note), sqlc.arg(expires_at))
+RETURNING id, user_id, account_id, amount_minor, currency, note, status, expires_at,
+          transaction_id, captured_minor, created_at, updated_at;
+
+-- name: GetHoldForUser :one
+SELECT id, user_id, account_id, amount_minor, currency, note, status, expires_at,
+       transaction_id, captured_minor, created_at, updated_at
+FROM holds
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
+
+-- Row lock so capture, void and the expiry sweep serialize on a hold.
+-- name: LockHoldForUser :one
+SELECT id, user_id, account_id, amount_minor, currency, note, status, expires_at,
+       transaction_id, captured_minor, created_at, updated_at
+FROM holds
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+FOR UPDATE;
+
+-- name: CaptureHold :one
+UPDATE holds
+SET status = 'captured', transaction_id = sqlc.arg(transaction_id),
+    captured_minor = sqlc.arg(captured_minor), updated_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING id, user_id, account_id, amount_minor, currency, note, status, expires_at,
+          transaction_id, captured_minor, created_at, updated_at;
+
+-- name: VoidHold :one
+UPDATE holds
+SET status = 'voided', updated_at = now()
+WHERE id = sqlc.arg(id) AND status = 'pending'
+RETURNING id, user_id, account_id, amount_minor, currency, note, status, expires_at,
+          transaction_id, captured_minor, created_at, updated_at;
+
+-- name: ExpireHolds :execrows
+UPDATE holds
+SET status = 'expired', updated_at = now()
+WHERE status = 'pending' AND expires_at <= sqlc.arg(now);
+
+-- Holds past expires_at stop counting immediately, even before the expiry
+-- sweep has flipped their status.
+-- name: SumPendingHolds :one
+SELECT COALESCE(SUM(amount_minor), 0)::bigint AS held
+FROM holds
+WHERE account_id = sqlc.arg(account_id)
+  AND status = 'pending'
+  AND expires_at > sqlc.arg(now);
+
+-- name: ListHoldsByAccount :many
+SELECT id, user_id, account_id, amount_minor, currency, note, status, expires_at,
+       transaction_id, captured_minor, created_at, updated_at
+FROM holds
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status))
+ORDER BY id DESC
+LIMIT sqlc.arg(page_limit);
@@ -2,3 +2,10 @@
 INSERT INTO outbox (event_type, payload)
 VALUES ($1, $2)
 RETURNING id, created_at;
+
+-- Events whose payload names the transaction, oldest first.
+-- name: ListOutboxEventsForTransaction :many
+SELECT id, event_type, payload, created_at, processed_at
+FROM outbox
+WHERE (payload->>'transaction_id')::bigint = sqlc.arg(transaction_id)::bigint
+ORDER BY id;
@@ -0,0 +1,51 @@
+-- Posting paths take the period locks shared and closing takes one
+-- exclusive, so a close waits for in-flight backdated postings and every
+-- posting after it sees the close. Each user has a lock of their own, and
+-- the global lock is only taken exclusive by global closes, so one user's
+-- close never waits on, or stalls, another user's postings.
+-- name: LockPeriodsShared :exec
+SELECT pg_advisory_xact_lock_shared(hashtext('ledgerx.closed_periods')::bigint),
+       pg_advisory_xact_lock_shared(hashtextextended('ledgerx.closed_periods:' || sqlc.arg(user_id)::bigint, 0));
+
+-- name: LockPeriodsExclusive :exec
+SELECT pg_advisory_xact_lock(hashtext('ledgerx.closed_periods')::bigint);
+
+-- name: LockUserPeriodsExclusive :exec
+SELECT pg_advisory_xact_lock(hashtextextended('ledgerx.closed_periods:' || sqlc.arg(user_id)::bigint, 0));
+
+-- Whether month is closed for the user, by the user or globally.
+-- name: IsPeriodClosed :one
+SELECT EXISTS (
+  SELECT 1 FROM closed_periods
+  WHERE month = sqlc.arg(month)::date
+    AND (user_id = sqlc.arg(user_id)::bigint OR user_id IS NULL)
+) AS closed;
+
+-- user_id NULL closes the month globally. Returns no row if already closed.
+-- name: ClosePeriod :one
+INSERT INTO closed_periods (user_id, month, closed_by)
+VALUES (sqlc.narg(user_id), sqlc.arg(month), sqlc.arg(closed_by))
+ON CONFLICT DO NOTHING
+RETURNING id, user_id, month, closed_by, closed_at;
+
+-- name: ReopenPeriod :execrows
+DELETE FROM closed_periods
+WHERE month = sqlc.arg(month) AND user_id IS NOT DISTINCT FROM sqlc.narg(user_id);
+
+-- name: InsertPeriodAudit :exec
+INSERT INTO period_close_audit (user_id, month, action, actor)
+VALUES (sqlc.narg(user_id), sqlc.arg(month), sqlc.arg(action), sqlc.arg(actor));
+
+-- The user's own closes and the global ones, newest month first.
+-- name: ListClosedPeriods :many
+SELECT id, user_id, month, closed_by, closed_at
+FROM closed_periods
+WHERE user_id = sqlc.arg(user_id)::bigint OR user_id IS NULL
+ORDER BY month DESC, id;
+
+-- name: ListPeriodAudit :many
+SELECT id, user_id, month, action, actor, created_at
+FROM period_close_audit
+WHERE user_id = sqlc.arg(user_id)::bigint OR user_id IS NULL
+ORDER BY id DESC
+LIMIT sqlc.arg(page_limit);
@@ -0,0 +1,93 @@
+-- name: CreateReconciliation :one
+INSERT INTO reconciliations (user_id, account_id, statement_date, closing_balance_minor, currency)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: CreateReconciliationLine :exec
+INSERT INTO reconciliation_lines (reconciliation_id, line_no, occurred_on, amount_minor, description)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetReconciliation :one
+SELECT * FROM reconciliations WHERE id = $1;
+
+-- Serializes changes to one session.
+-- name: LockReconciliation :one
+SELECT * FROM reconciliations WHERE id = $1 FOR UPDATE;
+
+-- Serializes completing the sessions of one account, so each computes its
+-- cleared balance with the postings the others reconciled.
+-- name: LockReconciliationAccount :exec
+SELECT pg_advisory_xact_lock(hashtextextended('ledgerx.reconcile:' || sqlc.arg(account_id)::bigint, 0));
+
+-- name: ListReconciliationsByAccount :many
+SELECT * FROM reconciliations
+WHERE account_id = $1
+ORDER BY statement_date DESC, id DESC;
+
+-- name: DeleteOpenReconciliation :execrows
+DELETE FROM reconciliations WHERE id = $1 AND status = 'open';
+
+-- name: ListReconciliationLines :many
+SELECT * FROM reconciliation_lines
+WHERE reconciliation_id = $1
+ORDER BY line_no;
+
+-- name: MatchReconciliationLine :execrows
+UPDATE reconciliation_lines
+SET posting_id = sqlc.arg(posting_id), matched_by = sqlc.arg(matched_by)
+WHERE id = sqlc.arg(id) AND reconciliation_id = sqlc.arg(reconciliation_id);
+
+-- name: UnmatchReconciliationLine :execrows
+UPDATE reconciliation_lines
+SET posting_id = NULL, matched_by = NULL
+WHERE id = $1 AND reconciliation_id = $2;
+
+-- Postings of the account dated in [from, to) that no completed
+-- reconciliation has cleared and no line of this session is matched to,
+-- oldest first.
+-- name: ListReconciliationCandidates :many
+SELECT p.id AS posting_id, p.transaction_id, t.occurred_at, p.amount_minor, t.payee, t.note
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.account_id = sqlc.arg(account_id)
+  AND t.occurred_at >= sqlc.arg(from_time)::timestamptz
+  AND t.occurred_at < sqlc.arg(to_time)::timestamptz
+  AND NOT EXISTS (SELECT 1 FROM reconciled_postings rp WHERE rp.posting_id = p.id)
+  AND NOT EXISTS (
+    SELECT 1 FROM reconciliation_lines l
+    WHERE l.reconciliation_id = sqlc.arg(reconciliation_id) AND l.posting_id = p.id
+  )
+ORDER BY t.occurred_at, p.id;
+
+-- name: GetReconciliationPosting :one
+SELECT p.id, p.account_id, p.amount_minor, t.occurred_at,
+       EXISTS (SELECT 1 FROM reconciled_postings rp WHERE rp.posting_id = p.id) AS reconciled
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.id = $1;
+
+-- The account's cleared balance: postings reconciled before, plus those
+-- matched in this session.
+-- name: ClearedBalance :one
+SELECT COALESCE(SUM(p.amount_minor), 0)::bigint AS cleared_minor
+FROM postings p
+WHERE p.account_id = sqlc.arg(account_id)
+  AND (
+    EXISTS (SELECT 1 FROM reconciled_postings rp WHERE rp.posting_id = p.id)
+    OR EXISTS (
+      SELECT 1 FROM reconciliation_lines l
+      WHERE l.reconciliation_id = sqlc.arg(reconciliation_id) AND l.posting_id = p.id
+    )
+  );
+
+-- Fails with a unique violation if another session has cleared one of the
+-- postings since it was matched.
+-- name: InsertReconciledPostings :execrows
+INSERT INTO reconciled_postings (posting_id, reconciliation_id)
+SELECT posting_id, reconciliation_id FROM reconciliation_lines
+WHERE reconciliation_id = $1 AND posting_id IS NOT NULL;
+
+-- name: CompleteReconciliation :exec
+UPDATE reconciliations
+SET status = 'completed', cleared_balance_minor = $2, difference_minor = $3, completed_at = now()
+WHERE id = $1;
@@ -0,0 +1,60 @@
+-- name: CreateRecurringRule :one
+INSERT INTO recurring_rules (user_id, kind, account_id, to_account_id, amount_minor, currency, note,
+                             frequency, interval_count, day_of_month, start_date, end_date, next_run_date)
+VALUES (sqlc.arg(user_id), sqlc.arg(kind), sqlc.arg(account_id), sqlc.narg(to_account_id),
+        sqlc.arg(amount_minor), sqlc.arg(currency), sqlc.narg(note), sqlc.arg(frequency),
+        sqlc.arg(interval_count), sqlc.narg(day_of_month), sqlc.arg(start_date), sqlc.narg(end_date),
+        sqlc.arg(next_run_date))
+RETURNING id, user_id, kind, account_id, to_account_id, amount_minor, currency, note, frequency, interval_count,
+       day_of_month, start_date, end_date, next_run_date, paused, last_error, created_at, updated_at;
+
+-- name: GetRecurringRuleForUser :one
+SELECT id, user_id, kind, account_id, to_account_id, amount_minor, currency, note, frequency, interval_count,
+       day_of_month, start_date, end_date, next_run_date, paused, last_error, created_at, updated_at
+FROM recurring_rules
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
+
+-- name: ListRecurringRulesByUser :many
+SELECT id, user_id, kind, account_id, to_account_id, amount_minor, currency, note, frequency, interval_count,
+       day_of_month, start_date, end_date, next_run_date, paused, last_error, created_at, updated_at
+FROM recurring_rules
+WHERE user_id = sqlc.arg(user_id)
+ORDER BY id;
+
+-- Replaces the editable fields; kind and accounts are fixed at creation.
+-- name: UpdateRecurringRule :one
+UPDATE recurring_rules
+SET amount_minor = sqlc.arg(amount_minor), note = sqlc.narg(note), frequency = sqlc.arg(frequency),
+    interval_count = sqlc.arg(interval_count), day_of_month = sqlc.narg(day_of_month),
+    start_date = sqlc.arg(start_date), end_date = sqlc.narg(end_date),
+    next_run_date = sqlc.arg(next_run_date), updated_at = now()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING id, user_id, kind, account_id, to_account_id, amount_minor, currency, note, frequency, interval_count,
+       day_of_month, start_date, end_date, next_run_date, paused, last_error, created_at, updated_at;
+
+-- name: SetRecurringRuleState :one
+UPDATE recurring_rules
+SET paused = sqlc.arg(paused), next_run_date = sqlc.arg(next_run_date), updated_at = now()
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+RETURNING id, user_id, kind, account_id, to_account_id, amount_minor, currency, note, frequency, interval_count,
+       day_of_month, start_date, end_date, next_run_date, paused, last_error, created_at, updated_at;
+
+-- name: DeleteRecurringRule :execrows
+DELETE FROM recurring_rules
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
+
+-- name: ListDueRecurringRules :many
+SELECT id, user_id, kind, account_id, to_account_id, amount_minor, currency, note, frequency, interval_count,
+       day_of_month, start_date, end_date, next_run_date, paused, last_error, created_at, updated_at
+FROM recurring_rules
+WHERE NOT paused
+  AND next_run_date <= sqlc.arg(today)
+  AND (end_date IS NULL OR next_run_date <= end_date)
+ORDER BY id;
+
+-- Moves a rule past one occurrence. Conditional on the date it was read
+-- with, so two workers racing on the same rule advance it only once.
+-- name: AdvanceRecurringRule :execrows
+UPDATE recurring_rules
+SET next_run_date = sqlc.arg(next_run_date), last_error = sqlc.narg(last_error), updated_at = now()
+WHERE id = sqlc.arg(id) AND next_run_date = sqlc.arg(current_run_date);
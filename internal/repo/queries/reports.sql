@@ -0,0 +1,63 @@
+-- Every account of the user with its debit and credit turnover and closing
+-- balance over entries dated before the cutoff, one row per currency the
+-- account has posted in (its own currency when it has none). Accounts are
+-- listed in chart order: assets, liabilities, equity, income, expenses.
+-- name: TrialBalance :many
+SELECT a.id AS account_id, a.name, a.kind, a.type, a.parent_id,
+       COALESCE(p.currency, a.currency)::text AS currency,
+       COALESCE(SUM(CASE WHEN p.amount_minor > 0 THEN p.amount_minor ELSE 0 END), 0)::bigint AS debit_minor,
+       COALESCE(SUM(CASE WHEN p.amount_minor < 0 THEN -p.amount_minor ELSE 0 END), 0)::bigint AS credit_minor,
+       COALESCE(SUM(p.amount_minor), 0)::bigint AS balance_minor
+FROM accounts a
+LEFT JOIN (postings p JOIN transactions t ON t.id = p.transaction_id)
+  ON p.account_id = a.id AND t.occurred_at < sqlc.arg(before)::timestamptz
+WHERE a.user_id = sqlc.arg(user_id)
+GROUP BY a.id, COALESCE(p.currency, a.currency)
+ORDER BY array_position(ARRAY['asset', 'liability', 'equity', 'income', 'expense'], a.type), a.name, a.id, 6;
+
+-- Income and expense postings of the user dated in [from, to), summed per
+-- account, currency and UTC calendar month. Months without activity are
+-- absent; the service lays the rows out into columns.
+-- name: IncomeStatement :many
+SELECT a.id AS account_id, a.name, a.type, a.parent_id, p.currency,
+       date_trunc('month', t.occurred_at AT TIME ZONE 'UTC')::date AS month,
+       SUM(p.amount_minor)::bigint AS amount_minor
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+JOIN accounts a ON a.id = p.account_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND a.type IN ('income', 'expense')
+  AND t.occurred_at >= sqlc.arg(from_time)::timestamptz
+  AND t.occurred_at < sqlc.arg(to_time)::timestamptz
+GROUP BY a.id, p.currency, 6
+ORDER BY array_position(ARRAY['income', 'expense'], a.type), a.name, a.id, p.currency, 6;
+
+-- Inflow and outflow on the user's asset and liability accounts (or the
+-- given ones; empty = all) per UTC bucket ('month' or 'week', ISO weeks
+-- starting Monday) and currency, over entries dated in [from, to).
+-- Internal transfers are left out: a transaction counts only if some
+-- posting leaves those accounts for one other than an FX account, so
+-- moving money between your own accounts, even across currencies, is not
+-- cash flow.
+-- name: CashFlow :many
+SELECT date_trunc(sqlc.arg(bucket)::text, t.occurred_at AT TIME ZONE 'UTC')::date AS bucket_start,
+       p.currency,
+       COALESCE(SUM(CASE WHEN p.amount_minor > 0 THEN p.amount_minor ELSE 0 END), 0)::bigint AS inflow,
+       COALESCE(SUM(CASE WHEN p.amount_minor < 0 THEN -p.amount_minor ELSE 0 END), 0)::bigint AS outflow,
+       COALESCE(SUM(p.amount_minor), 0)::bigint AS net
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+JOIN accounts a ON a.id = p.account_id
+WHERE t.user_id = sqlc.arg(user_id)
+  AND a.kind = 'normal' AND a.type IN ('asset', 'liability')
+  AND (cardinality(sqlc.arg(account_ids)::bigint[]) = 0 OR p.account_id = ANY(sqlc.arg(account_ids)::bigint[]))
+  AND t.occurred_at >= sqlc.arg(from_time)::timestamptz
+  AND t.occurred_at < sqlc.arg(to_time)::timestamptz
+  AND EXISTS (
+    SELECT 1 FROM postings o JOIN accounts oa ON oa.id = o.account_id
+    WHERE o.transaction_id = t.id
+      AND oa.kind <> 'fx'
+      AND NOT (oa.kind = 'normal' AND oa.type IN ('asset', 'liability'))
+  )
+GROUP BY 1, 2
+ORDER BY 2, 1;
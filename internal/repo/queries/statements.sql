@@ -0,0 +1,44 @@
+-- name: UpsertStatement :one
+INSERT INTO statements (user_id, account_id, period_start, period_end, currency)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (account_id, period_start, period_end)
+DO UPDATE SET status = 'pending', updated_at = now()
+RETURNING *;
+
+-- name: GetStatement :one
+SELECT * FROM statements WHERE id = $1;
+
+-- name: ListStatementsByUser :many
+SELECT * FROM statements
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(account_id)::bigint IS NULL OR account_id = sqlc.narg(account_id))
+ORDER BY period_start DESC, id DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CompleteStatement :exec
+UPDATE statements
+SET status = 'done', opening_minor = $2, closing_minor = $3, inflow_minor = $4,
+    outflow_minor = $5, entry_count = $6, csv_path = $7, html_path = $8, updated_at = now()
+WHERE id = $1;
+
+-- name: FailStatement :exec
+UPDATE statements SET status = 'error', updated_at = now() WHERE id = $1;
+
+-- An account's postings dated in [from, to), oldest first, with the
+-- transaction's payee and note.
+-- name: ListStatementEntries :many
+SELECT p.id AS posting_id, t.id AS transaction_id, t.occurred_at, p.amount_minor, t.payee, t.note
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.account_id = sqlc.arg(account_id)
+  AND t.occurred_at >= sqlc.arg(from_time)::timestamptz
+  AND t.occurred_at < sqlc.arg(to_time)::timestamptz
+ORDER BY t.occurred_at, p.id;
+
+-- Accounts that get a statement every month: active asset and liability
+-- accounts.
+-- name: ListStatementAccounts :many
+SELECT id, user_id, currency
+FROM accounts
+WHERE kind = 'normal' AND is_active AND type IN ('asset', 'liability')
+ORDER BY id;
@@ -7,3 +7,40 @@ FROM postings p
 JOIN transactions t ON t.id = p.transaction_id
 WHERE p.account_id = $1
   AND date_trunc('month', t.occurred_at) = date_trunc('month', $2::timestamptz);
+
+-- Inflow/outflow on the user's asset and liability accounts in one month,
+-- per line category (the posting's, else the header's) and currency.
+-- Uncategorized lines come back with a NULL category.
+-- name: GetCategorySummary :many
+SELECT COALESCE(p.category_id, t.category_id) AS category_id,
+       p.currency,
+       COALESCE(SUM(CASE WHEN p.amount_minor > 0 THEN p.amount_minor ELSE 0 END), 0)::bigint AS inflow,
+       COALESCE(SUM(CASE WHEN p.amount_minor < 0 THEN -p.amount_minor ELSE 0 END), 0)::bigint AS outflow,
+       COALESCE(SUM(p.amount_minor), 0)::bigint AS net
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+JOIN accounts a ON a.id = p.account_id
+WHERE t.user_id = sqlc.arg(user_id)
+  AND a.kind = 'normal' AND a.type IN ('asset', 'liability')
+  AND date_trunc('month', t.occurred_at) = date_trunc('month', sqlc.arg(month)::timestamptz)
+GROUP BY 1, 2
+ORDER BY 1 NULLS LAST, 2;
+
+-- GetMonthlySummary over an account and every account below it, per
+-- currency. Transfers inside the subtree count on both sides.
+-- name: GetSubtreeMonthlySummary :many
+WITH RECURSIVE subtree AS (
+  SELECT a.id FROM accounts a WHERE a.id = sqlc.arg(account_id)
+  UNION
+  SELECT a.id FROM accounts a JOIN subtree s ON a.parent_id = s.id
+)
+SELECT p.currency,
+       COALESCE(SUM(CASE WHEN p.amount_minor > 0 THEN p.amount_minor ELSE 0 END), 0)::bigint AS inflow,
+       COALESCE(SUM(CASE WHEN p.amount_minor < 0 THEN -p.amount_minor ELSE 0 END), 0)::bigint AS outflow,
+       COALESCE(SUM(p.amount_minor), 0)::bigint AS net
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.account_id IN (SELECT id FROM subtree)
+  AND date_trunc('month', t.occurred_at) = date_trunc('month', sqlc.arg(month)::timestamptz)
+GROUP BY p.currency
+ORDER BY p.currency;
@@ -1,20 +1,22 @@
+-- fx_rate round-trips as text so no precision is lost to a Go numeric type.
 -- name: CreateTransactionHeader :one
-INSERT INTO transactions (user_id, currency, category_id, occurred_at, note, reversal_of)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, user_id, currency, category_id, occurred_at, note, reversal_of, created_at;
+INSERT INTO transactions (user_id, currency, category_id, occurred_at, note, payee, reversal_of, amends, fx_rate)
+VALUES (sqlc.arg(user_id), sqlc.arg(currency), sqlc.narg(category_id), sqlc.arg(occurred_at),
+        sqlc.narg(note), sqlc.narg(payee), sqlc.narg(reversal_of), sqlc.narg(amends), sqlc.narg(fx_rate)::text::numeric)
+RETURNING id, user_id, currency, category_id, occurred_at, note, payee, reversal_of, amends, fx_rate::text AS fx_rate, created_at;
 
 -- name: GetTransactionForUser :one
-SELECT id, user_id, currency, category_id, occurred_at, note, reversal_of, created_at
+SELECT id, user_id, currency, category_id, occurred_at, note, payee, reversal_of, amends, fx_rate::text AS fx_rate, created_at
 FROM transactions
 WHERE id = $1 AND user_id = $2;
 
 -- name: CreatePosting :one
-INSERT INTO postings (transaction_id, account_id, amount_minor)
-VALUES ($1, $2, $3)
-RETURNING id, transaction_id, account_id, amount_minor, created_at;
+INSERT INTO postings (transaction_id, account_id, amount_minor, currency, category_id, reverses_posting_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, transaction_id, account_id, amount_minor, created_at, currency, category_id, reverses_posting_id;
 
 -- name: ListPostingsByTransaction :many
-SELECT id, transaction_id, account_id, amount_minor, created_at
+SELECT id, transaction_id, account_id, amount_minor, created_at, currency, category_id, reverses_posting_id
 FROM postings
 WHERE transaction_id = $1
 ORDER BY id;
@@ -27,7 +29,7 @@ SELECT p.id AS posting_id,
        t.id AS transaction_id,
        p.account_id,
        p.amount_minor,
-       t.currency,
+       p.currency,
        t.occurred_at,
        t.note,
        p.created_at
@@ -37,3 +39,106 @@ WHERE p.account_id = sqlc.arg(account_id)
   AND (t.occurred_at, p.id) < (sqlc.arg(cursor_occurred_at)::timestamptz, sqlc.arg(cursor_posting_id)::bigint)
 ORDER BY t.occurred_at DESC, p.id DESC
 LIMIT sqlc.arg(page_limit);
+
+-- Row lock on a header the caller is about to change or build on.
+-- name: LockTransactionForUser :one
+SELECT id, user_id, currency, category_id, occurred_at, note, payee, reversal_of, amends, fx_rate::text AS fx_rate, created_at
+FROM transactions
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id)
+FOR UPDATE;
+
+-- name: SetTransactionCategory :exec
+UPDATE transactions SET category_id = sqlc.narg(category_id) WHERE id = sqlc.arg(id);
+
+-- User-wide transaction search, newest first, keyset-paginated on
+-- (occurred_at, id) like ListAccountEntries. Every filter is optional:
+--   from_time/to_time   occurred_at in [from, to)
+--   account_ids         touches any of these accounts (empty = any)
+--   min/max_amount      some posting on a normal account (or on one of
+--                       account_ids) has an absolute amount in range
+--   category_id         header or line category in this category's subtree
+--   reversal            'reversed' (has been reversed), 'reversal' (is
+--                       one), 'none' (neither)
+--   note_pattern        ILIKE pattern on the note
+--   text_query          to_tsquery('english') input matched against payee
+--                       and note; results then rank by relevance first
+-- The highlight is HTML: payee and note are escaped before the matches are
+-- wrapped in <mark>, so it is safe to render as markup.
+-- rank is 0 without a text_query, so the order is plain newest-first and
+-- cursor_rank must be 0; with one, pass 'Infinity' for the first page.
+-- name: SearchTransactions :many
+WITH RECURSIVE subtree AS (
+  SELECT c.id FROM categories c WHERE c.id = sqlc.narg(category_id) AND c.user_id = sqlc.arg(user_id)
+  UNION
+  SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT t.id, t.user_id, t.currency, t.category_id, t.occurred_at, t.note, t.payee, t.reversal_of, t.amends,
+       t.fx_rate::text AS fx_rate, t.created_at, r.rank,
+       CASE WHEN tq.query IS NULL THEN ''
+            ELSE ts_headline('english',
+                             replace(replace(replace(replace(replace(concat_ws(' · ', t.payee, t.note),
+                               '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+                             tq.query,
+                             'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=4, MaxWords=16')
+       END::text AS highlight
+FROM transactions t
+CROSS JOIN LATERAL (SELECT to_tsquery('english', sqlc.narg(text_query)::text) AS query) tq
+CROSS JOIN LATERAL (
+  SELECT CASE WHEN tq.query IS NULL THEN 0 ELSE ts_rank(t.search_vector, tq.query) END::real AS rank
+) r
+WHERE t.user_id = sqlc.arg(user_id)
+  AND (tq.query IS NULL OR t.search_vector @@ tq.query)
+  AND (r.rank, t.occurred_at, t.id)
+      < (sqlc.arg(cursor_rank)::real, sqlc.arg(cursor_occurred_at)::timestamptz, sqlc.arg(cursor_id)::bigint)
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR t.occurred_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR t.occurred_at < sqlc.narg(to_time))
+  AND ((cardinality(sqlc.arg(account_ids)::bigint[]) = 0
+        AND sqlc.narg(min_amount)::bigint IS NULL AND sqlc.narg(max_amount)::bigint IS NULL)
+       OR EXISTS (
+         SELECT 1 FROM postings p JOIN accounts a ON a.id = p.account_id
+         WHERE p.transaction_id = t.id
+           AND (p.account_id = ANY(sqlc.arg(account_ids)::bigint[])
+                OR (cardinality(sqlc.arg(account_ids)::bigint[]) = 0 AND a.kind = 'normal'))
+           AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(p.amount_minor) >= sqlc.narg(min_amount))
+           AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(p.amount_minor) <= sqlc.narg(max_amount))))
+  AND (sqlc.narg(category_id)::bigint IS NULL
+       OR t.category_id IN (SELECT id FROM subtree)
+       OR EXISTS (SELECT 1 FROM postings p
+                  WHERE p.transaction_id = t.id AND p.category_id IN (SELECT id FROM subtree)))
+  AND (sqlc.narg(reversal)::text IS NULL
+       OR (sqlc.narg(reversal) = 'reversal' AND t.reversal_of IS NOT NULL)
+       OR (sqlc.narg(reversal) = 'reversed' AND EXISTS (SELECT 1 FROM transactions r WHERE r.reversal_of = t.id))
+       OR (sqlc.narg(reversal) = 'none' AND t.reversal_of IS NULL
+           AND NOT EXISTS (SELECT 1 FROM transactions r WHERE r.reversal_of = t.id)))
+  AND (sqlc.narg(note_pattern)::text IS NULL OR t.note ILIKE sqlc.narg(note_pattern))
+ORDER BY r.rank DESC, t.occurred_at DESC, t.id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- Lexemes left in a text_query once stop words are dropped; 0 means the
+-- query would match nothing.
+-- name: TextQueryNodes :one
+SELECT numnode(to_tsquery('english', sqlc.arg(text_query)::text))::int AS nodes;
+
+-- name: ListPostingsByTransactions :many
+SELECT id, transaction_id, account_id, amount_minor, created_at, currency, category_id, reverses_posting_id
+FROM postings
+WHERE transaction_id = ANY(sqlc.arg(transaction_ids)::bigint[])
+ORDER BY transaction_id, id;
+
+-- Every reversal of a transaction, full or partial, oldest first.
+-- name: ListReversalsOf :many
+SELECT id FROM transactions WHERE reversal_of = sqlc.arg(transaction_id) ORDER BY id;
+
+-- A transaction's postings with how much of each is already reversed
+-- (reversed_minor has the opposite sign to amount_minor) and the account
+-- kind, for planning a further reversal.
+-- name: ListReversiblePostings :many
+SELECT p.id, p.account_id, p.amount_minor, p.currency, p.category_id, a.kind AS account_kind,
+       COALESCE((SELECT SUM(r.amount_minor) FROM postings r WHERE r.reverses_posting_id = p.id), 0)::bigint AS reversed_minor
+FROM postings p
+JOIN accounts a ON a.id = p.account_id
+WHERE p.transaction_id = sqlc.arg(transaction_id)
+ORDER BY p.id;
+
+-- name: GetAmendmentOf :one
+SELECT id FROM transactions WHERE amends = sqlc.arg(transaction_id);
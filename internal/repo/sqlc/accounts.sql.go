@@ -8,18 +8,46 @@ package repo
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const accountIsInSubtree = `-- name: AccountIsInSubtree :one
+WITH RECURSIVE subtree AS (
+  SELECT a.id FROM accounts a WHERE a.id = $1
+  UNION
+  SELECT a.id FROM accounts a JOIN subtree s ON a.parent_id = s.id
 )
+SELECT EXISTS (SELECT 1 FROM subtree WHERE id = $2) AS in_subtree
+`
+
+type AccountIsInSubtreeParams struct {
+	AncestorID  int64
+	CandidateID int64
+}
+
+// True when candidate is ancestor itself or sits anywhere below it; used to
+// refuse re-parenting that would create a cycle. The subtree walks use
+// UNION so a cycle in the data still terminates.
+func (q *Queries) AccountIsInSubtree(ctx context.Context, arg AccountIsInSubtreeParams) (bool, error) {
+	row := q.db.QueryRow(ctx, accountIsInSubtree, arg.AncestorID, arg.CandidateID)
+	var inSubtree bool
+	err := row.Scan(&inSubtree)
+	return inSubtree, err
+}
 
 const createAccount = `-- name: CreateAccount :one
-INSERT INTO accounts (user_id, name, currency)
-VALUES ($1, $2, $3)
-RETURNING id, user_id, name, currency, is_active, kind, created_at
+INSERT INTO accounts (user_id, name, currency, type, parent_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at
 `
 
 type CreateAccountParams struct {
 	UserID   int64
 	Name     string
 	Currency string
+	Type     string
+	ParentID pgtype.Int8
 }
 
 type CreateAccountRow struct {
@@ -29,11 +57,19 @@ type CreateAccountRow struct {
 	Currency  string
 	IsActive  bool
 	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
 	CreatedAt time.Time
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (CreateAccountRow, error) {
-	row := q.db.QueryRow(ctx, createAccount, arg.UserID, arg.Name, arg.Currency)
+	row := q.db.QueryRow(ctx, createAccount,
+		arg.UserID,
+		arg.Name,
+		arg.Currency,
+		arg.Type,
+		arg.ParentID,
+	)
 	var i CreateAccountRow
 	err := row.Scan(
 		&i.ID,
@@ -42,15 +78,66 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (C
 		&i.Currency,
 		&i.IsActive,
 		&i.Kind,
+		&i.Type,
+		&i.ParentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createCounterpartAccount = `-- name: CreateCounterpartAccount :one
+INSERT INTO accounts (user_id, name, currency, kind, type)
+VALUES ($1, $2, $3, 'external', $4)
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+`
+
+type CreateCounterpartAccountParams struct {
+	UserID   int64
+	Name     string
+	Currency string
+	Type     string
+}
+
+type CreateCounterpartAccountRow struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Currency  string
+	IsActive  bool
+	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
+	CreatedAt time.Time
+}
+
+// An external income or expense account that takes the counterpart of
+// income or spending instead of the catch-all.
+func (q *Queries) CreateCounterpartAccount(ctx context.Context, arg CreateCounterpartAccountParams) (CreateCounterpartAccountRow, error) {
+	row := q.db.QueryRow(ctx, createCounterpartAccount,
+		arg.UserID,
+		arg.Name,
+		arg.Currency,
+		arg.Type,
+	)
+	var i CreateCounterpartAccountRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Currency,
+		&i.IsActive,
+		&i.Kind,
+		&i.Type,
+		&i.ParentID,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const createExternalAccount = `-- name: CreateExternalAccount :one
-INSERT INTO accounts (user_id, name, currency, kind)
-VALUES ($1, 'External', $2, 'external')
-RETURNING id, user_id, name, currency, is_active, kind, created_at
+INSERT INTO accounts (user_id, name, currency, kind, type)
+VALUES ($1, 'External', $2, 'external', 'equity')
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at
 `
 
 type CreateExternalAccountParams struct {
@@ -65,9 +152,12 @@ type CreateExternalAccountRow struct {
 	Currency  string
 	IsActive  bool
 	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
 	CreatedAt time.Time
 }
 
+// The catch-all counterpart for a user's dealings with the outside world.
 func (q *Queries) CreateExternalAccount(ctx context.Context, arg CreateExternalAccountParams) (CreateExternalAccountRow, error) {
 	row := q.db.QueryRow(ctx, createExternalAccount, arg.UserID, arg.Currency)
 	var i CreateExternalAccountRow
@@ -78,6 +168,48 @@ func (q *Queries) CreateExternalAccount(ctx context.Context, arg CreateExternalA
 		&i.Currency,
 		&i.IsActive,
 		&i.Kind,
+		&i.Type,
+		&i.ParentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createFXAccount = `-- name: CreateFXAccount :one
+INSERT INTO accounts (user_id, name, currency, kind, type)
+VALUES ($1, 'FX Trading', $2, 'fx', 'equity')
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+`
+
+type CreateFXAccountParams struct {
+	UserID   int64
+	Currency string
+}
+
+type CreateFXAccountRow struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Currency  string
+	IsActive  bool
+	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateFXAccount(ctx context.Context, arg CreateFXAccountParams) (CreateFXAccountRow, error) {
+	row := q.db.QueryRow(ctx, createFXAccount, arg.UserID, arg.Currency)
+	var i CreateFXAccountRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Currency,
+		&i.IsActive,
+		&i.Kind,
+		&i.Type,
+		&i.ParentID,
 		&i.CreatedAt,
 	)
 	return i, err
@@ -93,7 +225,7 @@ func (q *Queries) DeactivateAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, user_id, name, currency, is_active, kind, created_at
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
 FROM accounts
 WHERE id = $1
 `
@@ -105,6 +237,8 @@ type GetAccountRow struct {
 	Currency  string
 	IsActive  bool
 	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
 	CreatedAt time.Time
 }
 
@@ -118,15 +252,57 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (GetAccountRow, erro
 		&i.Currency,
 		&i.IsActive,
 		&i.Kind,
+		&i.Type,
+		&i.ParentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getCounterpartAccount = `-- name: GetCounterpartAccount :one
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+FROM accounts
+WHERE user_id = $1 AND kind = 'external' AND type = $2
+`
+
+type GetCounterpartAccountParams struct {
+	UserID int64
+	Type   string
+}
+
+type GetCounterpartAccountRow struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Currency  string
+	IsActive  bool
+	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
+	CreatedAt time.Time
+}
+
+func (q *Queries) GetCounterpartAccount(ctx context.Context, arg GetCounterpartAccountParams) (GetCounterpartAccountRow, error) {
+	row := q.db.QueryRow(ctx, getCounterpartAccount, arg.UserID, arg.Type)
+	var i GetCounterpartAccountRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Currency,
+		&i.IsActive,
+		&i.Kind,
+		&i.Type,
+		&i.ParentID,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const getExternalAccount = `-- name: GetExternalAccount :one
-SELECT id, user_id, name, currency, is_active, kind, created_at
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
 FROM accounts
-WHERE user_id = $1 AND kind = 'external'
+WHERE user_id = $1 AND kind = 'external' AND type = 'equity'
 `
 
 type GetExternalAccountRow struct {
@@ -136,6 +312,8 @@ type GetExternalAccountRow struct {
 	Currency  string
 	IsActive  bool
 	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
 	CreatedAt time.Time
 }
 
@@ -149,13 +327,50 @@ func (q *Queries) GetExternalAccount(ctx context.Context, userID int64) (GetExte
 		&i.Currency,
 		&i.IsActive,
 		&i.Kind,
+		&i.Type,
+		&i.ParentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getFXAccount = `-- name: GetFXAccount :one
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+FROM accounts
+WHERE user_id = $1 AND kind = 'fx'
+`
+
+type GetFXAccountRow struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Currency  string
+	IsActive  bool
+	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
+	CreatedAt time.Time
+}
+
+func (q *Queries) GetFXAccount(ctx context.Context, userID int64) (GetFXAccountRow, error) {
+	row := q.db.QueryRow(ctx, getFXAccount, userID)
+	var i GetFXAccountRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Currency,
+		&i.IsActive,
+		&i.Kind,
+		&i.Type,
+		&i.ParentID,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
 const listAccountsByUser = `-- name: ListAccountsByUser :many
-SELECT id, user_id, name, currency, is_active, kind, created_at
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
 FROM accounts
 WHERE user_id = $1
 ORDER BY created_at DESC
@@ -175,6 +390,8 @@ type ListAccountsByUserRow struct {
 	Currency  string
 	IsActive  bool
 	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
 	CreatedAt time.Time
 }
 
@@ -194,6 +411,8 @@ func (q *Queries) ListAccountsByUser(ctx context.Context, arg ListAccountsByUser
 			&i.Currency,
 			&i.IsActive,
 			&i.Kind,
+			&i.Type,
+			&i.ParentID,
 			&i.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -205,3 +424,224 @@ func (q *Queries) ListAccountsByUser(ctx context.Context, arg ListAccountsByUser
 	}
 	return items, nil
 }
+
+const listAllAccountsByUser = `-- name: ListAllAccountsByUser :many
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+FROM accounts
+WHERE user_id = $1
+ORDER BY name, id
+`
+
+type ListAllAccountsByUserRow struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Currency  string
+	IsActive  bool
+	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListAllAccountsByUser(ctx context.Context, userID int64) ([]ListAllAccountsByUserRow, error) {
+	rows, err := q.db.Query(ctx, listAllAccountsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAllAccountsByUserRow
+	for rows.Next() {
+		var i ListAllAccountsByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Currency,
+			&i.IsActive,
+			&i.Kind,
+			&i.Type,
+			&i.ParentID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listNormalAccountsByUser = `-- name: ListNormalAccountsByUser :many
+SELECT id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+FROM accounts
+WHERE user_id = $1 AND kind = 'normal'
+ORDER BY id
+`
+
+type ListNormalAccountsByUserRow struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Currency  string
+	IsActive  bool
+	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
+	CreatedAt time.Time
+}
+
+func (q *Queries) ListNormalAccountsByUser(ctx context.Context, userID int64) ([]ListNormalAccountsByUserRow, error) {
+	rows, err := q.db.Query(ctx, listNormalAccountsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListNormalAccountsByUserRow
+	for rows.Next() {
+		var i ListNormalAccountsByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Currency,
+			&i.IsActive,
+			&i.Kind,
+			&i.Type,
+			&i.ParentID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockAccountTree = `-- name: LockAccountTree :exec
+SELECT pg_advisory_xact_lock(hashtextextended('ledgerx.account_tree:' || $1::bigint, 0))
+`
+
+// Serializes re-parenting within one user's chart of accounts, so two
+// concurrent moves cannot each pass the cycle check and together form a
+// loop.
+func (q *Queries) LockAccountTree(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, lockAccountTree, userID)
+	return err
+}
+
+const setAccountParent = `-- name: SetAccountParent :one
+UPDATE accounts SET parent_id = $1
+WHERE id = $2 AND user_id = $3
+RETURNING id, user_id, name, currency, is_active, kind, type, parent_id, created_at
+`
+
+type SetAccountParentParams struct {
+	ParentID pgtype.Int8
+	ID       int64
+	UserID   int64
+}
+
+type SetAccountParentRow struct {
+	ID        int64
+	UserID    int64
+	Name      string
+	Currency  string
+	IsActive  bool
+	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
+	CreatedAt time.Time
+}
+
+func (q *Queries) SetAccountParent(ctx context.Context, arg SetAccountParentParams) (SetAccountParentRow, error) {
+	row := q.db.QueryRow(ctx, setAccountParent, arg.ParentID, arg.ID, arg.UserID)
+	var i SetAccountParentRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Currency,
+		&i.IsActive,
+		&i.Kind,
+		&i.Type,
+		&i.ParentID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const subtreeBalances = `-- name: SubtreeBalances :many
+WITH RECURSIVE subtree AS (
+  SELECT a.id, a.currency FROM accounts a WHERE a.id = $1
+  UNION
+  SELECT a.id, a.currency FROM accounts a JOIN subtree s ON a.parent_id = s.id
+),
+latest AS (
+  SELECT DISTINCT ON (bs.account_id) bs.account_id, bs.as_of_date, bs.balance_minor
+  FROM balance_snapshots bs
+  WHERE bs.account_id IN (SELECT id FROM subtree)
+  ORDER BY bs.account_id, bs.as_of_date DESC
+)
+SELECT s.currency,
+       count(*)::int AS accounts,
+       COALESCE(SUM(COALESCE(l.balance_minor, 0) + (
+         SELECT COALESCE(SUM(p.amount_minor), 0) FROM postings p
+         WHERE p.account_id = s.id
+           AND (l.as_of_date IS NULL OR p.created_at >= (l.as_of_date + 1)::timestamp AT TIME ZONE 'UTC')
+       )), 0)::bigint AS ledger_minor,
+       COALESCE(SUM((
+         SELECT COALESCE(SUM(h.amount_minor), 0) FROM holds h
+         WHERE h.account_id = s.id AND h.status = 'pending' AND h.expires_at > $2::timestamptz
+       )), 0)::bigint AS held_minor
+FROM subtree s
+LEFT JOIN latest l ON l.account_id = s.id
+GROUP BY s.currency
+ORDER BY s.currency
+`
+
+type SubtreeBalancesParams struct {
+	AccountID int64
+	Now       time.Time
+}
+
+type SubtreeBalancesRow struct {
+	Currency    string
+	Accounts    int32
+	LedgerMinor int64
+	HeldMinor   int64
+}
+
+// Ledger balance and pending holds of an account and every account below
+// it, per currency. Each account counts as in CurrentBalance: its latest
+// snapshot plus the postings created after the snapshot's day, so the
+// whole subtree costs one round trip.
+func (q *Queries) SubtreeBalances(ctx context.Context, arg SubtreeBalancesParams) ([]SubtreeBalancesRow, error) {
+	rows, err := q.db.Query(ctx, subtreeBalances, arg.AccountID, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SubtreeBalancesRow
+	for rows.Next() {
+		var i SubtreeBalancesRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Accounts,
+			&i.LedgerMinor,
+			&i.HeldMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -10,6 +10,104 @@ import (
 	"time"
 )
 
+const dailyPostingsOccurred = `-- name: DailyPostingsOccurred :many
+SELECT p.account_id,
+       (t.occurred_at AT TIME ZONE 'UTC')::date AS day,
+       SUM(p.amount_minor)::bigint AS delta
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.account_id = ANY($1::bigint[])
+  AND t.occurred_at >= $2::timestamptz AND t.occurred_at < $3::timestamptz
+GROUP BY 1, 2
+ORDER BY 1, 2
+`
+
+type DailyPostingsOccurredParams struct {
+	AccountIds []int64
+	FromTime   time.Time
+	ToTime     time.Time
+}
+
+type DailyPostingsOccurredRow struct {
+	AccountID int64
+	Day       time.Time
+	Delta     int64
+}
+
+// DailyPostingsRecorded by accounting date (occurred_at) instead.
+func (q *Queries) DailyPostingsOccurred(ctx context.Context, arg DailyPostingsOccurredParams) ([]DailyPostingsOccurredRow, error) {
+	rows, err := q.db.Query(ctx, dailyPostingsOccurred, arg.AccountIds, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DailyPostingsOccurredRow
+	for rows.Next() {
+		var i DailyPostingsOccurredRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Day,
+			&i.Delta,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const dailyPostingsRecorded = `-- name: DailyPostingsRecorded :many
+SELECT account_id,
+       (created_at AT TIME ZONE 'UTC')::date AS day,
+       SUM(amount_minor)::bigint AS delta
+FROM postings
+WHERE account_id = ANY($1::bigint[])
+  AND created_at >= $2 AND created_at < $3
+GROUP BY 1, 2
+ORDER BY 1, 2
+`
+
+type DailyPostingsRecordedParams struct {
+	AccountIds []int64
+	FromTime   time.Time
+	ToTime     time.Time
+}
+
+type DailyPostingsRecordedRow struct {
+	AccountID int64
+	Day       time.Time
+	Delta     int64
+}
+
+// Net postings per account and UTC day of recording (created_at) in
+// [from, to); days without postings are absent.
+func (q *Queries) DailyPostingsRecorded(ctx context.Context, arg DailyPostingsRecordedParams) ([]DailyPostingsRecordedRow, error) {
+	rows, err := q.db.Query(ctx, dailyPostingsRecorded, arg.AccountIds, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DailyPostingsRecordedRow
+	for rows.Next() {
+		var i DailyPostingsRecordedRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Day,
+			&i.Delta,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getLatestSnapshot = `-- name: GetLatestSnapshot :one
 SELECT id, account_id, as_of_date, balance_minor, created_at
 FROM balance_snapshots
@@ -55,6 +153,241 @@ func (q *Queries) GetSnapshotOnDate(ctx context.Context, arg GetSnapshotOnDatePa
 	return i, err
 }
 
+const getSnapshotOnOrBefore = `-- name: GetSnapshotOnOrBefore :one
+SELECT id, account_id, as_of_date, balance_minor, created_at
+FROM balance_snapshots
+WHERE account_id = $1 AND as_of_date <= $2
+ORDER BY as_of_date DESC
+LIMIT 1
+`
+
+type GetSnapshotOnOrBeforeParams struct {
+	AccountID int64
+	AsOfDate  time.Time
+}
+
+// The newest snapshot taken for a day on or before as_of_date.
+func (q *Queries) GetSnapshotOnOrBefore(ctx context.Context, arg GetSnapshotOnOrBeforeParams) (BalanceSnapshot, error) {
+	row := q.db.QueryRow(ctx, getSnapshotOnOrBefore, arg.AccountID, arg.AsOfDate)
+	var i BalanceSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.AsOfDate,
+		&i.BalanceMinor,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listSnapshotsBetween = `-- name: ListSnapshotsBetween :many
+SELECT id, account_id, as_of_date, balance_minor, created_at
+FROM balance_snapshots
+WHERE account_id = ANY($1::bigint[])
+  AND as_of_date >= $2 AND as_of_date <= $3
+ORDER BY account_id, as_of_date
+`
+
+type ListSnapshotsBetweenParams struct {
+	AccountIds []int64
+	FromDate   time.Time
+	ToDate     time.Time
+}
+
+// Balance history reads each series for a whole set of accounts at once,
+// so a net-worth history costs the same round trips as one account's.
+func (q *Queries) ListSnapshotsBetween(ctx context.Context, arg ListSnapshotsBetweenParams) ([]BalanceSnapshot, error) {
+	rows, err := q.db.Query(ctx, listSnapshotsBetween, arg.AccountIds, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BalanceSnapshot
+	for rows.Next() {
+		var i BalanceSnapshot
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.AsOfDate,
+			&i.BalanceMinor,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const openingBalancesOccurred = `-- name: OpeningBalancesOccurred :many
+WITH latest AS (
+  SELECT DISTINCT ON (bs.account_id) bs.account_id, bs.balance_minor,
+         (bs.as_of_date + 1)::timestamp AT TIME ZONE 'UTC' AS cutoff
+  FROM balance_snapshots bs
+  WHERE bs.account_id = ANY($1::bigint[])
+    AND bs.as_of_date <= $2
+  ORDER BY bs.account_id, bs.as_of_date DESC
+)
+SELECT a.id AS account_id,
+       (COALESCE(l.balance_minor, 0) + (
+         SELECT COALESCE(SUM(CASE WHEN p.created_at >= COALESCE(l.cutoff, '-infinity') THEN p.amount_minor ELSE -p.amount_minor END), 0)
+         FROM postings p
+         JOIN transactions t ON t.id = p.transaction_id
+         WHERE p.account_id = a.id
+           AND ((p.created_at >= COALESCE(l.cutoff, '-infinity') AND t.occurred_at < $3::timestamptz)
+             OR (p.created_at < COALESCE(l.cutoff, '-infinity') AND t.occurred_at >= $3::timestamptz))
+       ))::bigint AS ledger_minor
+FROM unnest($1::bigint[]) AS a(id)
+LEFT JOIN latest l ON l.account_id = a.id
+`
+
+type OpeningBalancesOccurredParams struct {
+	AccountIds   []int64
+	SnapshotDate time.Time
+	AsOf         time.Time
+}
+
+type OpeningBalancesOccurredRow struct {
+	AccountID   int64
+	LedgerMinor int64
+}
+
+// OpeningBalancesRecorded on the occurred basis, moving the postings whose
+// accounting date and recording fall on opposite sides of as_of, as
+// SumPostingsOccurredDelta does for one account.
+func (q *Queries) OpeningBalancesOccurred(ctx context.Context, arg OpeningBalancesOccurredParams) ([]OpeningBalancesOccurredRow, error) {
+	rows, err := q.db.Query(ctx, openingBalancesOccurred, arg.AccountIds, arg.SnapshotDate, arg.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OpeningBalancesOccurredRow
+	for rows.Next() {
+		var i OpeningBalancesOccurredRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.LedgerMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const openingBalancesRecorded = `-- name: OpeningBalancesRecorded :many
+WITH latest AS (
+  SELECT DISTINCT ON (bs.account_id) bs.account_id, bs.balance_minor,
+         (bs.as_of_date + 1)::timestamp AT TIME ZONE 'UTC' AS cutoff
+  FROM balance_snapshots bs
+  WHERE bs.account_id = ANY($1::bigint[])
+    AND bs.as_of_date <= $2
+  ORDER BY bs.account_id, bs.as_of_date DESC
+)
+SELECT a.id AS account_id,
+       (COALESCE(l.balance_minor, 0) + (
+         SELECT COALESCE(SUM(p.amount_minor), 0) FROM postings p
+         WHERE p.account_id = a.id
+           AND p.created_at >= COALESCE(l.cutoff, '-infinity')
+           AND p.created_at < $3::timestamptz
+       ))::bigint AS ledger_minor
+FROM unnest($1::bigint[]) AS a(id)
+LEFT JOIN latest l ON l.account_id = a.id
+`
+
+type OpeningBalancesRecordedParams struct {
+	AccountIds   []int64
+	SnapshotDate time.Time
+	AsOf         time.Time
+}
+
+type OpeningBalancesRecordedRow struct {
+	AccountID   int64
+	LedgerMinor int64
+}
+
+// BalanceAsOf on the recorded basis for several accounts: each account's
+// newest snapshot up to snapshot_date, plus the postings recorded from the
+// end of that day until as_of. Accounts without a snapshot sum every
+// posting recorded before as_of.
+func (q *Queries) OpeningBalancesRecorded(ctx context.Context, arg OpeningBalancesRecordedParams) ([]OpeningBalancesRecordedRow, error) {
+	rows, err := q.db.Query(ctx, openingBalancesRecorded, arg.AccountIds, arg.SnapshotDate, arg.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OpeningBalancesRecordedRow
+	for rows.Next() {
+		var i OpeningBalancesRecordedRow
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.LedgerMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const sumPostingsOccurredDelta = `-- name: SumPostingsOccurredDelta :one
+SELECT COALESCE(SUM(CASE WHEN p.created_at > $1 THEN p.amount_minor ELSE -p.amount_minor END), 0)::bigint AS delta
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.account_id = $2
+  AND ((p.created_at > $1 AND t.occurred_at < $3::timestamptz)
+    OR (p.created_at <= $1 AND t.occurred_at >= $3::timestamptz))
+`
+
+type SumPostingsOccurredDeltaParams struct {
+	Since     time.Time
+	AccountID int64
+	Before    time.Time
+}
+
+// Turns a recorded-basis snapshot cut at since into an occurred-basis
+// balance for entries dated before before: adds postings recorded after the
+// snapshot but dated before the cutoff, and takes out postings the snapshot
+// counted that are dated at or after it.
+func (q *Queries) SumPostingsOccurredDelta(ctx context.Context, arg SumPostingsOccurredDeltaParams) (int64, error) {
+	row := q.db.QueryRow(ctx, sumPostingsOccurredDelta, arg.Since, arg.AccountID, arg.Before)
+	var delta int64
+	err := row.Scan(&delta)
+	return delta, err
+}
+
+const sumPostingsRecordedBetween = `-- name: SumPostingsRecordedBetween :one
+SELECT COALESCE(SUM(amount_minor), 0)::bigint AS delta
+FROM postings
+WHERE account_id = $1
+  AND created_at > $2
+  AND created_at < $3
+`
+
+type SumPostingsRecordedBetweenParams struct {
+	AccountID int64
+	Since     time.Time
+	Before    time.Time
+}
+
+// Postings recorded in (since, before): what the system learned between a
+// snapshot cutoff and a later instant.
+func (q *Queries) SumPostingsRecordedBetween(ctx context.Context, arg SumPostingsRecordedBetweenParams) (int64, error) {
+	row := q.db.QueryRow(ctx, sumPostingsRecordedBetween, arg.AccountID, arg.Since, arg.Before)
+	var delta int64
+	err := row.Scan(&delta)
+	return delta, err
+}
+
 const sumPostingsSince = `-- name: SumPostingsSince :one
 SELECT COALESCE(SUM(amount_minor), 0)::bigint AS delta
 FROM postings
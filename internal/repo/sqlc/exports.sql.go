@@ -15,7 +15,7 @@ import (
 const createExport = `-- name: CreateExport :one
 INSERT INTO exports (user_id, month, status)
 VALUES ($1, $2, 'pending')
-RETURNING id, user_id, month, status, file_path, created_at, updated_at
+RETURNING id, user_id, month, status, file_path, created_at, updated_at, kind, range_start, range_end
 `
 
 type CreateExportParams struct {
@@ -34,12 +34,53 @@ func (q *Queries) CreateExport(ctx context.Context, arg CreateExportParams) (Exp
 		&i.FilePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Kind,
+		&i.RangeStart,
+		&i.RangeEnd,
+	)
+	return i, err
+}
+
+const createReportExport = `-- name: CreateReportExport :one
+INSERT INTO exports (user_id, month, status, kind, range_start, range_end)
+VALUES ($1, $2, 'pending', $3, $4, $5)
+RETURNING id, user_id, month, status, file_path, created_at, updated_at, kind, range_start, range_end
+`
+
+type CreateReportExportParams struct {
+	UserID     int64
+	Month      time.Time
+	Kind       string
+	RangeStart pgtype.Timestamptz
+	RangeEnd   pgtype.Timestamptz
+}
+
+func (q *Queries) CreateReportExport(ctx context.Context, arg CreateReportExportParams) (Export, error) {
+	row := q.db.QueryRow(ctx, createReportExport,
+		arg.UserID,
+		arg.Month,
+		arg.Kind,
+		arg.RangeStart,
+		arg.RangeEnd,
+	)
+	var i Export
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Month,
+		&i.Status,
+		&i.FilePath,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Kind,
+		&i.RangeStart,
+		&i.RangeEnd,
 	)
 	return i, err
 }
 
 const getExportByID = `-- name: GetExportByID :one
-SELECT id, user_id, month, status, file_path, created_at, updated_at FROM exports WHERE id = $1
+SELECT id, user_id, month, status, file_path, created_at, updated_at, kind, range_start, range_end FROM exports WHERE id = $1
 `
 
 func (q *Queries) GetExportByID(ctx context.Context, id int64) (Export, error) {
@@ -53,6 +94,9 @@ func (q *Queries) GetExportByID(ctx context.Context, id int64) (Export, error) {
 		&i.FilePath,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Kind,
+		&i.RangeStart,
+		&i.RangeEnd,
 	)
 	return i, err
 }
@@ -61,11 +105,14 @@ const listPostingsForMonth = `-- name: ListPostingsForMonth :many
 SELECT t.id AS transaction_id,
        p.account_id,
        a.name AS account_name,
-       t.category_id,
+       a.type AS account_type,
+       COALESCE(p.category_id, t.category_id) AS category_id,
        p.amount_minor,
-       t.currency,
+       p.currency,
        t.occurred_at,
-       t.note
+       t.note,
+       COALESCE((SELECT string_agg(at.id::text, ';' ORDER BY at.id)
+                 FROM attachments at WHERE at.transaction_id = t.id), '')::text AS attachment_ids
 FROM postings p
 JOIN transactions t ON t.id = p.transaction_id
 JOIN accounts a ON a.id = p.account_id
@@ -84,15 +131,19 @@ type ListPostingsForMonthRow struct {
 	TransactionID int64
 	AccountID     int64
 	AccountName   string
+	AccountType   string
 	CategoryID    pgtype.Int8
 	AmountMinor   int64
 	Currency      string
 	OccurredAt    time.Time
 	Note          pgtype.Text
+	AttachmentIds string
 }
 
 // Export rows are postings on the user's normal accounts; the offsetting
-// external legs would only duplicate every line.
+// external legs would only duplicate every line. category_id is the line
+// category, falling back to the header's; attachment_ids lists the
+// transaction's attachments as ';'-separated ids.
 func (q *Queries) ListPostingsForMonth(ctx context.Context, arg ListPostingsForMonthParams) ([]ListPostingsForMonthRow, error) {
 	rows, err := q.db.Query(ctx, listPostingsForMonth, arg.UserID, arg.Column2)
 	if err != nil {
@@ -106,11 +157,13 @@ func (q *Queries) ListPostingsForMonth(ctx context.Context, arg ListPostingsForM
 			&i.TransactionID,
 			&i.AccountID,
 			&i.AccountName,
+			&i.AccountType,
 			&i.CategoryID,
 			&i.AmountMinor,
 			&i.Currency,
 			&i.OccurredAt,
 			&i.Note,
+			&i.AttachmentIds,
 		); err != nil {
 			return nil, err
 		}
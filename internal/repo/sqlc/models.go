@@ -18,6 +18,28 @@ type Account struct {
 	IsActive  bool
 	CreatedAt time.Time
 	Kind      string
+	Type      string
+	ParentID  pgtype.Int8
+}
+
+type AccountLimit struct {
+	AccountID           int64
+	MinBalanceMinor     pgtype.Int8
+	OverdraftLimitMinor pgtype.Int8
+	NeverNegative       bool
+	UpdatedAt           time.Time
+}
+
+type Attachment struct {
+	ID            int64
+	TransactionID int64
+	UserID        int64
+	Filename      string
+	ContentType   string
+	SizeBytes     int64
+	Sha256        string
+	StorageKey    string
+	CreatedAt     time.Time
 }
 
 type BalanceSnapshot struct {
@@ -28,21 +50,69 @@ type BalanceSnapshot struct {
 	CreatedAt    time.Time
 }
 
+type Budget struct {
+	ID          int64
+	UserID      int64
+	CategoryID  int64
+	Currency    string
+	AmountMinor int64
+	Rollover    bool
+	StartMonth  time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
 type Category struct {
 	ID        int64
 	UserID    int64
 	Name      string
 	CreatedAt pgtype.Timestamptz
+	ParentID  pgtype.Int8
+}
+
+type ClosedPeriod struct {
+	ID       int64
+	UserID   pgtype.Int8
+	Month    time.Time
+	ClosedBy string
+	ClosedAt time.Time
 }
 
 type Export struct {
-	ID        int64
-	UserID    int64
-	Month     time.Time
-	Status    string
-	FilePath  pgtype.Text
-	CreatedAt pgtype.Timestamptz
-	UpdatedAt pgtype.Timestamptz
+	ID         int64
+	UserID     int64
+	Month      time.Time
+	Status     string
+	FilePath   pgtype.Text
+	CreatedAt  pgtype.Timestamptz
+	UpdatedAt  pgtype.Timestamptz
+	Kind       string
+	RangeStart pgtype.Timestamptz
+	RangeEnd   pgtype.Timestamptz
+}
+
+type FxRate struct {
+	Base      string
+	Quote     string
+	RateDate  time.Time
+	Rate      int64
+	Source    string
+	CreatedAt time.Time
+}
+
+type Hold struct {
+	ID            int64
+	UserID        int64
+	AccountID     int64
+	AmountMinor   int64
+	Currency      string
+	Note          pgtype.Text
+	Status        string
+	ExpiresAt     time.Time
+	TransactionID pgtype.Int8
+	CapturedMinor pgtype.Int8
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
 }
 
 type IdempotencyKey struct {
@@ -62,23 +132,119 @@ type Outbox struct {
 	ProcessedAt pgtype.Timestamptz
 }
 
+type PeriodCloseAudit struct {
+	ID        int64
+	UserID    pgtype.Int8
+	Month     time.Time
+	Action    string
+	Actor     string
+	CreatedAt time.Time
+}
+
 type Posting struct {
+	ID                int64
+	TransactionID     int64
+	AccountID         int64
+	AmountMinor       int64
+	CreatedAt         time.Time
+	Currency          string
+	CategoryID        pgtype.Int8
+	ReversesPostingID pgtype.Int8
+}
+
+type ReconciledPosting struct {
+	PostingID        int64
+	ReconciliationID int64
+	ReconciledAt     time.Time
+}
+
+type Reconciliation struct {
+	ID                  int64
+	UserID              int64
+	AccountID           int64
+	StatementDate       time.Time
+	ClosingBalanceMinor int64
+	Currency            string
+	Status              string
+	ClearedBalanceMinor pgtype.Int8
+	DifferenceMinor     pgtype.Int8
+	CreatedAt           time.Time
+	CompletedAt         pgtype.Timestamptz
+}
+
+type ReconciliationLine struct {
+	ID               int64
+	ReconciliationID int64
+	LineNo           int32
+	OccurredOn       time.Time
+	AmountMinor      int64
+	Description      string
+	PostingID        pgtype.Int8
+	MatchedBy        pgtype.Text
+}
+
+type RecurringRule struct {
 	ID            int64
-	TransactionID int64
+	UserID        int64
+	Kind          string
 	AccountID     int64
+	ToAccountID   pgtype.Int8
 	AmountMinor   int64
+	Currency      string
+	Note          pgtype.Text
+	Frequency     string
+	IntervalCount int32
+	DayOfMonth    pgtype.Int4
+	StartDate     time.Time
+	EndDate       pgtype.Date
+	NextRunDate   time.Time
+	Paused        bool
+	LastError     pgtype.Text
 	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type Statement struct {
+	ID           int64
+	UserID       int64
+	AccountID    int64
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Currency     string
+	Status       string
+	OpeningMinor int64
+	ClosingMinor int64
+	InflowMinor  int64
+	OutflowMinor int64
+	EntryCount   int32
+	CsvPath      pgtype.Text
+	HtmlPath     pgtype.Text
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 type Transaction struct {
-	ID         int64
-	UserID     int64
-	Currency   string
-	OccurredAt time.Time
-	Note       pgtype.Text
-	CreatedAt  time.Time
-	CategoryID pgtype.Int8
-	ReversalOf pgtype.Int8
+	ID           int64
+	UserID       int64
+	Currency     string
+	OccurredAt   time.Time
+	Note         pgtype.Text
+	CreatedAt    time.Time
+	CategoryID   pgtype.Int8
+	ReversalOf   pgtype.Int8
+	FxRate       pgtype.Numeric
+	Payee        pgtype.Text
+	SearchVector interface{}
+	Amends       pgtype.Int8
+}
+
+type TransactionCategoryChange struct {
+	ID            int64
+	TransactionID int64
+	UserID        int64
+	OldCategoryID pgtype.Int8
+	NewCategoryID pgtype.Int8
+	ChangedAt     time.Time
 }
 
 type User struct {
@@ -32,3 +32,37 @@ func (q *Queries) CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventPa
 	err := row.Scan(&i.ID, &i.CreatedAt)
 	return i, err
 }
+
+const listOutboxEventsForTransaction = `-- name: ListOutboxEventsForTransaction :many
+SELECT id, event_type, payload, created_at, processed_at
+FROM outbox
+WHERE (payload->>'transaction_id')::bigint = $1::bigint
+ORDER BY id
+`
+
+// Events whose payload names the transaction, oldest first.
+func (q *Queries) ListOutboxEventsForTransaction(ctx context.Context, transactionID int64) ([]Outbox, error) {
+	rows, err := q.db.Query(ctx, listOutboxEventsForTransaction, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Outbox
+	for rows.Next() {
+		var i Outbox
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventType,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.ProcessedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
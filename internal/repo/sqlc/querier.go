@@ -6,48 +6,281 @@ package repo
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
+	// True when candidate is ancestor itself or sits anywhere below it; used to
+	// refuse re-parenting that would create a cycle. The subtree walks use
+	// UNION so a cycle in the data still terminates.
+	AccountIsInSubtree(ctx context.Context, arg AccountIsInSubtreeParams) (bool, error)
+	// Moves a rule past one occurrence. Conditional on the date it was read
+	// with, so two workers racing on the same rule advance it only once.
+	AdvanceRecurringRule(ctx context.Context, arg AdvanceRecurringRuleParams) (int64, error)
+	// Net outflow from the user's asset and liability accounts, per month, of
+	// postings whose line category (the header's when the line has none) is
+	// category_id or any category below it. Refunds into the category reduce
+	// spending; months without activity are absent.
+	BudgetSpendByMonth(ctx context.Context, arg BudgetSpendByMonthParams) ([]BudgetSpendByMonthRow, error)
+	CaptureHold(ctx context.Context, arg CaptureHoldParams) (Hold, error)
+	// Inflow and outflow on the user's asset and liability accounts (or the
+	// given ones; empty = all) per UTC bucket ('month' or 'week', ISO weeks
+	// starting Monday) and currency, over entries dated in [from, to).
+	// Internal transfers are left out: a transaction counts only if some
+	// posting leaves those accounts for one other than an FX account, so
+	// moving money between your own accounts, even across currencies, is not
+	// cash flow.
+	CashFlow(ctx context.Context, arg CashFlowParams) ([]CashFlowRow, error)
+	// A category is in use while a transaction or split line, a child category
+	// or the re-categorization history refers to it.
+	CategoryInUse(ctx context.Context, id int64) (bool, error)
+	// True when candidate is ancestor itself or sits anywhere below it; used to
+	// refuse re-parenting that would create a cycle. UNION keeps the walk
+	// finite even if a cycle exists.
+	CategoryIsInSubtree(ctx context.Context, arg CategoryIsInSubtreeParams) (bool, error)
+	// The account's cleared balance: postings reconciled before, plus those
+	// matched in this session.
+	ClearedBalance(ctx context.Context, arg ClearedBalanceParams) (int64, error)
+	// user_id NULL closes the month globally. Returns no row if already closed.
+	ClosePeriod(ctx context.Context, arg ClosePeriodParams) (ClosedPeriod, error)
+	CompleteReconciliation(ctx context.Context, arg CompleteReconciliationParams) error
+	CompleteStatement(ctx context.Context, arg CompleteStatementParams) error
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (CreateAccountRow, error)
+	CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error)
+	CreateBudget(ctx context.Context, arg CreateBudgetParams) (Budget, error)
+	CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error)
+	CreateCategoryChange(ctx context.Context, arg CreateCategoryChangeParams) (TransactionCategoryChange, error)
+	// An external income or expense account that takes the counterpart of
+	// income or spending instead of the catch-all.
+	CreateCounterpartAccount(ctx context.Context, arg CreateCounterpartAccountParams) (CreateCounterpartAccountRow, error)
 	CreateExport(ctx context.Context, arg CreateExportParams) (Export, error)
+	// The catch-all counterpart for a user's dealings with the outside world.
 	CreateExternalAccount(ctx context.Context, arg CreateExternalAccountParams) (CreateExternalAccountRow, error)
+	CreateFXAccount(ctx context.Context, arg CreateFXAccountParams) (CreateFXAccountRow, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
 	CreateOutboxEvent(ctx context.Context, arg CreateOutboxEventParams) (CreateOutboxEventRow, error)
 	CreatePosting(ctx context.Context, arg CreatePostingParams) (Posting, error)
+	CreateReconciliation(ctx context.Context, arg CreateReconciliationParams) (Reconciliation, error)
+	CreateReconciliationLine(ctx context.Context, arg CreateReconciliationLineParams) error
+	CreateRecurringRule(ctx context.Context, arg CreateRecurringRuleParams) (RecurringRule, error)
+	CreateReportExport(ctx context.Context, arg CreateReportExportParams) (Export, error)
+	// fx_rate round-trips as text so no precision is lost to a Go numeric type.
 	CreateTransactionHeader(ctx context.Context, arg CreateTransactionHeaderParams) (CreateTransactionHeaderRow, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
+	// DailyPostingsRecorded by accounting date (occurred_at) instead.
+	DailyPostingsOccurred(ctx context.Context, arg DailyPostingsOccurredParams) ([]DailyPostingsOccurredRow, error)
+	// Net postings per account and UTC day of recording (created_at) in
+	// [from, to); days without postings are absent.
+	DailyPostingsRecorded(ctx context.Context, arg DailyPostingsRecordedParams) ([]DailyPostingsRecordedRow, error)
 	DeactivateAccount(ctx context.Context, id int64) error
+	DeleteAttachment(ctx context.Context, arg DeleteAttachmentParams) (int64, error)
+	DeleteBudget(ctx context.Context, arg DeleteBudgetParams) (int64, error)
+	DeleteCategory(ctx context.Context, arg DeleteCategoryParams) (int64, error)
+	DeleteOpenReconciliation(ctx context.Context, id int64) (int64, error)
+	DeleteRecurringRule(ctx context.Context, arg DeleteRecurringRuleParams) (int64, error)
+	ExpireHolds(ctx context.Context, now time.Time) (int64, error)
+	FailStatement(ctx context.Context, id int64) error
 	GetAccount(ctx context.Context, id int64) (GetAccountRow, error)
+	GetAccountLimits(ctx context.Context, accountID int64) (AccountLimit, error)
+	GetAmendmentOf(ctx context.Context, transactionID int64) (int64, error)
+	GetAttachmentForUser(ctx context.Context, arg GetAttachmentForUserParams) (Attachment, error)
+	GetBudgetForUser(ctx context.Context, arg GetBudgetForUserParams) (Budget, error)
+	GetCategoryForUser(ctx context.Context, arg GetCategoryForUserParams) (Category, error)
+	// Inflow/outflow on the user's asset and liability accounts in one month,
+	// per line category (the posting's, else the header's) and currency.
+	// Uncategorized lines come back with a NULL category.
+	GetCategorySummary(ctx context.Context, arg GetCategorySummaryParams) ([]GetCategorySummaryRow, error)
+	GetCounterpartAccount(ctx context.Context, arg GetCounterpartAccountParams) (GetCounterpartAccountRow, error)
 	GetExportByID(ctx context.Context, id int64) (Export, error)
 	GetExternalAccount(ctx context.Context, userID int64) (GetExternalAccountRow, error)
+	GetFXAccount(ctx context.Context, userID int64) (GetFXAccountRow, error)
+	// The rate effective on a day is the latest one published on or before it.
+	GetFXRateOnOrBefore(ctx context.Context, arg GetFXRateOnOrBeforeParams) (GetFXRateOnOrBeforeRow, error)
+	GetHoldForUser(ctx context.Context, arg GetHoldForUserParams) (Hold, error)
 	GetIdempotency(ctx context.Context, arg GetIdempotencyParams) (IdempotencyKey, error)
 	GetLatestSnapshot(ctx context.Context, accountID int64) (BalanceSnapshot, error)
 	GetMonthlySummary(ctx context.Context, arg GetMonthlySummaryParams) (GetMonthlySummaryRow, error)
+	GetReconciliation(ctx context.Context, id int64) (Reconciliation, error)
+	GetReconciliationPosting(ctx context.Context, id int64) (GetReconciliationPostingRow, error)
+	GetRecurringRuleForUser(ctx context.Context, arg GetRecurringRuleForUserParams) (RecurringRule, error)
 	GetSnapshotOnDate(ctx context.Context, arg GetSnapshotOnDateParams) (BalanceSnapshot, error)
+	// The newest snapshot taken for a day on or before as_of_date.
+	GetSnapshotOnOrBefore(ctx context.Context, arg GetSnapshotOnOrBeforeParams) (BalanceSnapshot, error)
+	GetStatement(ctx context.Context, id int64) (Statement, error)
+	// GetMonthlySummary over an account and every account below it, per
+	// currency. Transfers inside the subtree count on both sides.
+	GetSubtreeMonthlySummary(ctx context.Context, arg GetSubtreeMonthlySummaryParams) ([]GetSubtreeMonthlySummaryRow, error)
 	GetTransactionForUser(ctx context.Context, arg GetTransactionForUserParams) (GetTransactionForUserRow, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id int64) (User, error)
+	// Income and expense postings of the user dated in [from, to), summed per
+	// account, currency and UTC calendar month. Months without activity are
+	// absent; the service lays the rows out into columns.
+	IncomeStatement(ctx context.Context, arg IncomeStatementParams) ([]IncomeStatementRow, error)
 	// Claim an idempotency key. Returns no row when the key already exists;
 	// callers then read the existing record with GetIdempotency.
 	InsertIdempotencyKey(ctx context.Context, arg InsertIdempotencyKeyParams) (IdempotencyKey, error)
+	InsertPeriodAudit(ctx context.Context, arg InsertPeriodAuditParams) error
+	// Fails with a unique violation if another session has cleared one of the
+	// postings since it was matched.
+	InsertReconciledPostings(ctx context.Context, reconciliationID int64) (int64, error)
+	// Whether month is closed for the user, by the user or globally.
+	IsPeriodClosed(ctx context.Context, arg IsPeriodClosedParams) (bool, error)
 	// Ledger entries for one account, newest first, keyset-paginated on
 	// (occurred_at, posting id). Pass cursor values of 'infinity'/max id
 	// for the first page.
 	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]ListAccountEntriesRow, error)
 	ListAccountsByUser(ctx context.Context, arg ListAccountsByUserParams) ([]ListAccountsByUserRow, error)
 	ListAllAccountIDs(ctx context.Context) ([]int64, error)
+	ListAllAccountsByUser(ctx context.Context, userID int64) ([]ListAllAccountsByUserRow, error)
+	ListAttachmentsByTransaction(ctx context.Context, transactionID int64) ([]Attachment, error)
+	// Budgets already in effect in month (start_month on or before it).
+	ListBudgetsForMonth(ctx context.Context, arg ListBudgetsForMonthParams) ([]Budget, error)
+	ListCategoriesByUser(ctx context.Context, userID int64) ([]Category, error)
+	ListCategoryChanges(ctx context.Context, transactionID int64) ([]TransactionCategoryChange, error)
+	// The user's own closes and the global ones, newest month first.
+	ListClosedPeriods(ctx context.Context, userID int64) ([]ClosedPeriod, error)
+	ListDueRecurringRules(ctx context.Context, today time.Time) ([]RecurringRule, error)
+	ListHoldsByAccount(ctx context.Context, arg ListHoldsByAccountParams) ([]Hold, error)
+	ListNormalAccountsByUser(ctx context.Context, userID int64) ([]ListNormalAccountsByUserRow, error)
+	// Events whose payload names the transaction, oldest first.
+	ListOutboxEventsForTransaction(ctx context.Context, transactionID int64) ([]Outbox, error)
+	ListPeriodAudit(ctx context.Context, arg ListPeriodAuditParams) ([]PeriodCloseAudit, error)
 	ListPostingsByTransaction(ctx context.Context, transactionID int64) ([]Posting, error)
+	ListPostingsByTransactions(ctx context.Context, transactionIds []int64) ([]Posting, error)
 	// Export rows are postings on the user's normal accounts; the offsetting
-	// external legs would only duplicate every line.
+	// external legs would only duplicate every line. category_id is the line
+	// category, falling back to the header's; attachment_ids lists the
+	// transaction's attachments as ';'-separated ids.
 	ListPostingsForMonth(ctx context.Context, arg ListPostingsForMonthParams) ([]ListPostingsForMonthRow, error)
+	// Postings of the account dated in [from, to) that no completed
+	// reconciliation has cleared and no line of this session is matched to,
+	// oldest first.
+	ListReconciliationCandidates(ctx context.Context, arg ListReconciliationCandidatesParams) ([]ListReconciliationCandidatesRow, error)
+	ListReconciliationLines(ctx context.Context, reconciliationID int64) ([]ReconciliationLine, error)
+	ListReconciliationsByAccount(ctx context.Context, accountID int64) ([]Reconciliation, error)
+	ListRecurringRulesByUser(ctx context.Context, userID int64) ([]RecurringRule, error)
+	// Every reversal of a transaction, full or partial, oldest first.
+	ListReversalsOf(ctx context.Context, transactionID int64) ([]int64, error)
+	// A transaction's postings with how much of each is already reversed
+	// (reversed_minor has the opposite sign to amount_minor) and the account
+	// kind, for planning a further reversal.
+	ListReversiblePostings(ctx context.Context, transactionID int64) ([]ListReversiblePostingsRow, error)
+	// Balance history reads each series for a whole set of accounts at once,
+	// so a net-worth history costs the same round trips as one account's.
+	ListSnapshotsBetween(ctx context.Context, arg ListSnapshotsBetweenParams) ([]BalanceSnapshot, error)
+	// Accounts that get a statement every month: active asset and liability
+	// accounts.
+	ListStatementAccounts(ctx context.Context) ([]ListStatementAccountsRow, error)
+	// An account's postings dated in [from, to), oldest first, with the
+	// transaction's payee and note.
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	ListStatementsByUser(ctx context.Context, arg ListStatementsByUserParams) ([]Statement, error)
+	// Locks the limits rows of the given accounts in id order, so two
+	// transactions touching the same accounts always lock them in the same
+	// order and cannot deadlock. Accounts without limits return no row.
+	LockAccountLimits(ctx context.Context, accountIds []int64) ([]AccountLimit, error)
+	// Serializes re-parenting within one user's chart of accounts, so two
+	// concurrent moves cannot each pass the cycle check and together form a
+	// loop.
+	LockAccountTree(ctx context.Context, userID int64) error
+	// Budgets in effect in month on any of category_ids or their ancestors,
+	// locked so concurrent postings into the same budget check it one at a time.
+	LockBudgetsCoveringCategories(ctx context.Context, arg LockBudgetsCoveringCategoriesParams) ([]Budget, error)
+	// Serializes re-parenting within one user's category tree, like
+	// LockAccountTree.
+	LockCategoryTree(ctx context.Context, userID int64) error
+	// Row lock so capture, void and the expiry sweep serialize on a hold.
+	LockHoldForUser(ctx context.Context, arg LockHoldForUserParams) (Hold, error)
+	LockPeriodsExclusive(ctx context.Context) error
+	// Posting paths take the period locks shared and closing takes one
+	// exclusive, so a close waits for in-flight backdated postings and every
+	// posting after it sees the close. Each user has a lock of their own, and
+	// the global lock is only taken exclusive by global closes, so one user's
+	// close never waits on, or stalls, another user's postings.
+	LockPeriodsShared(ctx context.Context, userID int64) error
+	// Serializes changes to one session.
+	LockReconciliation(ctx context.Context, id int64) (Reconciliation, error)
+	// Serializes completing the sessions of one account, so each computes its
+	// cleared balance with the postings the others reconciled.
+	LockReconciliationAccount(ctx context.Context, accountID int64) error
+	// Row lock on a header the caller is about to change or build on.
+	LockTransactionForUser(ctx context.Context, arg LockTransactionForUserParams) (LockTransactionForUserRow, error)
+	LockUserPeriodsExclusive(ctx context.Context, userID int64) error
 	MarkIdempotencySuccess(ctx context.Context, arg MarkIdempotencySuccessParams) error
+	MatchReconciliationLine(ctx context.Context, arg MatchReconciliationLineParams) (int64, error)
+	// OpeningBalancesRecorded on the occurred basis, moving the postings whose
+	// accounting date and recording fall on opposite sides of as_of, as
+	// SumPostingsOccurredDelta does for one account.
+	OpeningBalancesOccurred(ctx context.Context, arg OpeningBalancesOccurredParams) ([]OpeningBalancesOccurredRow, error)
+	// BalanceAsOf on the recorded basis for several accounts: each account's
+	// newest snapshot up to snapshot_date, plus the postings recorded from the
+	// end of that day until as_of. Accounts without a snapshot sum every
+	// posting recorded before as_of.
+	OpeningBalancesRecorded(ctx context.Context, arg OpeningBalancesRecordedParams) ([]OpeningBalancesRecordedRow, error)
+	ReopenPeriod(ctx context.Context, arg ReopenPeriodParams) (int64, error)
+	// User-wide transaction search, newest first, keyset-paginated on
+	// (occurred_at, id) like ListAccountEntries. Every filter is optional:
+	//   from_time/to_time   occurred_at in [from, to)
+	//   account_ids         touches any of these accounts (empty = any)
+	//   min/max_amount      some posting on a normal account (or on one of
+	//                       account_ids) has an absolute amount in range
+	//   category_id         header or line category in this category's subtree
+	//   reversal            'reversed' (has been reversed), 'reversal' (is
+	//                       one), 'none' (neither)
+	//   note_pattern        ILIKE pattern on the note
+	//   text_query          to_tsquery('english') input matched against payee
+	//                       and note; results then rank by relevance first
+	// The highlight is HTML: payee and note are escaped before the matches are
+	// wrapped in <mark>, so it is safe to render as markup.
+	// rank is 0 without a text_query, so the order is plain newest-first and
+	// cursor_rank must be 0; with one, pass 'Infinity' for the first page.
+	SearchTransactions(ctx context.Context, arg SearchTransactionsParams) ([]SearchTransactionsRow, error)
+	SetAccountParent(ctx context.Context, arg SetAccountParentParams) (SetAccountParentRow, error)
+	SetRecurringRuleState(ctx context.Context, arg SetRecurringRuleStateParams) (RecurringRule, error)
+	SetTransactionCategory(ctx context.Context, arg SetTransactionCategoryParams) error
+	// Ledger balance and pending holds of an account and every account below
+	// it, per currency. Each account counts as in CurrentBalance: its latest
+	// snapshot plus the postings created after the snapshot's day, so the
+	// whole subtree costs one round trip.
+	SubtreeBalances(ctx context.Context, arg SubtreeBalancesParams) ([]SubtreeBalancesRow, error)
+	// Holds past expires_at stop counting immediately, even before the expiry
+	// sweep has flipped their status.
+	SumPendingHolds(ctx context.Context, arg SumPendingHoldsParams) (int64, error)
+	// Turns a recorded-basis snapshot cut at since into an occurred-basis
+	// balance for entries dated before before: adds postings recorded after the
+	// snapshot but dated before the cutoff, and takes out postings the snapshot
+	// counted that are dated at or after it.
+	SumPostingsOccurredDelta(ctx context.Context, arg SumPostingsOccurredDeltaParams) (int64, error)
+	// Postings recorded in (since, before): what the system learned between a
+	// snapshot cutoff and a later instant.
+	SumPostingsRecordedBetween(ctx context.Context, arg SumPostingsRecordedBetweenParams) (int64, error)
 	// Balances are derived from postings by created_at (insertion time), not
 	// occurred_at, so backdated entries can never fall behind a snapshot cutoff.
 	SumPostingsSince(ctx context.Context, arg SumPostingsSinceParams) (int64, error)
 	// Snapshot cutoff uses created_at to match SumPostingsSince semantics.
 	SumPostingsUpTo(ctx context.Context, arg SumPostingsUpToParams) (int64, error)
+	// Lexemes left in a text_query once stop words are dropped; 0 means the
+	// query would match nothing.
+	TextQueryNodes(ctx context.Context, textQuery string) (int32, error)
+	// What one transaction adds to a budget's spending (see BudgetSpendByMonth).
+	TransactionSpend(ctx context.Context, arg TransactionSpendParams) (int64, error)
+	// Every account of the user with its debit and credit turnover and closing
+	// balance over entries dated before the cutoff, one row per currency the
+	// account has posted in (its own currency when it has none). Accounts are
+	// listed in chart order: assets, liabilities, equity, income, expenses.
+	TrialBalance(ctx context.Context, arg TrialBalanceParams) ([]TrialBalanceRow, error)
+	UnmatchReconciliationLine(ctx context.Context, arg UnmatchReconciliationLineParams) (int64, error)
+	UpdateBudget(ctx context.Context, arg UpdateBudgetParams) (Budget, error)
+	UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error)
 	UpdateExportStatus(ctx context.Context, arg UpdateExportStatusParams) error
+	// Replaces the editable fields; kind and accounts are fixed at creation.
+	UpdateRecurringRule(ctx context.Context, arg UpdateRecurringRuleParams) (RecurringRule, error)
+	UpsertAccountLimits(ctx context.Context, arg UpsertAccountLimitsParams) (AccountLimit, error)
 	UpsertBalanceSnapshot(ctx context.Context, arg UpsertBalanceSnapshotParams) error
+	UpsertFXRate(ctx context.Context, arg UpsertFXRateParams) error
+	UpsertStatement(ctx context.Context, arg UpsertStatementParams) (Statement, error)
+	VoidHold(ctx context.Context, id int64) (Hold, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -8,8 +8,68 @@ package repo
 import (
 	"context"
 	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const getCategorySummary = `-- name: GetCategorySummary :many
+SELECT COALESCE(p.category_id, t.category_id) AS category_id,
+       p.currency,
+       COALESCE(SUM(CASE WHEN p.amount_minor > 0 THEN p.amount_minor ELSE 0 END), 0)::bigint AS inflow,
+       COALESCE(SUM(CASE WHEN p.amount_minor < 0 THEN -p.amount_minor ELSE 0 END), 0)::bigint AS outflow,
+       COALESCE(SUM(p.amount_minor), 0)::bigint AS net
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+JOIN accounts a ON a.id = p.account_id
+WHERE t.user_id = $1
+  AND a.kind = 'normal' AND a.type IN ('asset', 'liability')
+  AND date_trunc('month', t.occurred_at) = date_trunc('month', $2::timestamptz)
+GROUP BY 1, 2
+ORDER BY 1 NULLS LAST, 2
+`
+
+type GetCategorySummaryParams struct {
+	UserID int64
+	Month  time.Time
+}
+
+type GetCategorySummaryRow struct {
+	CategoryID pgtype.Int8
+	Currency   string
+	Inflow     int64
+	Outflow    int64
+	Net        int64
+}
+
+// Inflow/outflow on the user's asset and liability accounts in one month,
+// per line category (the posting's, else the header's) and currency.
+// Uncategorized lines come back with a NULL category.
+func (q *Queries) GetCategorySummary(ctx context.Context, arg GetCategorySummaryParams) ([]GetCategorySummaryRow, error) {
+	rows, err := q.db.Query(ctx, getCategorySummary, arg.UserID, arg.Month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategorySummaryRow
+	for rows.Next() {
+		var i GetCategorySummaryRow
+		if err := rows.Scan(
+			&i.CategoryID,
+			&i.Currency,
+			&i.Inflow,
+			&i.Outflow,
+			&i.Net,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getMonthlySummary = `-- name: GetMonthlySummary :one
 SELECT
     COALESCE(SUM(CASE WHEN p.amount_minor > 0 THEN p.amount_minor ELSE 0 END), 0)::bigint AS inflow,
@@ -38,3 +98,60 @@ func (q *Queries) GetMonthlySummary(ctx context.Context, arg GetMonthlySummaryPa
 	err := row.Scan(&i.Inflow, &i.Outflow, &i.Net)
 	return i, err
 }
+
+const getSubtreeMonthlySummary = `-- name: GetSubtreeMonthlySummary :many
+WITH RECURSIVE subtree AS (
+  SELECT a.id FROM accounts a WHERE a.id = $1
+  UNION
+  SELECT a.id FROM accounts a JOIN subtree s ON a.parent_id = s.id
+)
+SELECT p.currency,
+       COALESCE(SUM(CASE WHEN p.amount_minor > 0 THEN p.amount_minor ELSE 0 END), 0)::bigint AS inflow,
+       COALESCE(SUM(CASE WHEN p.amount_minor < 0 THEN -p.amount_minor ELSE 0 END), 0)::bigint AS outflow,
+       COALESCE(SUM(p.amount_minor), 0)::bigint AS net
+FROM postings p
+JOIN transactions t ON t.id = p.transaction_id
+WHERE p.account_id IN (SELECT id FROM subtree)
+  AND date_trunc('month', t.occurred_at) = date_trunc('month', $2::timestamptz)
+GROUP BY p.currency
+ORDER BY p.currency
+`
+
+type GetSubtreeMonthlySummaryParams struct {
+	AccountID int64
+	Month     time.Time
+}
+
+type GetSubtreeMonthlySummaryRow struct {
+	Currency string
+	Inflow   int64
+	Outflow  int64
+	Net      int64
+}
+
+// GetMonthlySummary over an account and every account below it, per
+// currency. Transfers inside the subtree count on both sides.
+func (q *Queries) GetSubtreeMonthlySummary(ctx context.Context, arg GetSubtreeMonthlySummaryParams) ([]GetSubtreeMonthlySummaryRow, error) {
+	rows, err := q.db.Query(ctx, getSubtreeMonthlySummary, arg.AccountID, arg.Month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSubtreeMonthlySummaryRow
+	for rows.Next() {
+		var i GetSubtreeMonthlySummaryRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.Inflow,
+			&i.Outflow,
+			&i.Net,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
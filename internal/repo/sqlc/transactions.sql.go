@@ -13,19 +13,29 @@ import (
 )
 
 const createPosting = `-- name: CreatePosting :one
-INSERT INTO postings (transaction_id, account_id, amount_minor)
-VALUES ($1, $2, $3)
-RETURNING id, transaction_id, account_id, amount_minor, created_at
+INSERT INTO postings (transaction_id, account_id, amount_minor, currency, category_id, reverses_posting_id)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, transaction_id, account_id, amount_minor, created_at, currency, category_id, reverses_posting_id
 `
 
 type CreatePostingParams struct {
-	TransactionID int64
-	AccountID     int64
-	AmountMinor   int64
+	TransactionID     int64
+	AccountID         int64
+	AmountMinor       int64
+	Currency          string
+	CategoryID        pgtype.Int8
+	ReversesPostingID pgtype.Int8
 }
 
 func (q *Queries) CreatePosting(ctx context.Context, arg CreatePostingParams) (Posting, error) {
-	row := q.db.QueryRow(ctx, createPosting, arg.TransactionID, arg.AccountID, arg.AmountMinor)
+	row := q.db.QueryRow(ctx, createPosting,
+		arg.TransactionID,
+		arg.AccountID,
+		arg.AmountMinor,
+		arg.Currency,
+		arg.CategoryID,
+		arg.ReversesPostingID,
+	)
 	var i Posting
 	err := row.Scan(
 		&i.ID,
@@ -33,14 +43,18 @@ func (q *Queries) CreatePosting(ctx context.Context, arg CreatePostingParams) (P
 		&i.AccountID,
 		&i.AmountMinor,
 		&i.CreatedAt,
+		&i.Currency,
+		&i.CategoryID,
+		&i.ReversesPostingID,
 	)
 	return i, err
 }
 
 const createTransactionHeader = `-- name: CreateTransactionHeader :one
-INSERT INTO transactions (user_id, currency, category_id, occurred_at, note, reversal_of)
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING id, user_id, currency, category_id, occurred_at, note, reversal_of, created_at
+INSERT INTO transactions (user_id, currency, category_id, occurred_at, note, payee, reversal_of, amends, fx_rate)
+VALUES ($1, $2, $3, $4,
+        $5, $6, $7, $8, $9::text::numeric)
+RETURNING id, user_id, currency, category_id, occurred_at, note, payee, reversal_of, amends, fx_rate::text AS fx_rate, created_at
 `
 
 type CreateTransactionHeaderParams struct {
@@ -49,7 +63,10 @@ type CreateTransactionHeaderParams struct {
 	CategoryID pgtype.Int8
 	OccurredAt time.Time
 	Note       pgtype.Text
+	Payee      pgtype.Text
 	ReversalOf pgtype.Int8
+	Amends     pgtype.Int8
+	FxRate     pgtype.Text
 }
 
 type CreateTransactionHeaderRow struct {
@@ -59,10 +76,14 @@ type CreateTransactionHeaderRow struct {
 	CategoryID pgtype.Int8
 	OccurredAt time.Time
 	Note       pgtype.Text
+	Payee      pgtype.Text
 	ReversalOf pgtype.Int8
+	Amends     pgtype.Int8
+	FxRate     pgtype.Text
 	CreatedAt  time.Time
 }
 
+// fx_rate round-trips as text so no precision is lost to a Go numeric type.
 func (q *Queries) CreateTransactionHeader(ctx context.Context, arg CreateTransactionHeaderParams) (CreateTransactionHeaderRow, error) {
 	row := q.db.QueryRow(ctx, createTransactionHeader,
 		arg.UserID,
@@ -70,7 +91,10 @@ func (q *Queries) CreateTransactionHeader(ctx context.Context, arg CreateTransac
 		arg.CategoryID,
 		arg.OccurredAt,
 		arg.Note,
+		arg.Payee,
 		arg.ReversalOf,
+		arg.Amends,
+		arg.FxRate,
 	)
 	var i CreateTransactionHeaderRow
 	err := row.Scan(
@@ -80,14 +104,28 @@ func (q *Queries) CreateTransactionHeader(ctx context.Context, arg CreateTransac
 		&i.CategoryID,
 		&i.OccurredAt,
 		&i.Note,
+		&i.Payee,
 		&i.ReversalOf,
+		&i.Amends,
+		&i.FxRate,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
+const getAmendmentOf = `-- name: GetAmendmentOf :one
+SELECT id FROM transactions WHERE amends = $1
+`
+
+func (q *Queries) GetAmendmentOf(ctx context.Context, transactionID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, getAmendmentOf, transactionID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getTransactionForUser = `-- name: GetTransactionForUser :one
-SELECT id, user_id, currency, category_id, occurred_at, note, reversal_of, created_at
+SELECT id, user_id, currency, category_id, occurred_at, note, payee, reversal_of, amends, fx_rate::text AS fx_rate, created_at
 FROM transactions
 WHERE id = $1 AND user_id = $2
 `
@@ -104,7 +142,10 @@ type GetTransactionForUserRow struct {
 	CategoryID pgtype.Int8
 	OccurredAt time.Time
 	Note       pgtype.Text
+	Payee      pgtype.Text
 	ReversalOf pgtype.Int8
+	Amends     pgtype.Int8
+	FxRate     pgtype.Text
 	CreatedAt  time.Time
 }
 
@@ -118,7 +159,10 @@ func (q *Queries) GetTransactionForUser(ctx context.Context, arg GetTransactionF
 		&i.CategoryID,
 		&i.OccurredAt,
 		&i.Note,
+		&i.Payee,
 		&i.ReversalOf,
+		&i.Amends,
+		&i.FxRate,
 		&i.CreatedAt,
 	)
 	return i, err
@@ -129,7 +173,7 @@ SELECT p.id AS posting_id,
        t.id AS transaction_id,
        p.account_id,
        p.amount_minor,
-       t.currency,
+       p.currency,
        t.occurred_at,
        t.note,
        p.created_at
@@ -197,7 +241,7 @@ func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntries
 }
 
 const listPostingsByTransaction = `-- name: ListPostingsByTransaction :many
-SELECT id, transaction_id, account_id, amount_minor, created_at
+SELECT id, transaction_id, account_id, amount_minor, created_at, currency, category_id, reverses_posting_id
 FROM postings
 WHERE transaction_id = $1
 ORDER BY id
@@ -218,6 +262,313 @@ func (q *Queries) ListPostingsByTransaction(ctx context.Context, transactionID i
 			&i.AccountID,
 			&i.AmountMinor,
 			&i.CreatedAt,
+			&i.Currency,
+			&i.CategoryID,
+			&i.ReversesPostingID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPostingsByTransactions = `-- name: ListPostingsByTransactions :many
+SELECT id, transaction_id, account_id, amount_minor, created_at, currency, category_id, reverses_posting_id
+FROM postings
+WHERE transaction_id = ANY($1::bigint[])
+ORDER BY transaction_id, id
+`
+
+func (q *Queries) ListPostingsByTransactions(ctx context.Context, transactionIds []int64) ([]Posting, error) {
+	rows, err := q.db.Query(ctx, listPostingsByTransactions, transactionIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Posting
+	for rows.Next() {
+		var i Posting
+		if err := rows.Scan(
+			&i.ID,
+			&i.TransactionID,
+			&i.AccountID,
+			&i.AmountMinor,
+			&i.CreatedAt,
+			&i.Currency,
+			&i.CategoryID,
+			&i.ReversesPostingID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReversalsOf = `-- name: ListReversalsOf :many
+SELECT id FROM transactions WHERE reversal_of = $1 ORDER BY id
+`
+
+// Every reversal of a transaction, full or partial, oldest first.
+func (q *Queries) ListReversalsOf(ctx context.Context, transactionID int64) ([]int64, error) {
+	rows, err := q.db.Query(ctx, listReversalsOf, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReversiblePostings = `-- name: ListReversiblePostings :many
+SELECT p.id, p.account_id, p.amount_minor, p.currency, p.category_id, a.kind AS account_kind,
+       COALESCE((SELECT SUM(r.amount_minor) FROM postings r WHERE r.reverses_posting_id = p.id), 0)::bigint AS reversed_minor
+FROM postings p
+JOIN accounts a ON a.id = p.account_id
+WHERE p.transaction_id = $1
+ORDER BY p.id
+`
+
+type ListReversiblePostingsRow struct {
+	ID            int64
+	AccountID     int64
+	AmountMinor   int64
+	Currency      string
+	CategoryID    pgtype.Int8
+	AccountKind   string
+	ReversedMinor int64
+}
+
+// A transaction's postings with how much of each is already reversed
+// (reversed_minor has the opposite sign to amount_minor) and the account
+// kind, for planning a further reversal.
+func (q *Queries) ListReversiblePostings(ctx context.Context, transactionID int64) ([]ListReversiblePostingsRow, error) {
+	rows, err := q.db.Query(ctx, listReversiblePostings, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReversiblePostingsRow
+	for rows.Next() {
+		var i ListReversiblePostingsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.AmountMinor,
+			&i.Currency,
+			&i.CategoryID,
+			&i.AccountKind,
+			&i.ReversedMinor,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockTransactionForUser = `-- name: LockTransactionForUser :one
+SELECT id, user_id, currency, category_id, occurred_at, note, payee, reversal_of, amends, fx_rate::text AS fx_rate, created_at
+FROM transactions
+WHERE id = $1 AND user_id = $2
+FOR UPDATE
+`
+
+type LockTransactionForUserParams struct {
+	ID     int64
+	UserID int64
+}
+
+type LockTransactionForUserRow struct {
+	ID         int64
+	UserID     int64
+	Currency   string
+	CategoryID pgtype.Int8
+	OccurredAt time.Time
+	Note       pgtype.Text
+	Payee      pgtype.Text
+	ReversalOf pgtype.Int8
+	Amends     pgtype.Int8
+	FxRate     pgtype.Text
+	CreatedAt  time.Time
+}
+
+// Row lock on a header the caller is about to change or build on.
+func (q *Queries) LockTransactionForUser(ctx context.Context, arg LockTransactionForUserParams) (LockTransactionForUserRow, error) {
+	row := q.db.QueryRow(ctx, lockTransactionForUser, arg.ID, arg.UserID)
+	var i LockTransactionForUserRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Currency,
+		&i.CategoryID,
+		&i.OccurredAt,
+		&i.Note,
+		&i.Payee,
+		&i.ReversalOf,
+		&i.Amends,
+		&i.FxRate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const searchTransactions = `-- name: SearchTransactions :many
+WITH RECURSIVE subtree AS (
+  SELECT c.id FROM categories c WHERE c.id = $1 AND c.user_id = $2
+  UNION
+  SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+)
+SELECT t.id, t.user_id, t.currency, t.category_id, t.occurred_at, t.note, t.payee, t.reversal_of, t.amends,
+       t.fx_rate::text AS fx_rate, t.created_at, r.rank,
+       CASE WHEN tq.query IS NULL THEN ''
+            ELSE ts_headline('english',
+                             replace(replace(replace(replace(replace(concat_ws(' · ', t.payee, t.note),
+                               '&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&quot;'), '''', '&#39;'),
+                             tq.query,
+                             'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MinWords=4, MaxWords=16')
+       END::text AS highlight
+FROM transactions t
+CROSS JOIN LATERAL (SELECT to_tsquery('english', $3::text) AS query) tq
+CROSS JOIN LATERAL (
+  SELECT CASE WHEN tq.query IS NULL THEN 0 ELSE ts_rank(t.search_vector, tq.query) END::real AS rank
+) r
+WHERE t.user_id = $2
+  AND (tq.query IS NULL OR t.search_vector @@ tq.query)
+  AND (r.rank, t.occurred_at, t.id)
+      < ($4::real, $5::timestamptz, $6::bigint)
+  AND ($7::timestamptz IS NULL OR t.occurred_at >= $7)
+  AND ($8::timestamptz IS NULL OR t.occurred_at < $8)
+  AND ((cardinality($9::bigint[]) = 0
+        AND $10::bigint IS NULL AND $11::bigint IS NULL)
+       OR EXISTS (
+         SELECT 1 FROM postings p JOIN accounts a ON a.id = p.account_id
+         WHERE p.transaction_id = t.id
+           AND (p.account_id = ANY($9::bigint[])
+                OR (cardinality($9::bigint[]) = 0 AND a.kind = 'normal'))
+           AND ($10::bigint IS NULL OR abs(p.amount_minor) >= $10)
+           AND ($11::bigint IS NULL OR abs(p.amount_minor) <= $11)))
+  AND ($1::bigint IS NULL
+       OR t.category_id IN (SELECT id FROM subtree)
+       OR EXISTS (SELECT 1 FROM postings p
+                  WHERE p.transaction_id = t.id AND p.category_id IN (SELECT id FROM subtree)))
+  AND ($12::text IS NULL
+       OR ($12 = 'reversal' AND t.reversal_of IS NOT NULL)
+       OR ($12 = 'reversed' AND EXISTS (SELECT 1 FROM transactions r WHERE r.reversal_of = t.id))
+       OR ($12 = 'none' AND t.reversal_of IS NULL
+           AND NOT EXISTS (SELECT 1 FROM transactions r WHERE r.reversal_of = t.id)))
+  AND ($13::text IS NULL OR t.note ILIKE $13)
+ORDER BY r.rank DESC, t.occurred_at DESC, t.id DESC
+LIMIT $14
+`
+
+type SearchTransactionsParams struct {
+	CategoryID       pgtype.Int8
+	UserID           int64
+	TextQuery        pgtype.Text
+	CursorRank       float32
+	CursorOccurredAt time.Time
+	CursorID         int64
+	FromTime         pgtype.Timestamptz
+	ToTime           pgtype.Timestamptz
+	AccountIds       []int64
+	MinAmount        pgtype.Int8
+	MaxAmount        pgtype.Int8
+	Reversal         pgtype.Text
+	NotePattern      pgtype.Text
+	PageLimit        int32
+}
+
+type SearchTransactionsRow struct {
+	ID         int64
+	UserID     int64
+	Currency   string
+	CategoryID pgtype.Int8
+	OccurredAt time.Time
+	Note       pgtype.Text
+	Payee      pgtype.Text
+	ReversalOf pgtype.Int8
+	Amends     pgtype.Int8
+	FxRate     pgtype.Text
+	CreatedAt  time.Time
+	Rank       float32
+	Highlight  string
+}
+
+// User-wide transaction search, newest first, keyset-paginated on
+// (occurred_at, id) like ListAccountEntries. Every filter is optional:
+//   from_time/to_time   occurred_at in [from, to)
+//   account_ids         touches any of these accounts (empty = any)
+//   min/max_amount      some posting on a normal account (or on one of
+//                       account_ids) has an absolute amount in range
+//   category_id         header or line category in this category's subtree
+//   reversal            'reversed' (has been reversed), 'reversal' (is
+//                       one), 'none' (neither)
+//   note_pattern        ILIKE pattern on the note
+//   text_query          to_tsquery('english') input matched against payee
+//                       and note; results then rank by relevance first
+// The highlight is HTML: payee and note are escaped before the matches are
+// wrapped in <mark>, so it is safe to render as markup.
+// rank is 0 without a text_query, so the order is plain newest-first and
+// cursor_rank must be 0; with one, pass 'Infinity' for the first page.
+func (q *Queries) SearchTransactions(ctx context.Context, arg SearchTransactionsParams) ([]SearchTransactionsRow, error) {
+	rows, err := q.db.Query(ctx, searchTransactions,
+		arg.CategoryID,
+		arg.UserID,
+		arg.TextQuery,
+		arg.CursorRank,
+		arg.CursorOccurredAt,
+		arg.CursorID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AccountIds,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.Reversal,
+		arg.NotePattern,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTransactionsRow
+	for rows.Next() {
+		var i SearchTransactionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Currency,
+			&i.CategoryID,
+			&i.OccurredAt,
+			&i.Note,
+			&i.Payee,
+			&i.ReversalOf,
+			&i.Amends,
+			&i.FxRate,
+			&i.CreatedAt,
+			&i.Rank,
+			&i.Highlight,
 		); err != nil {
 			return nil, err
 		}
@@ -228,3 +579,30 @@ func (q *Queries) ListPostingsByTransaction(ctx context.Context, transactionID i
 	}
 	return items, nil
 }
+
+const setTransactionCategory = `-- name: SetTransactionCategory :exec
+UPDATE transactions SET category_id = $1 WHERE id = $2
+`
+
+type SetTransactionCategoryParams struct {
+	CategoryID pgtype.Int8
+	ID         int64
+}
+
+func (q *Queries) SetTransactionCategory(ctx context.Context, arg SetTransactionCategoryParams) error {
+	_, err := q.db.Exec(ctx, setTransactionCategory, arg.CategoryID, arg.ID)
+	return err
+}
+
+const textQueryNodes = `-- name: TextQueryNodes :one
+SELECT numnode(to_tsquery('english', $1::text))::int AS nodes
+`
+
+// Lexemes left in a text_query once stop words are dropped; 0 means the
+// query would match nothing.
+func (q *Queries) TextQueryNodes(ctx context.Context, textQuery string) (int32, error) {
+	row := q.db.QueryRow(ctx, textQueryNodes, textQuery)
+	var nodes int32
+	err := row.Scan(&nodes)
+	return nodes, err
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+// Account types of the chart of accounts.
+const (
+	AccountAsset     = "asset"
+	AccountLiability = "liability"
+	AccountEquity    = "equity"
+	AccountIncome    = "income"
+	AccountExpense   = "expense"
+)
+
+var (
+	ErrAccountType   = errors.New("invalid account type")
+	ErrAccountExists = errors.New("an account with this name already exists")
+	ErrAccountParent = errors.New("a parent account must be one of your normal accounts of the same type")
+	ErrAccountCycle  = errors.New("an account cannot be moved under itself or its descendants")
+)
+
+// AccountService manages a user's chart of accounts.
+type AccountService struct {
+	pool *pgxpool.Pool
+	q    *sqlc.Queries
+}
+
+func NewAccountService(pool *pgxpool.Pool, q *sqlc.Queries) *AccountService {
+	return &AccountService{pool: pool, q: q}
+}
+
+type AccountDTO struct {
+	ID            int64  `json:"id"`
+	UserID        int64  `json:"user_id"`
+	Name          string `json:"name"`
+	Currency      string `json:"currency"`
+	Active        bool   `json:"active"`
+	Kind          string `json:"kind"`
+	Type          string `json:"type"`
+	NormalBalance string `json:"normal_balance"` // debit | credit
+	ParentID      int64  `json:"parent_id,omitempty"`
+}
+
+// toAccountDTO takes any of the account query rows, which share one shape.
+func toAccountDTO(a sqlc.GetAccountRow) AccountDTO {
+	return AccountDTO{
+		ID: a.ID, UserID: a.UserID, Name: a.Name, Currency: a.Currency, Active: a.IsActive,
+		Kind: a.Kind, Type: a.Type, NormalBalance: NormalBalance(a.Type), ParentID: a.ParentID.Int64,
+	}
+}
+
+type CreateAccountInput struct {
+	UserID   int64
+	Name     string
+	Currency string // default USD
+	Type     string // default asset
+	ParentID int64  // optional
+	// DefaultCounterpart makes an income or expense account the
+	// outside-world side of the user's income or spending, in place of the
+	// catch-all external account. It cannot have a parent.
+	DefaultCounterpart bool
+}
+
+func (s *AccountService) Create(ctx context.Context, in CreateAccountInput) (AccountDTO, error) {
+	if in.Currency == "" {
+		in.Currency = "USD"
+	}
+	if in.Type == "" {
+		in.Type = AccountAsset
+	}
+	if err := ValidateAccountType(in.Type, in.DefaultCounterpart); err != nil {
+		return AccountDTO{}, err
+	}
+
+	var row sqlc.GetAccountRow
+	var err error
+	if in.DefaultCounterpart {
+		if in.ParentID != 0 {
+			return AccountDTO{}, ErrAccountParent
+		}
+		var cp sqlc.CreateCounterpartAccountRow
+		cp, err = s.q.CreateCounterpartAccount(ctx, sqlc.CreateCounterpartAccountParams{
+			UserID: in.UserID, Name: in.Name, Currency: in.Currency, Type: in.Type,
+		})
+		if isUniqueViolation(err) {
+			return AccountDTO{}, fmt.Errorf("%w, or you already have a default %s account", ErrAccountExists, in.Type)
+		}
+		row = sqlc.GetAccountRow(cp)
+	} else {
+		if in.ParentID != 0 {
+			if err := checkParent(ctx, s.q, in.UserID, in.Type, in.ParentID); err != nil {
+				return AccountDTO{}, err
+			}
+		}
+		var created sqlc.CreateAccountRow
+		created, err = s.q.CreateAccount(ctx, sqlc.CreateAccountParams{
+			UserID: in.UserID, Name: in.Name, Currency: in.Currency, Type: in.Type,
+			ParentID: pgtype.Int8{Int64: in.ParentID, Valid: in.ParentID != 0},
+		})
+		if isUniqueViolation(err) {
+			return AccountDTO{}, ErrAccountExists
+		}
+		row = sqlc.GetAccountRow(created)
+	}
+	if err != nil {
+		return AccountDTO{}, err
+	}
+	return toAccountDTO(row), nil
+}
+
+func (s *AccountService) List(ctx context.Context, userID int64, limit, offset int32) ([]AccountDTO, error) {
+	rows, err := s.q.ListAccountsByUser(ctx, sqlc.ListAccountsByUserParams{UserID: userID, Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AccountDTO, 0, len(rows))
+	for _, a := range rows {
+		out = append(out, toAccountDTO(sqlc.GetAccountRow(a)))
+	}
+	return out, nil
+}
+
+// SetParent moves an account under parentID, or to the top level when
+// parentID is 0. Moving it under itself or a descendant is refused. Moves
+// within one user's tree are serialized, so the cycle check holds until the
+// update commits.
+func (s *AccountService) SetParent(ctx context.Context, userID, id, parentID int64) (AccountDTO, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return AccountDTO{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	if err := qtx.LockAccountTree(ctx, userID); err != nil {
+		return AccountDTO{}, err
+	}
+	acc, err := ownedAccount(ctx, qtx, userID, id)
+	if err != nil {
+		return AccountDTO{}, err
+	}
+	if parentID != 0 {
+		if acc.Kind != "normal" {
+			return AccountDTO{}, ErrAccountParent
+		}
+		if err := checkParent(ctx, qtx, userID, acc.Type, parentID); err != nil {
+			return AccountDTO{}, err
+		}
+		cycle, err := qtx.AccountIsInSubtree(ctx, sqlc.AccountIsInSubtreeParams{AncestorID: id, CandidateID: parentID})
+		if err != nil {
+			return AccountDTO{}, err
+		}
+		if cycle {
+			return AccountDTO{}, ErrAccountCycle
+		}
+	}
+	row, err := qtx.SetAccountParent(ctx, sqlc.SetAccountParentParams{
+		ParentID: pgtype.Int8{Int64: parentID, Valid: parentID != 0}, ID: id, UserID: userID,
+	})
+	if err != nil {
+		return AccountDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return AccountDTO{}, err
+	}
+	return toAccountDTO(sqlc.GetAccountRow(row)), nil
+}
+
+// AccountNode is an account in the tree, with its colon-separated path from
+// the root ("Assets:Bank:Checking").
+type AccountNode struct {
+	AccountDTO
+	Path     string         `json:"path"`
+	Children []*AccountNode `json:"children"`
+}
+
+// Tree returns the user's accounts as a forest, siblings sorted by name.
+func (s *AccountService) Tree(ctx context.Context, userID int64) ([]*AccountNode, error) {
+	rows, err := s.q.ListAllAccountsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]AccountDTO, 0, len(rows))
+	for _, a := range rows {
+		accounts = append(accounts, toAccountDTO(sqlc.GetAccountRow(a)))
+	}
+	return buildAccountTree(accounts), nil
+}
+
+// buildAccountTree nests accounts under their parents, keeping the input
+// order among siblings. An account whose parent is missing becomes a root.
+func buildAccountTree(accounts []AccountDTO) []*AccountNode {
+	nodes := make(map[int64]*AccountNode, len(accounts))
+	for _, a := range accounts {
+		nodes[a.ID] = &AccountNode{AccountDTO: a, Children: []*AccountNode{}}
+	}
+	roots := []*AccountNode{}
+	for _, a := range accounts {
+		n := nodes[a.ID]
+		if p, ok := nodes[a.ParentID]; ok {
+			p.Children = append(p.Children, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	var walk func(prefix string, ns []*AccountNode)
+	walk = func(prefix string, ns []*AccountNode) {
+		for _, n := range ns {
+			n.Path = prefix + n.Name
+			walk(n.Path+":", n.Children)
+		}
+	}
+	walk("", roots)
+	return roots
+}
+
+// checkParent verifies parentID can hold an account of type t.
+func checkParent(ctx context.Context, q *sqlc.Queries, userID int64, t string, parentID int64) error {
+	p, err := ownedAccount(ctx, q, userID, parentID)
+	if errors.Is(err, ErrAccountNotFound) {
+		return ErrAccountParent
+	}
+	if err != nil {
+		return err
+	}
+	if p.Kind != "normal" || p.Type != t {
+		return ErrAccountParent
+	}
+	return nil
+}
+
+// ownedAccount loads an account of userID; other users' accounts are
+// reported as not found.
+func ownedAccount(ctx context.Context, q *sqlc.Queries, userID, id int64) (sqlc.GetAccountRow, error) {
+	a, err := q.GetAccount(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && a.UserID != userID) {
+		return sqlc.GetAccountRow{}, ErrAccountNotFound
+	}
+	return a, err
+}
+
+// ValidateAccountType checks an account type, and that a default
+// counterpart is an income or expense account.
+func ValidateAccountType(t string, counterpart bool) error {
+	switch t {
+	case AccountAsset, AccountLiability, AccountEquity:
+		if counterpart {
+			return fmt.Errorf("%w: a default counterpart must be an income or expense account", ErrAccountType)
+		}
+		return nil
+	case AccountIncome, AccountExpense:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrAccountType, t)
+}
+
+// NormalBalance is the side an account of type t carries when in its usual
+// state: "debit" for assets and expenses, "credit" for liabilities, equity
+// and income. Postings are debit-positive, so a credit-normal account
+// usually has a negative ledger balance (a credit card that is owed money,
+// an income account that has earned).
+func NormalBalance(t string) string {
+	if t == AccountAsset || t == AccountExpense {
+		return "debit"
+	}
+	return "credit"
+}
+
+// NormalSign turns a debit-positive amount on an account of type t into
+// its normal-balance sign: positive when the account carries its usual
+// balance.
+func NormalSign(t string, amount int64) int64 {
+	if NormalBalance(t) == "credit" {
+		return -amount
+	}
+	return amount
+}
+
+// externalCounterpart picks the account that takes the other side of
+// income (net > 0 on the user's accounts) or spending (net < 0): the user's
+// default income or expense account if they keep one, else the catch-all
+// external account, created on first use.
+func externalCounterpart(ctx context.Context, qtx *sqlc.Queries, userID int64, currency string, net int64) (int64, error) {
+	t := AccountExpense
+	if net > 0 {
+		t = AccountIncome
+	}
+	cp, err := qtx.GetCounterpartAccount(ctx, sqlc.GetCounterpartAccountParams{UserID: userID, Type: t})
+	if err == nil {
+		return cp.ID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+
+	ext, err := qtx.GetExternalAccount(ctx, userID)
+	if err == nil {
+		return ext.ID, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return 0, err
+	}
+	// Users created before the double-entry migration get one lazily.
+	created, err := qtx.CreateExternalAccount(ctx, sqlc.CreateExternalAccountParams{
+		UserID: userID, Currency: currency,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
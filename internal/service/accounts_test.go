@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccountTypes(t *testing.T) {
+	cases := []struct {
+		typ           string
+		normal        string
+		counterpartOK bool
+	}{
+		{AccountAsset, "debit", false},
+		{AccountLiability, "credit", false},
+		{AccountEquity, "credit", false},
+		{AccountIncome, "credit", true},
+		{AccountExpense, "debit", true},
+	}
+	for _, c := range cases {
+		t.Run(c.typ, func(t *testing.T) {
+			if got := NormalBalance(c.typ); got != c.normal {
+				t.Fatalf("NormalBalance = %s, want %s", got, c.normal)
+			}
+			if err := ValidateAccountType(c.typ, false); err != nil {
+				t.Fatalf("ValidateAccountType: %v", err)
+			}
+			if err := ValidateAccountType(c.typ, true); (err == nil) != c.counterpartOK {
+				t.Fatalf("ValidateAccountType as counterpart: %v", err)
+			}
+		})
+	}
+	if err := ValidateAccountType("revenue", false); !errors.Is(err, ErrAccountType) {
+		t.Fatalf("unknown type: %v", err)
+	}
+	// A card owing 50.00 carries a credit balance of -5000.
+	if got := NormalSign(AccountLiability, -5000); got != 5000 {
+		t.Fatalf("NormalSign(liability, -5000) = %d", got)
+	}
+	if got := NormalSign(AccountAsset, -5000); got != -5000 {
+		t.Fatalf("NormalSign(asset, -5000) = %d", got)
+	}
+}
+
+func TestBuildAccountTree(t *testing.T) {
+	// Sorted by name, as ListAllAccountsByUser returns them.
+	roots := buildAccountTree([]AccountDTO{
+		{ID: 2, Name: "Assets"},
+		{ID: 3, Name: "Bank", ParentID: 2},
+		{ID: 5, Name: "Cash", ParentID: 2},
+		{ID: 4, Name: "Checking", ParentID: 3},
+		{ID: 1, Name: "External"},
+		{ID: 6, Name: "Savings", ParentID: 3},
+	})
+	if len(roots) != 2 || roots[0].Name != "Assets" || roots[1].Name != "External" {
+		t.Fatalf("roots: %+v", roots)
+	}
+	assets := roots[0]
+	if len(assets.Children) != 2 || assets.Children[0].Name != "Bank" || assets.Children[1].Path != "Assets:Cash" {
+		t.Fatalf("Assets children: %+v", assets.Children)
+	}
+	bank := assets.Children[0]
+	if len(bank.Children) != 2 || bank.Children[0].Path != "Assets:Bank:Checking" || bank.Children[1].Path != "Assets:Bank:Savings" {
+		t.Fatalf("Bank children: %+v", bank.Children)
+	}
+	if roots[1].Children == nil {
+		t.Fatal("leaf children must be an empty list, not null")
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+// ErrAmendRefunded refuses to amend a transaction that has been partly
+// refunded: re-booking the corrected original would undo the refunds.
+var ErrAmendRefunded = errors.New("a partly refunded transaction cannot be amended; reverse what remains and book it again")
+
+// AmendInput describes the replacement for a transaction. Anything left
+// unset is copied from the original, so correcting just the amount or just
+// the date is a one-field request.
+type AmendInput struct {
+	UserID        int64
+	TransactionID int64
+	// Legs replaces the postings outright (journal rules: two or more legs
+	// summing to zero per currency). Mutually exclusive with AmountMinor.
+	Legs []Leg
+	// AmountMinor re-books a two-posting transaction (income, expense or a
+	// same-currency transfer) at this positive amount, keeping each
+	// posting's direction.
+	AmountMinor    int64
+	OccurredAt     time.Time // zero = the original's, or now if its month is closed
+	Note           *string   // nil = the original's
+	Payee          *string   // nil = the original's
+	CategoryID     *int64    // nil = the original's; 0 clears
+	IdempotencyKey string
+}
+
+// AmendResult links the three transactions of an amendment.
+type AmendResult struct {
+	OriginalID  int64          `json:"original_id"`
+	Reversal    TransactionDTO `json:"reversal"`
+	Replacement TransactionDTO `json:"replacement"`
+}
+
+// Amend corrects a transaction atomically: in one DB transaction it reverses
+// the original and books the replacement, which points back at the original
+// via amends. A transaction with refunds is refused with ErrAmendRefunded.
+// A single TransactionAmended event describes the whole correction.
+func (s *TransactionService) Amend(ctx context.Context, in AmendInput) (AmendResult, error) {
+	if len(in.Legs) > 0 && in.AmountMinor != 0 {
+		return AmendResult{}, fmt.Errorf("%w: give either legs or amount_minor, not both", ErrUnbalanced)
+	}
+	if in.AmountMinor < 0 {
+		return AmendResult{}, fmt.Errorf("%w: amount_minor must be positive", ErrZeroAmount)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return AmendResult{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	hash := amendHash(in)
+	var cached AmendResult
+	replay, err := claimIdempotencyInto(ctx, qtx, in.UserID, in.IdempotencyKey, hash, &cached)
+	if err != nil {
+		return AmendResult{}, err
+	}
+	if replay {
+		return cached, nil
+	}
+
+	now := time.Now().UTC()
+	reversal, orig, err := postReversal(ctx, qtx, in.UserID, in.TransactionID, 0, nil, "", now)
+	if err != nil {
+		return AmendResult{}, err
+	}
+	origPostings, err := qtx.ListPostingsByTransaction(ctx, orig.ID)
+	if err != nil {
+		return AmendResult{}, err
+	}
+	// The reversal negates what is left; anything short of the whole
+	// original was refunded earlier.
+	var left, whole int64
+	for _, p := range reversal.Postings {
+		if p.AmountMinor > 0 {
+			left += p.AmountMinor
+		}
+	}
+	for _, p := range origPostings {
+		if p.AmountMinor < 0 {
+			whole -= p.AmountMinor
+		}
+	}
+	if left != whole {
+		return AmendResult{}, ErrAmendRefunded
+	}
+
+	legs := in.Legs
+	if len(legs) == 0 {
+		legs = make([]Leg, 0, len(origPostings))
+		for _, p := range origPostings {
+			legs = append(legs, Leg{AccountID: p.AccountID, AmountMinor: p.AmountMinor, Currency: p.Currency, CategoryID: p.CategoryID.Int64})
+		}
+		if in.AmountMinor != 0 {
+			if legs, err = rescaleLegs(legs, in.AmountMinor); err != nil {
+				return AmendResult{}, err
+			}
+		}
+	}
+	if err := validateLegs(withCurrency(legs, orig.Currency), false); err != nil {
+		return AmendResult{}, err
+	}
+
+	bin := balancedInput{
+		UserID:     in.UserID,
+		Legs:       legs,
+		Currency:   orig.Currency,
+		OccurredAt: orig.OccurredAt,
+		Note:       orig.Note.String,
+		Payee:      orig.Payee.String,
+		CategoryID: orig.CategoryID.Int64,
+		Amends:     orig.ID,
+	}
+	if len(in.Legs) == 0 {
+		// Same postings, same conversion.
+		bin.FXRate = rateText(orig.FxRate)
+	}
+	if !in.OccurredAt.IsZero() {
+		bin.OccurredAt = in.OccurredAt
+	} else {
+		// A correction to a closed month lands in the current one.
+		closed, err := qtx.IsPeriodClosed(ctx, sqlc.IsPeriodClosedParams{Month: monthOf(orig.OccurredAt), UserID: in.UserID})
+		if err != nil {
+			return AmendResult{}, err
+		}
+		if closed {
+			bin.OccurredAt = now
+		}
+	}
+	if in.Note != nil {
+		bin.Note = *in.Note
+	}
+	if in.Payee != nil {
+		bin.Payee = *in.Payee
+	}
+	if in.CategoryID != nil {
+		bin.CategoryID = *in.CategoryID
+	}
+	replacement, newLegs, err := s.postBalanced(ctx, qtx, bin)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return AmendResult{}, ErrAlreadyReversed
+		}
+		return AmendResult{}, err
+	}
+
+	res := AmendResult{OriginalID: orig.ID, Reversal: reversal, Replacement: replacement}
+	if err := emitTransactionEvent(ctx, qtx, "TransactionAmended", replacement, map[string]any{
+		"reversal_id": reversal.ID,
+	}); err != nil {
+		return AmendResult{}, err
+	}
+	if err := markIdempotency(ctx, qtx, in.UserID, in.IdempotencyKey, hash, res); err != nil {
+		return AmendResult{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return AmendResult{}, err
+	}
+
+	newLegs = append(newLegs, postingLegs(reversal.Postings)...)
+	s.invalidate(ctx, newLegs, orig.OccurredAt, now, bin.OccurredAt)
+	return res, nil
+}
+
+// rescaleLegs sets both legs of a two-posting, single-currency transaction
+// to amount, keeping their signs.
+func rescaleLegs(legs []Leg, amount int64) ([]Leg, error) {
+	if len(legs) != 2 || legs[0].Currency != legs[1].Currency {
+		return nil, fmt.Errorf("%w: amount_minor only applies to two-posting transactions; pass legs", ErrUnbalanced)
+	}
+	out := make([]Leg, 2)
+	for i, l := range legs {
+		if l.AmountMinor < 0 {
+			l.AmountMinor = -amount
+		} else {
+			l.AmountMinor = amount
+		}
+		out[i] = l
+	}
+	return out, nil
+}
+
+func amendHash(in AmendInput) string {
+	payload := struct {
+		Op string `json:"op"`
+		AmendInput
+	}{"amend", in}
+	payload.IdempotencyKey = ""
+	b, _ := json.Marshal(payload)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRescaleLegsKeepsDirection(t *testing.T) {
+	legs := []Leg{
+		{AccountID: 1, AmountMinor: -4500, Currency: "USD", CategoryID: 7},
+		{AccountID: 2, AmountMinor: 4500, Currency: "USD"},
+	}
+	got, err := rescaleLegs(legs, 5400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Leg{
+		{AccountID: 1, AmountMinor: -5400, Currency: "USD", CategoryID: 7},
+		{AccountID: 2, AmountMinor: 5400, Currency: "USD"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if legs[0].AmountMinor != -4500 {
+		t.Fatal("input legs must not be modified")
+	}
+}
+
+func TestRescaleLegsNeedsTwoSameCurrencyPostings(t *testing.T) {
+	for _, legs := range [][]Leg{
+		{{AccountID: 1, AmountMinor: -100, Currency: "USD"}, {AccountID: 2, AmountMinor: 60, Currency: "USD"}, {AccountID: 3, AmountMinor: 40, Currency: "USD"}},
+		{{AccountID: 1, AmountMinor: -100, Currency: "USD"}, {AccountID: 2, AmountMinor: 92, Currency: "EUR"}},
+	} {
+		if _, err := rescaleLegs(legs, 50); !errors.Is(err, ErrUnbalanced) {
+			t.Errorf("%+v: want ErrUnbalanced, got %v", legs, err)
+		}
+	}
+}
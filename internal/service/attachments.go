@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+	"github.com/EftikharAzim/ledgerx/internal/storage"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrAttachmentType     = errors.New("attachments must be PDF, JPEG or PNG")
+	ErrAttachmentEmpty    = errors.New("attachment is empty")
+)
+
+// attachmentTypes are the content types accepted on upload, as reported by
+// http.DetectContentType.
+var attachmentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+// AttachmentService stores receipts and other documents for transactions.
+// Bytes go to the same storage backend as exports; the attachments table
+// records where, and what was uploaded.
+type AttachmentService struct {
+	q     *sqlc.Queries
+	store storage.Storage
+}
+
+func NewAttachmentService(q *sqlc.Queries, store storage.Storage) *AttachmentService {
+	return &AttachmentService{q: q, store: store}
+}
+
+type AttachmentDTO struct {
+	ID            int64     `json:"id"`
+	TransactionID int64     `json:"transaction_id"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	SizeBytes     int64     `json:"size_bytes"`
+	SHA256        string    `json:"sha256"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func toAttachmentDTO(a sqlc.Attachment) AttachmentDTO {
+	return AttachmentDTO{
+		ID:            a.ID,
+		TransactionID: a.TransactionID,
+		Filename:      a.Filename,
+		ContentType:   a.ContentType,
+		SizeBytes:     a.SizeBytes,
+		SHA256:        a.Sha256,
+		CreatedAt:     a.CreatedAt,
+	}
+}
+
+type UploadInput struct {
+	UserID        int64
+	TransactionID int64
+	Filename      string
+	Body          []byte
+}
+
+// attachmentType sniffs the content type from the bytes themselves; the
+// client's Content-Type header is not trusted.
+func attachmentType(body []byte) (string, error) {
+	if len(body) == 0 {
+		return "", ErrAttachmentEmpty
+	}
+	ct := http.DetectContentType(body)
+	if !attachmentTypes[ct] {
+		return "", ErrAttachmentType
+	}
+	return ct, nil
+}
+
+// Upload stores the file and records it. The object is written first, so a
+// row never points at missing bytes; if the insert fails the object is
+// removed again.
+func (s *AttachmentService) Upload(ctx context.Context, in UploadInput) (AttachmentDTO, error) {
+	if err := s.ownedTransaction(ctx, in.UserID, in.TransactionID); err != nil {
+		return AttachmentDTO{}, err
+	}
+	ct, err := attachmentType(in.Body)
+	if err != nil {
+		return AttachmentDTO{}, err
+	}
+	sum := sha256.Sum256(in.Body)
+	var nonce [8]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return AttachmentDTO{}, err
+	}
+	key := fmt.Sprintf("attachments/%d/%d/%s", in.UserID, in.TransactionID, hex.EncodeToString(nonce[:]))
+	if err := s.store.Save(ctx, key, bytes.NewReader(in.Body)); err != nil {
+		return AttachmentDTO{}, err
+	}
+
+	a, err := s.q.CreateAttachment(ctx, sqlc.CreateAttachmentParams{
+		TransactionID: in.TransactionID,
+		UserID:        in.UserID,
+		Filename:      cleanFilename(in.Filename),
+		ContentType:   ct,
+		SizeBytes:     int64(len(in.Body)),
+		Sha256:        hex.EncodeToString(sum[:]),
+		StorageKey:    key,
+	})
+	if err != nil {
+		_ = s.store.Delete(ctx, key)
+		return AttachmentDTO{}, err
+	}
+	return toAttachmentDTO(a), nil
+}
+
+func (s *AttachmentService) List(ctx context.Context, userID, txID int64) ([]AttachmentDTO, error) {
+	if err := s.ownedTransaction(ctx, userID, txID); err != nil {
+		return nil, err
+	}
+	rows, err := s.q.ListAttachmentsByTransaction(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AttachmentDTO, 0, len(rows))
+	for _, a := range rows {
+		out = append(out, toAttachmentDTO(a))
+	}
+	return out, nil
+}
+
+// Open returns the attachment and its content; the caller must close it.
+func (s *AttachmentService) Open(ctx context.Context, userID, txID, id int64) (AttachmentDTO, io.ReadCloser, error) {
+	a, err := s.owned(ctx, userID, txID, id)
+	if err != nil {
+		return AttachmentDTO{}, nil, err
+	}
+	body, err := s.store.Open(ctx, a.StorageKey)
+	if err != nil {
+		return AttachmentDTO{}, nil, err
+	}
+	return toAttachmentDTO(a), body, nil
+}
+
+// Delete removes the row, then the object. A failed object delete leaves an
+// unreferenced object behind rather than a row pointing at nothing.
+func (s *AttachmentService) Delete(ctx context.Context, userID, txID, id int64) error {
+	a, err := s.owned(ctx, userID, txID, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.q.DeleteAttachment(ctx, sqlc.DeleteAttachmentParams{ID: a.ID, UserID: userID}); err != nil {
+		return err
+	}
+	_ = s.store.Delete(ctx, a.StorageKey)
+	return nil
+}
+
+func (s *AttachmentService) owned(ctx context.Context, userID, txID, id int64) (sqlc.Attachment, error) {
+	a, err := s.q.GetAttachmentForUser(ctx, sqlc.GetAttachmentForUserParams{
+		ID: id, TransactionID: txID, UserID: userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.Attachment{}, ErrAttachmentNotFound
+	}
+	return a, err
+}
+
+func (s *AttachmentService) ownedTransaction(ctx context.Context, userID, txID int64) error {
+	_, err := s.q.GetTransactionForUser(ctx, sqlc.GetTransactionForUserParams{ID: txID, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTxNotFound
+	}
+	return err
+}
+
+// cleanFilename keeps only the base name of what the client sent, for
+// display and Content-Disposition.
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAttachmentType(t *testing.T) {
+	cases := []struct {
+		name string
+		body []byte
+		want string
+		err  error
+	}{
+		{"pdf", []byte("%PDF-1.7\n1 0 obj"), "application/pdf", nil},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png", nil},
+		{"jpeg", []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), "image/jpeg", nil},
+		{"plain text", []byte("total: 12.00"), "", ErrAttachmentType},
+		{"html", []byte("<html><body>receipt</body></html>"), "", ErrAttachmentType},
+		{"empty", nil, "", ErrAttachmentEmpty},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := attachmentType(c.body)
+			if got != c.want || !errors.Is(err, c.err) {
+				t.Fatalf("attachmentType() = %q, %v; want %q, %v", got, err, c.want, c.err)
+			}
+		})
+	}
+}
+
+func TestCleanFilename(t *testing.T) {
+	cases := map[string]string{
+		"receipt.pdf":             "receipt.pdf",
+		"../../etc/passwd":        "passwd",
+		`C:\Users\me\scan 1.jpeg`: "scan 1.jpeg",
+		"":                        "attachment",
+		"/":                       "attachment",
+	}
+	for in, want := range cases {
+		if got := cleanFilename(in); got != want {
+			t.Errorf("cleanFilename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+
 	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
 )
 
@@ -11,15 +15,22 @@ type BalanceService struct{ q *sqlc.Queries }
 
 func NewBalanceService(q *sqlc.Queries) *BalanceService { return &BalanceService{q: q} }
 
+// BalanceResult carries both views of an account: the ledger balance counts
+// posted entries only; the available balance also subtracts pending holds.
+// Balance mirrors Ledger for older clients.
 type BalanceResult struct {
 	AccountID int64  `json:"account_id"`
 	AsOfISO   string `json:"as_of"`
 	Balance   int64  `json:"balance_minor"`
+	Ledger    int64  `json:"ledger_balance_minor"`
+	Available int64  `json:"available_balance_minor"`
+	Held      int64  `json:"held_minor"`
 }
 
 // CurrentBalance = latest snapshot + postings created after the snapshot
 // cutoff. Both sides cut on postings.created_at, so a backdated entry
 // (occurred_at in the past, created today) still lands in the delta.
+// Unexpired pending holds are then subtracted for the available balance.
 func (s *BalanceService) CurrentBalance(ctx context.Context, accountID int64, now time.Time) (BalanceResult, error) {
 	var snapCutoff time.Time
 	var snapBal int64
@@ -40,9 +51,174 @@ func (s *BalanceService) CurrentBalance(ctx context.Context, accountID int64, no
 		return BalanceResult{}, err
 	}
 
+	held, err := s.q.SumPendingHolds(ctx, sqlc.SumPendingHoldsParams{
+		AccountID: accountID,
+		Now:       now,
+	})
+	if err != nil {
+		return BalanceResult{}, err
+	}
+
+	ledger := snapBal + delta
 	return BalanceResult{
 		AccountID: accountID,
 		AsOfISO:   now.UTC().Format(time.RFC3339),
-		Balance:   snapBal + delta,
+		Balance:   ledger,
+		Ledger:    ledger,
+		Available: ledger - held,
+		Held:      held,
 	}, nil
 }
+
+// Balance bases: when an entry counts toward a point-in-time balance.
+const (
+	BasisOccurred = "occurred" // by occurred_at, the accounting date
+	BasisRecorded = "recorded" // by postings.created_at, when the system learned of it
+)
+
+var ErrBalanceBasis = errors.New("basis must be occurred or recorded")
+
+// PointInTimeBalance is an account's ledger balance at an instant. Holds are
+// not kept historically, so there is no available balance.
+type PointInTimeBalance struct {
+	AccountID int64  `json:"account_id"`
+	AsOfISO   string `json:"as_of"` // entries before this instant
+	Basis     string `json:"basis"`
+	Balance   int64  `json:"balance_minor"`
+	Ledger    int64  `json:"ledger_balance_minor"`
+	// SnapshotDate is the end-of-day snapshot the answer was built on;
+	// empty when there was none and every posting was summed.
+	SnapshotDate string `json:"snapshot_date,omitempty"`
+}
+
+// BalanceAsOf answers "what was the balance before asOf" on either basis.
+// Recorded: what the system would have reported then, i.e. postings created
+// before asOf; backdated entries recorded later are not in it. Occurred: the
+// economic balance, entries dated before asOf whenever they were recorded.
+//
+// Both start from the newest snapshot whose end-of-day cutoff precedes
+// asOf. Snapshots are cut by created_at, so the recorded basis only adds
+// postings recorded since; the occurred basis also moves the postings whose
+// accounting date lies on the other side of asOf than their recording.
+func (s *BalanceService) BalanceAsOf(ctx context.Context, accountID int64, asOf time.Time, basis string) (PointInTimeBalance, error) {
+	if basis != BasisOccurred && basis != BasisRecorded {
+		return PointInTimeBalance{}, fmt.Errorf("%w, not %q", ErrBalanceBasis, basis)
+	}
+	res := PointInTimeBalance{AccountID: accountID, AsOfISO: asOf.UTC().Format(time.RFC3339Nano), Basis: basis}
+
+	// A day's cutoff is its last instant, so the usable snapshots are those
+	// of days ending before asOf.
+	var since time.Time
+	var ledger int64
+	snap, err := s.q.GetSnapshotOnOrBefore(ctx, sqlc.GetSnapshotOnOrBeforeParams{
+		AccountID: accountID,
+		AsOfDate:  dayOf(asOf.AddDate(0, 0, -1)),
+	})
+	switch {
+	case err == nil:
+		d := snap.AsOfDate.UTC()
+		since = time.Date(d.Year(), d.Month(), d.Day(), 23, 59, 59, int(time.Second-time.Nanosecond), time.UTC)
+		ledger = snap.BalanceMinor
+		res.SnapshotDate = d.Format("2006-01-02")
+	case !errors.Is(err, pgx.ErrNoRows):
+		return PointInTimeBalance{}, err
+	}
+
+	var delta int64
+	if basis == BasisRecorded {
+		delta, err = s.q.SumPostingsRecordedBetween(ctx, sqlc.SumPostingsRecordedBetweenParams{
+			AccountID: accountID, Since: since, Before: asOf,
+		})
+	} else {
+		delta, err = s.q.SumPostingsOccurredDelta(ctx, sqlc.SumPostingsOccurredDeltaParams{
+			Since: since, AccountID: accountID, Before: asOf,
+		})
+	}
+	if err != nil {
+		return PointInTimeBalance{}, err
+	}
+	res.Ledger = ledger + delta
+	res.Balance = res.Ledger
+	return res, nil
+}
+
+// SubtreeBalance is the rolled-up balance of an account and all accounts
+// below it in one currency.
+type SubtreeBalance struct {
+	Currency  string `json:"currency"`
+	Accounts  int32  `json:"accounts"` // how many accounts of the subtree hold this currency
+	Ledger    int64  `json:"ledger_balance_minor"`
+	Available int64  `json:"available_balance_minor"`
+	Held      int64  `json:"held_minor"`
+}
+
+// SubtreeBalances rolls CurrentBalance up over accountID's subtree, per
+// currency, in a single query.
+func (s *BalanceService) SubtreeBalances(ctx context.Context, accountID int64, now time.Time) ([]SubtreeBalance, error) {
+	rows, err := s.q.SubtreeBalances(ctx, sqlc.SubtreeBalancesParams{AccountID: accountID, Now: now})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SubtreeBalance, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, SubtreeBalance{
+			Currency:  r.Currency,
+			Accounts:  r.Accounts,
+			Ledger:    r.LedgerMinor,
+			Available: r.LedgerMinor - r.HeldMinor,
+			Held:      r.HeldMinor,
+		})
+	}
+	return out, nil
+}
+
+type NetWorth struct {
+	AsOfISO string           `json:"as_of"`
+	Totals  []CurrencyAmount `json:"totals"` // assets minus liabilities, one entry per currency held
+	// Assets and Liabilities are gross, each in its normal-balance sign.
+	Assets      []CurrencyAmount `json:"assets"`
+	Liabilities []CurrencyAmount `json:"liabilities"`
+}
+
+// NetWorth sums the current balances of the user's asset and liability
+// accounts per currency. Income, expense and equity accounts, and the
+// external and FX counterparts, are not holdings and are left out.
+func (s *BalanceService) NetWorth(ctx context.Context, userID int64, now time.Time) (NetWorth, error) {
+	accounts, err := s.q.ListNormalAccountsByUser(ctx, userID)
+	if err != nil {
+		return NetWorth{}, err
+	}
+	var order []string
+	sums := make(map[string]int64)
+	gross := map[string]map[string]int64{AccountAsset: {}, AccountLiability: {}}
+	for _, a := range accounts {
+		if a.Type != AccountAsset && a.Type != AccountLiability {
+			continue
+		}
+		b, err := s.CurrentBalance(ctx, a.ID, now)
+		if err != nil {
+			return NetWorth{}, err
+		}
+		if _, ok := sums[a.Currency]; !ok {
+			order = append(order, a.Currency)
+		}
+		sums[a.Currency] += b.Balance
+		gross[a.Type][a.Currency] += NormalSign(a.Type, b.Balance)
+	}
+	nw := NetWorth{
+		AsOfISO:     now.UTC().Format(time.RFC3339),
+		Totals:      []CurrencyAmount{},
+		Assets:      []CurrencyAmount{},
+		Liabilities: []CurrencyAmount{},
+	}
+	for _, c := range order {
+		nw.Totals = append(nw.Totals, CurrencyAmount{Currency: c, AmountMinor: sums[c]})
+		if v, ok := gross[AccountAsset][c]; ok {
+			nw.Assets = append(nw.Assets, CurrencyAmount{Currency: c, AmountMinor: v})
+		}
+		if v, ok := gross[AccountLiability][c]; ok {
+			nw.Liabilities = append(nw.Liabilities, CurrencyAmount{Currency: c, AmountMinor: v})
+		}
+	}
+	return nw, nil
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var (
+	ErrBudgetNotFound = errors.New("budget not found")
+	ErrBudgetExists   = errors.New("this category already has a budget in this currency")
+	ErrInvalidBudget  = errors.New("budget needs a positive amount and a currency")
+)
+
+// BudgetService manages monthly category budgets. Spending is never stored:
+// it is summed from postings whenever a month is asked for.
+type BudgetService struct{ q *sqlc.Queries }
+
+func NewBudgetService(q *sqlc.Queries) *BudgetService { return &BudgetService{q: q} }
+
+type BudgetDTO struct {
+	ID          int64     `json:"id"`
+	CategoryID  int64     `json:"category_id"`
+	Currency    string    `json:"currency"`
+	AmountMinor int64     `json:"amount_minor"`
+	Rollover    bool      `json:"rollover"`
+	StartMonth  string    `json:"start_month"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func toBudgetDTO(b sqlc.Budget) BudgetDTO {
+	return BudgetDTO{
+		ID:          b.ID,
+		CategoryID:  b.CategoryID,
+		Currency:    b.Currency,
+		AmountMinor: b.AmountMinor,
+		Rollover:    b.Rollover,
+		StartMonth:  b.StartMonth.Format("2006-01"),
+		CreatedAt:   b.CreatedAt,
+		UpdatedAt:   b.UpdatedAt,
+	}
+}
+
+// BudgetStatus is a budget's position in one month. Budgeted is the amount
+// plus whatever rolled over from earlier months; Remaining goes negative
+// once the month is overspent.
+type BudgetStatus struct {
+	BudgetDTO
+	Month           string `json:"month"`
+	BudgetedMinor   int64  `json:"budgeted_minor"`
+	RolledOverMinor int64  `json:"rolled_over_minor"`
+	SpentMinor      int64  `json:"spent_minor"`
+	RemainingMinor  int64  `json:"remaining_minor"`
+	Exceeded        bool   `json:"exceeded"`
+}
+
+type BudgetInput struct {
+	UserID      int64
+	CategoryID  int64
+	Currency    string
+	AmountMinor int64
+	Rollover    bool
+	StartMonth  time.Time // any day of the first budgeted month
+}
+
+func (s *BudgetService) Create(ctx context.Context, in BudgetInput) (BudgetDTO, error) {
+	if in.AmountMinor <= 0 || in.Currency == "" {
+		return BudgetDTO{}, ErrInvalidBudget
+	}
+	if _, err := ownedCategory(ctx, s.q, in.UserID, in.CategoryID); err != nil {
+		return BudgetDTO{}, err
+	}
+	b, err := s.q.CreateBudget(ctx, sqlc.CreateBudgetParams{
+		UserID:      in.UserID,
+		CategoryID:  in.CategoryID,
+		Currency:    in.Currency,
+		AmountMinor: in.AmountMinor,
+		Rollover:    in.Rollover,
+		StartMonth:  monthOf(in.StartMonth),
+	})
+	if isUniqueViolation(err) {
+		return BudgetDTO{}, ErrBudgetExists
+	}
+	if err != nil {
+		return BudgetDTO{}, err
+	}
+	return toBudgetDTO(b), nil
+}
+
+func (s *BudgetService) Get(ctx context.Context, userID, id int64) (BudgetDTO, error) {
+	b, err := s.q.GetBudgetForUser(ctx, sqlc.GetBudgetForUserParams{ID: id, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return BudgetDTO{}, ErrBudgetNotFound
+	}
+	if err != nil {
+		return BudgetDTO{}, err
+	}
+	return toBudgetDTO(b), nil
+}
+
+// Update changes the monthly amount and the rollover flag. Both apply to
+// every month, so rolled-over amounts are recomputed with the new values.
+func (s *BudgetService) Update(ctx context.Context, userID, id, amountMinor int64, rollover bool) (BudgetDTO, error) {
+	if amountMinor <= 0 {
+		return BudgetDTO{}, ErrInvalidBudget
+	}
+	b, err := s.q.UpdateBudget(ctx, sqlc.UpdateBudgetParams{
+		AmountMinor: amountMinor, Rollover: rollover, ID: id, UserID: userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return BudgetDTO{}, ErrBudgetNotFound
+	}
+	if err != nil {
+		return BudgetDTO{}, err
+	}
+	return toBudgetDTO(b), nil
+}
+
+func (s *BudgetService) Delete(ctx context.Context, userID, id int64) error {
+	n, err := s.q.DeleteBudget(ctx, sqlc.DeleteBudgetParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// Month reports every budget in effect in month.
+func (s *BudgetService) Month(ctx context.Context, userID int64, month time.Time) ([]BudgetStatus, error) {
+	month = monthOf(month)
+	rows, err := s.q.ListBudgetsForMonth(ctx, sqlc.ListBudgetsForMonthParams{UserID: userID, Month: month})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]BudgetStatus, 0, len(rows))
+	for _, b := range rows {
+		st, err := budgetStatus(ctx, s.q, b, month)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// budgetStatus sums the budget's spending from its start month through
+// month and folds it into a BudgetStatus.
+func budgetStatus(ctx context.Context, q *sqlc.Queries, b sqlc.Budget, month time.Time) (BudgetStatus, error) {
+	rows, err := q.BudgetSpendByMonth(ctx, sqlc.BudgetSpendByMonthParams{
+		CategoryID: b.CategoryID,
+		Currency:   b.Currency,
+		FromTime:   b.StartMonth,
+		ToTime:     month.AddDate(0, 1, 0),
+	})
+	if err != nil {
+		return BudgetStatus{}, err
+	}
+	spent := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		spent[r.Month.Format("2006-01")] = r.Spent
+	}
+	st := BudgetStatus{BudgetDTO: toBudgetDTO(b), Month: month.Format("2006-01")}
+	st.BudgetedMinor, st.RolledOverMinor, st.SpentMinor = budgetPeriod(b.AmountMinor, b.Rollover, b.StartMonth, month, spent)
+	st.RemainingMinor = st.BudgetedMinor - st.SpentMinor
+	st.Exceeded = st.RemainingMinor < 0
+	return st, nil
+}
+
+// budgetPeriod walks the months from start to month (spent is keyed
+// "YYYY-MM"). With rollover, each month's unspent amount is carried into the
+// next one; an overspent month carries nothing, so overspending is never
+// charged against later months.
+func budgetPeriod(amount int64, rollover bool, start, month time.Time, spent map[string]int64) (budgeted, rolledOver, spentInMonth int64) {
+	m := monthOf(start)
+	month = monthOf(month)
+	for m.Before(month) {
+		if rollover {
+			left := amount + rolledOver - spent[m.Format("2006-01")]
+			rolledOver = max(left, 0)
+		}
+		m = m.AddDate(0, 1, 0)
+	}
+	return amount + rolledOver, rolledOver, spent[month.Format("2006-01")]
+}
+
+// checkBudgets runs inside a posting transaction, after transaction txID was
+// written (or moved) into categoryIDs — its header and line categories.
+// Every budget covering one of them in the month of occurredAt is locked and
+// recomputed; if this transaction is the one that pushed it over, a
+// BudgetExceeded outbox event is queued. Later postings into an already
+// overspent month stay quiet.
+func checkBudgets(ctx context.Context, qtx *sqlc.Queries, userID, txID int64, categoryIDs []int64, occurredAt time.Time) error {
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+	month := monthOf(occurredAt)
+	budgets, err := qtx.LockBudgetsCoveringCategories(ctx, sqlc.LockBudgetsCoveringCategoriesParams{
+		CategoryIds: categoryIDs, Month: month,
+	})
+	if err != nil || len(budgets) == 0 {
+		return err
+	}
+
+	for _, b := range budgets {
+		added, err := qtx.TransactionSpend(ctx, sqlc.TransactionSpendParams{
+			CategoryID: b.CategoryID, TransactionID: txID, Currency: b.Currency,
+		})
+		if err != nil {
+			return err
+		}
+		if added <= 0 {
+			continue
+		}
+		st, err := budgetStatus(ctx, qtx, b, month)
+		if err != nil {
+			return err
+		}
+		if !st.Exceeded || st.RemainingMinor+added < 0 {
+			continue
+		}
+		event, err := json.Marshal(map[string]any{
+			"budget_id":       b.ID,
+			"user_id":         userID,
+			"category_id":     b.CategoryID,
+			"transaction_id":  txID,
+			"currency":        b.Currency,
+			"month":           st.Month,
+			"budgeted_minor":  st.BudgetedMinor,
+			"spent_minor":     st.SpentMinor,
+			"remaining_minor": st.RemainingMinor,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := qtx.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
+			EventType: "BudgetExceeded", Payload: event,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// monthOf truncates t to the first day of its UTC month.
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetPeriod(t *testing.T) {
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	apr := time.Date(2025, 4, 17, 0, 0, 0, 0, time.UTC)
+	spent := map[string]int64{"2025-01": 6000, "2025-02": 15000, "2025-03": 2000, "2025-04": 4000}
+	cases := []struct {
+		name       string
+		rollover   bool
+		start      time.Time
+		month      time.Time
+		budgeted   int64
+		rolledOver int64
+		spent      int64
+	}{
+		{"first month", true, jan, jan, 10000, 0, 6000},
+		{"no rollover", false, jan, apr, 10000, 0, 4000},
+		// Jan leaves 4000; Feb overspends by 1000 and carries nothing, so the
+		// overspend is not charged to March; March leaves 8000.
+		{"rollover skips overspent months", true, jan, apr, 18000, 8000, 4000},
+		{"start mid-month counts from its month", true, jan.AddDate(0, 0, 20), jan.AddDate(0, 1, 0), 14000, 4000, 15000},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, r, s := budgetPeriod(10000, c.rollover, c.start, c.month, spent)
+			if b != c.budgeted || r != c.rolledOver || s != c.spent {
+				t.Fatalf("budgetPeriod() = %d, %d, %d; want %d, %d, %d", b, r, s, c.budgeted, c.rolledOver, c.spent)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+// Bucket sizes of time-series reports.
+const (
+	IntervalMonth = "month"
+	IntervalWeek  = "week"
+	IntervalDay   = "day" // balance history only
+)
+
+// MaxCashFlowBuckets bounds the buckets of one cash flow report.
+const MaxCashFlowBuckets = 260
+
+type CashFlowInput struct {
+	UserID     int64
+	From, To   time.Time // widened to whole buckets
+	Interval   string    // month (default) or week
+	AccountIDs []int64   // asset or liability accounts; empty = all of them
+}
+
+// CashFlowBucket is one month or week; Change is the difference from the
+// bucket before it.
+type CashFlowBucket struct {
+	Start string `json:"start"` // YYYY-MM-DD, UTC
+	MonthlySummary
+	Change MonthlySummary `json:"change"`
+}
+
+// CashFlowCurrency is one currency's flow per bucket, the range total, and
+// the total of the equally long period just before it.
+type CashFlowCurrency struct {
+	Currency      string           `json:"currency"`
+	Buckets       []CashFlowBucket `json:"buckets"`
+	Total         MonthlySummary   `json:"total"`
+	PreviousTotal MonthlySummary   `json:"previous_total"`
+	Change        MonthlySummary   `json:"change"`
+}
+
+type CashFlow struct {
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"` // exclusive
+	Interval   string             `json:"interval"`
+	AccountIDs []int64            `json:"account_ids,omitempty"`
+	Currencies []CashFlowCurrency `json:"currencies"`
+}
+
+// CashFlow reports money in and out of the user's asset and liability
+// accounts per bucket, leaving out transfers between their own accounts.
+// The range is widened to whole buckets, and every figure is compared with
+// the one before it: each bucket with the previous bucket, the total with
+// the same number of buckets before From.
+func (s *ReportService) CashFlow(ctx context.Context, in CashFlowInput) (CashFlow, error) {
+	if in.Interval == "" {
+		in.Interval = IntervalMonth
+	}
+	if in.Interval != IntervalMonth && in.Interval != IntervalWeek {
+		return CashFlow{}, fmt.Errorf("%w: interval must be month or week", ErrReportRange)
+	}
+	if !in.To.After(in.From) {
+		return CashFlow{}, fmt.Errorf("%w: to must be after from", ErrReportRange)
+	}
+	starts := bucketStarts(in.From, in.To, in.Interval)
+	if len(starts) > MaxCashFlowBuckets {
+		return CashFlow{}, fmt.Errorf("%w: %d buckets exceeds the limit of %d", ErrReportRange, len(starts), MaxCashFlowBuckets)
+	}
+	for _, id := range in.AccountIDs {
+		a, err := ownedAccount(ctx, s.q, in.UserID, id)
+		if err != nil {
+			return CashFlow{}, err
+		}
+		if a.Kind != "normal" || (a.Type != AccountAsset && a.Type != AccountLiability) {
+			return CashFlow{}, fmt.Errorf("%w: cash flow covers asset and liability accounts only", ErrAccountType)
+		}
+	}
+
+	// The previous period is as many buckets again, ending where this one
+	// starts.
+	n := len(starts)
+	prevFrom := stepBucket(starts[0], in.Interval, -n)
+	to := stepBucket(starts[n-1], in.Interval, 1)
+	ids := in.AccountIDs
+	if ids == nil {
+		ids = []int64{}
+	}
+	rows, err := s.q.CashFlow(ctx, sqlc.CashFlowParams{
+		Bucket: in.Interval, UserID: in.UserID, AccountIds: ids, FromTime: prevFrom, ToTime: to,
+	})
+	if err != nil {
+		return CashFlow{}, err
+	}
+	cf := buildCashFlow(bucketStarts(prevFrom, to, in.Interval), n, rows)
+	cf.From, cf.To, cf.Interval, cf.AccountIDs = starts[0], to, in.Interval, in.AccountIDs
+	return cf, nil
+}
+
+// bucketStarts lists the starts of the buckets that [from, to) touches.
+func bucketStarts(from, to time.Time, interval string) []time.Time {
+	var starts []time.Time
+	for b := bucketStart(from, interval); b.Before(to); b = stepBucket(b, interval, 1) {
+		starts = append(starts, b)
+	}
+	return starts
+}
+
+// bucketStart truncates t to its UTC day, ISO week (Monday) or month, as
+// Postgres' date_trunc does.
+func bucketStart(t time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalDay:
+		return dayOf(t)
+	case IntervalWeek:
+		d := dayOf(t)
+		return d.AddDate(0, 0, -(int(d.Weekday())+6)%7)
+	}
+	return monthOf(t)
+}
+
+func stepBucket(b time.Time, interval string, n int) time.Time {
+	switch interval {
+	case IntervalDay:
+		return b.AddDate(0, 0, n)
+	case IntervalWeek:
+		return b.AddDate(0, 0, 7*n)
+	}
+	return b.AddDate(0, n, 0)
+}
+
+// buildCashFlow lays rows out over starts, whose first prev buckets are the
+// previous period. Currencies come in the rows' order.
+func buildCashFlow(starts []time.Time, prev int, rows []sqlc.CashFlowRow) CashFlow {
+	col := make(map[time.Time]int, len(starts))
+	for i, b := range starts {
+		col[b] = i
+	}
+	cf := CashFlow{Currencies: []CashFlowCurrency{}}
+	var byCurrency [][]MonthlySummary
+	idx := make(map[string]int)
+	for _, r := range rows {
+		i, ok := idx[r.Currency]
+		if !ok {
+			i = len(cf.Currencies)
+			idx[r.Currency] = i
+			cf.Currencies = append(cf.Currencies, CashFlowCurrency{Currency: r.Currency})
+			byCurrency = append(byCurrency, make([]MonthlySummary, len(starts)))
+		}
+		if j, ok := col[r.BucketStart.UTC()]; ok {
+			byCurrency[i][j] = MonthlySummary{Inflow: r.Inflow, Outflow: r.Outflow, Net: r.Net}
+		}
+	}
+	for i := range cf.Currencies {
+		c := &cf.Currencies[i]
+		all := byCurrency[i]
+		c.Buckets = make([]CashFlowBucket, 0, len(starts)-prev)
+		for j := prev; j < len(starts); j++ {
+			c.Buckets = append(c.Buckets, CashFlowBucket{
+				Start:          starts[j].Format("2006-01-02"),
+				MonthlySummary: all[j],
+				Change:         all[j].minus(all[j-1]),
+			})
+			c.Total = c.Total.plus(all[j])
+			c.PreviousTotal = c.PreviousTotal.plus(all[j-prev])
+		}
+		c.Change = c.Total.minus(c.PreviousTotal)
+	}
+	return cf
+}
+
+func (m MonthlySummary) plus(o MonthlySummary) MonthlySummary {
+	return MonthlySummary{Inflow: m.Inflow + o.Inflow, Outflow: m.Outflow + o.Outflow, Net: m.Net + o.Net}
+}
+
+func (m MonthlySummary) minus(o MonthlySummary) MonthlySummary {
+	return MonthlySummary{Inflow: m.Inflow - o.Inflow, Outflow: m.Outflow - o.Outflow, Net: m.Net - o.Net}
+}
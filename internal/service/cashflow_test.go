@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+func TestBucketStarts(t *testing.T) {
+	// Wednesday 2025-01-15 falls in the ISO week starting Monday the 13th.
+	from := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC)
+	weeks := bucketStarts(from, to, IntervalWeek)
+	if len(weeks) != 2 || weeks[0].Format("2006-01-02") != "2025-01-13" || weeks[1].Format("2006-01-02") != "2025-01-20" {
+		t.Fatalf("weeks: %v", weeks)
+	}
+	if sun := bucketStart(time.Date(2025, 1, 19, 23, 0, 0, 0, time.UTC), IntervalWeek); !sun.Equal(weeks[0]) {
+		t.Fatalf("Sunday belongs to the week before: %v", sun)
+	}
+	months := bucketStarts(from, time.Date(2025, 3, 1, 0, 0, 0, 1, time.UTC), IntervalMonth)
+	if len(months) != 3 || months[2].Month() != time.March {
+		t.Fatalf("months: %v", months)
+	}
+}
+
+func TestBuildCashFlow(t *testing.T) {
+	m := func(mo time.Month) time.Time { return time.Date(2025, mo, 1, 0, 0, 0, 0, time.UTC) }
+	// Two current months (Mar, Apr) and the two before them.
+	starts := []time.Time{m(1), m(2), m(3), m(4)}
+	rows := []sqlc.CashFlowRow{
+		{BucketStart: m(1), Currency: "EUR", Inflow: 100, Net: 100},
+		{BucketStart: m(2), Currency: "USD", Inflow: 5000, Outflow: 1000, Net: 4000},
+		{BucketStart: m(3), Currency: "USD", Inflow: 5000, Outflow: 3000, Net: 2000},
+		{BucketStart: m(4), Currency: "USD", Inflow: 6000, Outflow: 2500, Net: 3500},
+	}
+	cf := buildCashFlow(starts, 2, rows)
+	if len(cf.Currencies) != 2 {
+		t.Fatalf("currencies: %+v", cf.Currencies)
+	}
+	eur, usd := cf.Currencies[0], cf.Currencies[1]
+	if len(usd.Buckets) != 2 || usd.Buckets[0].Start != "2025-03-01" {
+		t.Fatalf("USD buckets: %+v", usd.Buckets)
+	}
+	if got := usd.Buckets[0].Change; got != (MonthlySummary{Inflow: 0, Outflow: 2000, Net: -2000}) {
+		t.Fatalf("March change: %+v", got)
+	}
+	if got := usd.Buckets[1].Change; got != (MonthlySummary{Inflow: 1000, Outflow: -500, Net: 1500}) {
+		t.Fatalf("April change: %+v", got)
+	}
+	if usd.Total.Net != 5500 || usd.PreviousTotal.Net != 4000 || usd.Change.Net != 1500 {
+		t.Fatalf("USD totals: %+v", usd)
+	}
+	// Active only in the previous period: zero buckets, negative change.
+	if eur.Total != (MonthlySummary{}) || eur.PreviousTotal.Inflow != 100 || eur.Change.Net != -100 {
+		t.Fatalf("EUR: %+v", eur)
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryExists   = errors.New("a sibling category with this name already exists")
+	ErrCategoryInUse    = errors.New("category is still referenced by transactions or child categories")
+	ErrCategoryCycle    = errors.New("a category cannot be moved under itself or its descendants")
+	ErrCategoryName     = errors.New("category name is required")
+)
+
+// CategoryService manages a user's category tree. Categories are plain
+// labels: assigning one never touches postings.
+type CategoryService struct {
+	pool *pgxpool.Pool
+	q    *sqlc.Queries
+}
+
+func NewCategoryService(pool *pgxpool.Pool, q *sqlc.Queries) *CategoryService {
+	return &CategoryService{pool: pool, q: q}
+}
+
+type CategoryDTO struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  int64     `json:"parent_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toCategoryDTO(c sqlc.Category) CategoryDTO {
+	return CategoryDTO{ID: c.ID, Name: c.Name, ParentID: c.ParentID.Int64, CreatedAt: c.CreatedAt.Time}
+}
+
+// Create adds a category; parentID 0 makes it top-level.
+func (s *CategoryService) Create(ctx context.Context, userID int64, name string, parentID int64) (CategoryDTO, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return CategoryDTO{}, ErrCategoryName
+	}
+	if parentID != 0 {
+		if _, err := ownedCategory(ctx, s.q, userID, parentID); err != nil {
+			return CategoryDTO{}, err
+		}
+	}
+	c, err := s.q.CreateCategory(ctx, sqlc.CreateCategoryParams{
+		UserID: userID, Name: name, ParentID: categoryParam(parentID),
+	})
+	if isUniqueViolation(err) {
+		return CategoryDTO{}, ErrCategoryExists
+	}
+	if err != nil {
+		return CategoryDTO{}, err
+	}
+	return toCategoryDTO(c), nil
+}
+
+func (s *CategoryService) Get(ctx context.Context, userID, id int64) (CategoryDTO, error) {
+	c, err := ownedCategory(ctx, s.q, userID, id)
+	if err != nil {
+		return CategoryDTO{}, err
+	}
+	return toCategoryDTO(c), nil
+}
+
+func (s *CategoryService) List(ctx context.Context, userID int64) ([]CategoryDTO, error) {
+	rows, err := s.q.ListCategoriesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CategoryDTO, 0, len(rows))
+	for _, c := range rows {
+		out = append(out, toCategoryDTO(c))
+	}
+	return out, nil
+}
+
+// Update renames and/or re-parents a category. Moving it under itself or
+// one of its descendants is refused; updates within one user's tree are
+// serialized, so the cycle check holds until the update commits.
+func (s *CategoryService) Update(ctx context.Context, userID, id int64, name string, parentID int64) (CategoryDTO, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return CategoryDTO{}, ErrCategoryName
+	}
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return CategoryDTO{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	if err := qtx.LockCategoryTree(ctx, userID); err != nil {
+		return CategoryDTO{}, err
+	}
+	if _, err := ownedCategory(ctx, qtx, userID, id); err != nil {
+		return CategoryDTO{}, err
+	}
+	if parentID != 0 {
+		if _, err := ownedCategory(ctx, qtx, userID, parentID); err != nil {
+			return CategoryDTO{}, err
+		}
+		cycle, err := qtx.CategoryIsInSubtree(ctx, sqlc.CategoryIsInSubtreeParams{AncestorID: id, CandidateID: parentID})
+		if err != nil {
+			return CategoryDTO{}, err
+		}
+		if cycle {
+			return CategoryDTO{}, ErrCategoryCycle
+		}
+	}
+	c, err := qtx.UpdateCategory(ctx, sqlc.UpdateCategoryParams{
+		Name: name, ParentID: categoryParam(parentID), ID: id, UserID: userID,
+	})
+	if isUniqueViolation(err) {
+		return CategoryDTO{}, ErrCategoryExists
+	}
+	if err != nil {
+		return CategoryDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return CategoryDTO{}, err
+	}
+	return toCategoryDTO(c), nil
+}
+
+// Delete removes an unused category. Categories that still label a
+// transaction, have children, or appear in re-categorization history stay:
+// history must keep pointing at something.
+func (s *CategoryService) Delete(ctx context.Context, userID, id int64) error {
+	if _, err := ownedCategory(ctx, s.q, userID, id); err != nil {
+		return err
+	}
+	inUse, err := s.q.CategoryInUse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrCategoryInUse
+	}
+	if _, err := s.q.DeleteCategory(ctx, sqlc.DeleteCategoryParams{ID: id, UserID: userID}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ownedCategory loads a category of userID; other users' categories are
+// reported as not found.
+func ownedCategory(ctx context.Context, q *sqlc.Queries, userID, id int64) (sqlc.Category, error) {
+	c, err := q.GetCategoryForUser(ctx, sqlc.GetCategoryForUserParams{ID: id, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.Category{}, ErrCategoryNotFound
+	}
+	return c, err
+}
+
+// categoryParam turns an optional id (0 = none) into a nullable column value.
+func categoryParam(id int64) pgtype.Int8 {
+	return pgtype.Int8{Int64: id, Valid: id != 0}
+}
+
+type RecategorizeInput struct {
+	UserID        int64
+	TransactionID int64
+	CategoryID    int64 // 0 clears the category
+}
+
+type CategoryChangeDTO struct {
+	ID            int64     `json:"id"`
+	TransactionID int64     `json:"transaction_id"`
+	OldCategoryID int64     `json:"old_category_id,omitempty"`
+	NewCategoryID int64     `json:"new_category_id,omitempty"`
+	ChangedAt     time.Time `json:"changed_at"`
+}
+
+func toCategoryChangeDTO(c sqlc.TransactionCategoryChange) CategoryChangeDTO {
+	return CategoryChangeDTO{
+		ID:            c.ID,
+		TransactionID: c.TransactionID,
+		OldCategoryID: c.OldCategoryID.Int64,
+		NewCategoryID: c.NewCategoryID.Int64,
+		ChangedAt:     c.ChangedAt,
+	}
+}
+
+// Recategorize moves a transaction to another category. Postings are never
+// touched; the header's category changes and the change is appended to
+// transaction_category_changes, with a TransactionRecategorized outbox event
+// (and BudgetExceeded if the move overspends a budget), in one DB
+// transaction. Setting the category it already has is a no-op. A
+// transaction dated in a closed month keeps its category.
+func (s *TransactionService) Recategorize(ctx context.Context, in RecategorizeInput) (CategoryChangeDTO, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	t, err := qtx.LockTransactionForUser(ctx, sqlc.LockTransactionForUserParams{ID: in.TransactionID, UserID: in.UserID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CategoryChangeDTO{}, ErrTxNotFound
+	}
+	if err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	// The move changes the category and budget figures of the month the
+	// transaction is dated in.
+	if err := checkPeriodOpen(ctx, qtx, in.UserID, t.OccurredAt); err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	if in.CategoryID != 0 {
+		if _, err := ownedCategory(ctx, qtx, in.UserID, in.CategoryID); err != nil {
+			return CategoryChangeDTO{}, err
+		}
+	}
+	if t.CategoryID.Int64 == in.CategoryID {
+		return CategoryChangeDTO{TransactionID: t.ID, OldCategoryID: in.CategoryID, NewCategoryID: in.CategoryID}, nil
+	}
+
+	if err := qtx.SetTransactionCategory(ctx, sqlc.SetTransactionCategoryParams{
+		CategoryID: categoryParam(in.CategoryID), ID: t.ID,
+	}); err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	change, err := qtx.CreateCategoryChange(ctx, sqlc.CreateCategoryChangeParams{
+		TransactionID: t.ID,
+		UserID:        in.UserID,
+		OldCategoryID: t.CategoryID,
+		NewCategoryID: categoryParam(in.CategoryID),
+	})
+	if err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	var moved []int64
+	if in.CategoryID != 0 {
+		moved = []int64{in.CategoryID}
+	}
+	if err := checkBudgets(ctx, qtx, in.UserID, t.ID, moved, t.OccurredAt); err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	dto := toCategoryChangeDTO(change)
+	event, err := json.Marshal(map[string]any{
+		"transaction_id":  t.ID,
+		"user_id":         in.UserID,
+		"old_category_id": dto.OldCategoryID,
+		"new_category_id": dto.NewCategoryID,
+		"changed_at":      dto.ChangedAt,
+	})
+	if err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	if _, err := qtx.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
+		EventType: "TransactionRecategorized", Payload: event,
+	}); err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return CategoryChangeDTO{}, err
+	}
+	return dto, nil
+}
+
+// CategoryHistory lists a transaction's re-categorizations, oldest first.
+func (s *TransactionService) CategoryHistory(ctx context.Context, userID, txID int64) ([]CategoryChangeDTO, error) {
+	if _, err := s.q.GetTransactionForUser(ctx, sqlc.GetTransactionForUserParams{ID: txID, UserID: userID}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTxNotFound
+		}
+		return nil, err
+	}
+	rows, err := s.q.ListCategoryChanges(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CategoryChangeDTO, 0, len(rows))
+	for _, c := range rows {
+		out = append(out, toCategoryChangeDTO(c))
+	}
+	return out, nil
+}
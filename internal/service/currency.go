@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var ErrInvalidRate = errors.New("exchange rate must be a positive decimal")
+
+// minorUnitExceptions lists ISO 4217 currencies whose minor unit is not
+// 1/100 of the major unit. Everything else is assumed to have two decimals.
+var minorUnitExceptions = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// minorUnits returns the number of decimal places in currency's minor unit.
+func minorUnits(currency string) int {
+	if n, ok := minorUnitExceptions[strings.ToUpper(currency)]; ok {
+		return n
+	}
+	return 2
+}
+
+// parseRate parses a decimal exchange rate ("0.9215", "157.3") exactly.
+// Rates are never floats: a binary float cannot represent most of them.
+func parseRate(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() <= 0 || strings.ContainsAny(s, "/eE") {
+		return nil, ErrInvalidRate
+	}
+	return r, nil
+}
+
+// formatRate renders a rate without trailing zeros, e.g. 0.9200000000 -> 0.92.
+func formatRate(r *big.Rat) string {
+	s := r.FloatString(10)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// rateText normalizes a rate read back from a NUMERIC column; NULL is "".
+func rateText(t pgtype.Text) string {
+	if !t.Valid {
+		return ""
+	}
+	r, err := parseRate(t.String)
+	if err != nil {
+		return t.String
+	}
+	return formatRate(r)
+}
+
+// convertMinor converts amount (minor units of from) into minor units of to
+// at rate, where rate is units of to per one major unit of from. The result
+// is rounded half away from zero.
+func convertMinor(amount int64, from, to string, rate *big.Rat) (int64, error) {
+	v := new(big.Rat).Mul(big.NewRat(amount, 1), rate)
+	shift := minorUnits(to) - minorUnits(from)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil)
+	if shift > 0 {
+		v.Mul(v, new(big.Rat).SetInt(scale))
+	} else if shift < 0 {
+		v.Quo(v, new(big.Rat).SetInt(scale))
+	}
+
+	num, den := new(big.Int).Set(v.Num()), v.Denom()
+	neg := num.Sign() < 0
+	num.Abs(num)
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if neg {
+		q.Neg(q)
+	}
+	if !q.IsInt64() {
+		return 0, fmt.Errorf("%w: converted amount overflows", ErrInvalidRate)
+	}
+	return q.Int64(), nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
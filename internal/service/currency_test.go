@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConvertMinor(t *testing.T) {
+	cases := []struct {
+		name     string
+		amount   int64
+		from, to string
+		rate     string
+		want     int64
+	}{
+		{"usd to eur", 1000, "USD", "EUR", "0.92", 920},
+		{"rounds half away from zero", 1, "USD", "EUR", "0.5", 1},
+		{"negative rounds symmetrically", -1, "USD", "EUR", "0.5", -1},
+		{"rounds down below half", 1000, "USD", "EUR", "0.92049", 920},
+		{"to zero-decimal currency", 1000, "USD", "JPY", "157.3", 1573},
+		{"from zero-decimal currency", 1573, "JPY", "USD", "0.0063573", 1000},
+		{"to three-decimal currency", 1000, "USD", "KWD", "0.3071", 3071},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rate, err := parseRate(c.rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := convertMinor(c.amount, c.from, c.to, rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != c.want {
+				t.Fatalf("convertMinor(%d %s->%s @ %s) = %d, want %d", c.amount, c.from, c.to, c.rate, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseRateRejectsNonPositiveAndFractions(t *testing.T) {
+	for _, s := range []string{"", "0", "-1.2", "abc", "1/3", "1e3"} {
+		if _, err := parseRate(s); !errors.Is(err, ErrInvalidRate) {
+			t.Fatalf("parseRate(%q): want ErrInvalidRate, got %v", s, err)
+		}
+	}
+}
+
+func TestFormatRateTrimsZeros(t *testing.T) {
+	for in, want := range map[string]string{"0.9200000000": "0.92", "157": "157", "1.0823": "1.0823"} {
+		r, err := parseRate(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := formatRate(r); got != want {
+			t.Fatalf("formatRate(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+// TransactionDetail is everything known about one transaction: the header
+// with its postings, what it links to, and the outbox events it produced.
+// Each posting's reversed_minor is how much of it reversals and refunds have
+// negated so far.
+type TransactionDetail struct {
+	TransactionDTO
+	Reversals   []int64         `json:"reversals"`
+	AmendedBy   int64           `json:"amended_by,omitempty"`
+	Category    *CategoryDTO    `json:"category,omitempty"`
+	Attachments []AttachmentDTO `json:"attachments"`
+	Events      []EventDTO      `json:"events"`
+}
+
+// EventDTO is an outbox row as queued; ProcessedAt is nil until published.
+type EventDTO struct {
+	ID          int64           `json:"id"`
+	EventType   string          `json:"event_type"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ProcessedAt *time.Time      `json:"processed_at,omitempty"`
+}
+
+// Detail loads one of the user's transactions for inspection.
+func (s *TransactionService) Detail(ctx context.Context, userID, txID int64) (TransactionDetail, error) {
+	t, err := s.q.GetTransactionForUser(ctx, sqlc.GetTransactionForUserParams{ID: txID, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TransactionDetail{}, ErrTxNotFound
+	}
+	if err != nil {
+		return TransactionDetail{}, err
+	}
+	d := TransactionDetail{
+		TransactionDTO: TransactionDTO{
+			ID:         t.ID,
+			UserID:     t.UserID,
+			Currency:   t.Currency,
+			OccurredAt: t.OccurredAt,
+			Note:       t.Note.String,
+			Payee:      t.Payee.String,
+			ReversalOf: t.ReversalOf.Int64,
+			Amends:     t.Amends.Int64,
+			FXRate:     rateText(t.FxRate),
+			CategoryID: t.CategoryID.Int64,
+			Postings:   []PostingDTO{},
+			CreatedAt:  t.CreatedAt,
+		},
+		Attachments: []AttachmentDTO{},
+		Events:      []EventDTO{},
+	}
+
+	postings, err := s.q.ListReversiblePostings(ctx, t.ID)
+	if err != nil {
+		return TransactionDetail{}, err
+	}
+	for _, p := range postings {
+		d.Postings = append(d.Postings, PostingDTO{
+			ID: p.ID, AccountID: p.AccountID, AmountMinor: p.AmountMinor, Currency: p.Currency,
+			CategoryID: p.CategoryID.Int64, ReversedMinor: p.ReversedMinor,
+		})
+	}
+
+	if d.Reversals, err = s.q.ListReversalsOf(ctx, t.ID); err != nil {
+		return TransactionDetail{}, err
+	}
+	if d.Reversals == nil {
+		d.Reversals = []int64{}
+	}
+	amendment, err := s.q.GetAmendmentOf(ctx, t.ID)
+	switch {
+	case err == nil:
+		d.AmendedBy = amendment
+	case !errors.Is(err, pgx.ErrNoRows):
+		return TransactionDetail{}, err
+	}
+
+	if t.CategoryID.Valid {
+		c, err := ownedCategory(ctx, s.q, userID, t.CategoryID.Int64)
+		if err != nil {
+			return TransactionDetail{}, err
+		}
+		dto := toCategoryDTO(c)
+		d.Category = &dto
+	}
+
+	atts, err := s.q.ListAttachmentsByTransaction(ctx, t.ID)
+	if err != nil {
+		return TransactionDetail{}, err
+	}
+	for _, a := range atts {
+		d.Attachments = append(d.Attachments, toAttachmentDTO(a))
+	}
+
+	events, err := s.q.ListOutboxEventsForTransaction(ctx, t.ID)
+	if err != nil {
+		return TransactionDetail{}, err
+	}
+	for _, e := range events {
+		ev := EventDTO{ID: e.ID, EventType: e.EventType, Payload: e.Payload, CreatedAt: e.CreatedAt}
+		if e.ProcessedAt.Valid {
+			ev.ProcessedAt = &e.ProcessedAt.Time
+		}
+		d.Events = append(d.Events, ev)
+	}
+	return d, nil
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var ErrRateNotFound = errors.New("no exchange rate available for currency pair")
+
+// pivotCurrency is the base ECB publishes every rate against; pairs with no
+// direct or inverse rate are triangulated through it.
+const pivotCurrency = "EUR"
+
+type FXService struct {
+	pool *pgxpool.Pool
+	q    *sqlc.Queries
+}
+
+func NewFXService(pool *pgxpool.Pool, q *sqlc.Queries) *FXService {
+	return &FXService{pool: pool, q: q}
+}
+
+// RateRow is one imported rate: Rate units of Quote per one unit of Base.
+type RateRow struct {
+	Date  time.Time
+	Base  string
+	Quote string
+	Rate  string
+}
+
+// AppliedRate reports which rate a conversion used, so callers can show it
+// next to the converted figure.
+type AppliedRate struct {
+	Base     string `json:"base"`
+	Quote    string `json:"quote"`
+	Rate     string `json:"rate"`
+	RateDate string `json:"rate_date"`     // YYYY-MM-DD the rate was published for
+	Via      string `json:"via,omitempty"` // "inverse" or the pivot currency when not direct
+	Source   string `json:"source,omitempty"`
+}
+
+// Convert applies the rate to an amount in minor units of Base.
+func (r AppliedRate) Convert(amount int64) (int64, error) {
+	rate, err := parseRate(r.Rate)
+	if err != nil {
+		return 0, err
+	}
+	return convertMinor(amount, r.Base, r.Quote, rate)
+}
+
+// Import upserts rows in one transaction, so a bad row leaves the stored
+// rates as they were; re-importing a file is harmless.
+func (s *FXService) Import(ctx context.Context, rows []RateRow, source string) (int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	for i, r := range rows {
+		rate, err := parseRate(r.Rate)
+		if err != nil {
+			return 0, fmt.Errorf("row %d (%s/%s): %w", i+1, r.Base, r.Quote, err)
+		}
+		if err := qtx.UpsertFXRate(ctx, sqlc.UpsertFXRateParams{
+			Base:     strings.ToUpper(r.Base),
+			Quote:    strings.ToUpper(r.Quote),
+			RateDate: r.Date,
+			Rate:     formatRate(rate),
+			Source:   source,
+		}); err != nil {
+			return 0, fmt.Errorf("row %d (%s/%s): %w", i+1, r.Base, r.Quote, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return len(rows), nil
+}
+
+// RateOn returns the base->quote rate effective on day: a direct rate, else
+// the inverse of quote->base, else a cross rate through the pivot currency.
+func (s *FXService) RateOn(ctx context.Context, base, quote string, day time.Time) (AppliedRate, error) {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if base == quote {
+		return AppliedRate{Base: base, Quote: quote, Rate: "1", RateDate: day.Format("2006-01-02")}, nil
+	}
+	if r, err := s.lookup(ctx, base, quote, day); !errors.Is(err, ErrRateNotFound) {
+		return r, err
+	}
+	if base == pivotCurrency || quote == pivotCurrency {
+		return AppliedRate{}, ErrRateNotFound
+	}
+	leg1, err := s.lookup(ctx, base, pivotCurrency, day)
+	if err != nil {
+		return AppliedRate{}, err
+	}
+	leg2, err := s.lookup(ctx, pivotCurrency, quote, day)
+	if err != nil {
+		return AppliedRate{}, err
+	}
+	r1, _ := parseRate(leg1.Rate)
+	r2, _ := parseRate(leg2.Rate)
+	// Report the older of the two publication dates: the cross rate is only
+	// as fresh as its stalest leg.
+	date := leg1.RateDate
+	if leg2.RateDate < date {
+		date = leg2.RateDate
+	}
+	return AppliedRate{
+		Base: base, Quote: quote,
+		Rate:     formatRate(new(big.Rat).Mul(r1, r2)),
+		RateDate: date,
+		Via:      pivotCurrency,
+		Source:   leg1.Source,
+	}, nil
+}
+
+// lookup tries the direct pair, then the inverse.
+func (s *FXService) lookup(ctx context.Context, base, quote string, day time.Time) (AppliedRate, error) {
+	row, err := s.q.GetFXRateOnOrBefore(ctx, sqlc.GetFXRateOnOrBeforeParams{Base: base, Quote: quote, OnDate: day})
+	if err == nil {
+		r, err := parseRate(row.Rate)
+		if err != nil {
+			return AppliedRate{}, err
+		}
+		return AppliedRate{
+			Base: base, Quote: quote, Rate: formatRate(r),
+			RateDate: row.RateDate.Format("2006-01-02"), Source: row.Source,
+		}, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return AppliedRate{}, err
+	}
+	row, err = s.q.GetFXRateOnOrBefore(ctx, sqlc.GetFXRateOnOrBeforeParams{Base: quote, Quote: base, OnDate: day})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return AppliedRate{}, ErrRateNotFound
+	}
+	if err != nil {
+		return AppliedRate{}, err
+	}
+	r, err := parseRate(row.Rate)
+	if err != nil {
+		return AppliedRate{}, err
+	}
+	return AppliedRate{
+		Base: base, Quote: quote, Rate: formatRate(new(big.Rat).Inv(r)),
+		RateDate: row.RateDate.Format("2006-01-02"), Via: "inverse", Source: row.Source,
+	}, nil
+}
+
+// ParseRatesCSV reads "date,base,quote,rate" lines (YYYY-MM-DD dates). A
+// header row is skipped if present.
+func ParseRatesCSV(r io.Reader) ([]RateRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 4
+	cr.TrimLeadingSpace = true
+	var out []RateRow
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(rec[0], "date") {
+			continue
+		}
+		d, err := time.Parse("2006-01-02", rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: date must be YYYY-MM-DD", line)
+		}
+		if _, err := parseRate(rec[3]); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		out = append(out, RateRow{Date: d, Base: strings.ToUpper(rec[1]), Quote: strings.ToUpper(rec[2]), Rate: rec[3]})
+	}
+	return out, nil
+}
+
+// ecbEnvelope matches the ECB eurofxref daily/historical XML layout:
+// Cube > Cube[time] > Cube[currency, rate], every rate quoted against EUR.
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECBXML reads an ECB-style reference rate file.
+func ParseECBXML(r io.Reader) ([]RateRow, error) {
+	var env ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return nil, err
+	}
+	var out []RateRow
+	for _, day := range env.Days {
+		d, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("cube time %q: date must be YYYY-MM-DD", day.Time)
+		}
+		for _, c := range day.Rates {
+			if _, err := parseRate(c.Rate); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", day.Time, c.Currency, err)
+			}
+			out = append(out, RateRow{Date: d, Base: pivotCurrency, Quote: strings.ToUpper(c.Currency), Rate: c.Rate})
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no rates found")
+	}
+	return out, nil
+}
+
+// CurrencyAmount is an amount in minor units of Currency.
+type CurrencyAmount struct {
+	Currency    string `json:"currency"`
+	AmountMinor int64  `json:"amount_minor"`
+}
+
+// ConvertedTotal is a sum of amounts in several currencies expressed in one,
+// with every rate that went into it.
+type ConvertedTotal struct {
+	Currency    string        `json:"currency"`
+	AmountMinor int64         `json:"amount_minor"`
+	Rates       []AppliedRate `json:"rates"`
+}
+
+// ConvertTotal converts each amount to `to` at the rate effective on day and
+// sums the results. Amounts already in `to` are added as-is.
+func (s *FXService) ConvertTotal(ctx context.Context, amounts []CurrencyAmount, to string, day time.Time) (ConvertedTotal, error) {
+	out := ConvertedTotal{Currency: strings.ToUpper(to), Rates: []AppliedRate{}}
+	for _, a := range amounts {
+		if strings.EqualFold(a.Currency, to) {
+			out.AmountMinor += a.AmountMinor
+			continue
+		}
+		rate, err := s.RateOn(ctx, a.Currency, to, day)
+		if err != nil {
+			return ConvertedTotal{}, fmt.Errorf("%s->%s: %w", a.Currency, to, err)
+		}
+		v, err := rate.Convert(a.AmountMinor)
+		if err != nil {
+			return ConvertedTotal{}, err
+		}
+		out.AmountMinor += v
+		out.Rates = append(out.Rates, rate)
+	}
+	return out, nil
+}
+
+// ConvertedSummary is a MonthlySummary expressed in another currency.
+type ConvertedSummary struct {
+	Currency string      `json:"currency"`
+	Inflow   int64       `json:"inflow"`
+	Outflow  int64       `json:"outflow"`
+	Net      int64       `json:"net"`
+	Rate     AppliedRate `json:"rate"`
+}
+
+// ConvertSummary expresses ms (in currency from) in currency to at the rate
+// effective on day. Inflow and outflow are converted independently, so Net
+// is derived from them rather than converted on its own.
+func (s *FXService) ConvertSummary(ctx context.Context, ms MonthlySummary, from, to string, day time.Time) (ConvertedSummary, error) {
+	rate, err := s.RateOn(ctx, from, to, day)
+	if err != nil {
+		return ConvertedSummary{}, err
+	}
+	in, err := rate.Convert(ms.Inflow)
+	if err != nil {
+		return ConvertedSummary{}, err
+	}
+	out, err := rate.Convert(ms.Outflow)
+	if err != nil {
+		return ConvertedSummary{}, err
+	}
+	return ConvertedSummary{Currency: rate.Quote, Inflow: in, Outflow: out, Net: in - out, Rate: rate}, nil
+}
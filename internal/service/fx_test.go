@@ -0,0 +1,62 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+const ecbSample = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-03-31">
+			<Cube currency="USD" rate="1.0823"/>
+			<Cube currency="JPY" rate="163.45"/>
+		</Cube>
+		<Cube time="2026-03-30">
+			<Cube currency="USD" rate="1.0790"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestParseECBXML(t *testing.T) {
+	rows, err := ParseECBXML(strings.NewReader(ecbSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("want 3 rates, got %d", len(rows))
+	}
+	first := rows[0]
+	if first.Base != "EUR" || first.Quote != "USD" || first.Rate != "1.0823" || first.Date.Format("2006-01-02") != "2026-03-31" {
+		t.Fatalf("unexpected first row: %+v", first)
+	}
+}
+
+func TestParseRatesCSV(t *testing.T) {
+	rows, err := ParseRatesCSV(strings.NewReader("date,base,quote,rate\n2026-03-31,usd,eur,0.924\n2026-04-01, USD, GBP, 0.79\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Base != "USD" || rows[1].Quote != "GBP" {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+
+	if _, err := ParseRatesCSV(strings.NewReader("2026-03-31,USD,EUR,-1\n")); err == nil {
+		t.Fatal("negative rate must be rejected")
+	}
+	if _, err := ParseRatesCSV(strings.NewReader("31/03/2026,USD,EUR,0.9\n")); err == nil {
+		t.Fatal("non-ISO date must be rejected")
+	}
+}
+
+func TestAppliedRateConvert(t *testing.T) {
+	r := AppliedRate{Base: "EUR", Quote: "JPY", Rate: "163.45"}
+	got, err := r.Convert(1000) // EUR 10.00
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1635 { // JPY 1634.5 rounds up
+		t.Fatalf("got %d, want 1635", got)
+	}
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+// MaxHistoryPoints bounds the points of one balance history.
+const MaxHistoryPoints = 731
+
+type HistoryInput struct {
+	From, To time.Time // widened to whole periods
+	Interval string    // day (default), week or month
+	Basis    string    // recorded (default) or occurred
+}
+
+// BalancePoint is the balance at the end of a period, dated by its last day.
+type BalancePoint struct {
+	Date    string `json:"date"` // YYYY-MM-DD, UTC
+	Balance int64  `json:"balance_minor"`
+}
+
+type BalanceHistory struct {
+	AccountID int64          `json:"account_id"`
+	Currency  string         `json:"currency"`
+	Basis     string         `json:"basis"`
+	Interval  string         `json:"interval"`
+	From      time.Time      `json:"from"`
+	To        time.Time      `json:"to"` // exclusive
+	Points    []BalancePoint `json:"points"`
+}
+
+// periods validates in and returns the period starts and the end of the
+// last period.
+func (in *HistoryInput) periods() ([]time.Time, time.Time, error) {
+	if in.Interval == "" {
+		in.Interval = IntervalDay
+	}
+	if in.Basis == "" {
+		in.Basis = BasisRecorded
+	}
+	if in.Interval != IntervalDay && in.Interval != IntervalWeek && in.Interval != IntervalMonth {
+		return nil, time.Time{}, fmt.Errorf("%w: interval must be day, week or month", ErrReportRange)
+	}
+	if in.Basis != BasisOccurred && in.Basis != BasisRecorded {
+		return nil, time.Time{}, fmt.Errorf("%w, not %q", ErrBalanceBasis, in.Basis)
+	}
+	if !in.To.After(in.From) {
+		return nil, time.Time{}, fmt.Errorf("%w: to must be after from", ErrReportRange)
+	}
+	starts := bucketStarts(in.From, in.To, in.Interval)
+	if len(starts) > MaxHistoryPoints {
+		return nil, time.Time{}, fmt.Errorf("%w: %d points exceeds the limit of %d", ErrReportRange, len(starts), MaxHistoryPoints)
+	}
+	return starts, stepBucket(starts[len(starts)-1], in.Interval, 1), nil
+}
+
+// History returns the account's balance at the end of every day, week or
+// month of the range. The opening balance is BalanceAsOf's; daily posting
+// totals carry it forward, and on the recorded basis each day that has a
+// snapshot takes the snapshot's figure.
+func (s *BalanceService) History(ctx context.Context, accountID int64, in HistoryInput) (BalanceHistory, error) {
+	starts, end, err := in.periods()
+	if err != nil {
+		return BalanceHistory{}, err
+	}
+	acc, err := s.q.GetAccount(ctx, accountID)
+	if err != nil {
+		return BalanceHistory{}, err
+	}
+	points, err := s.histories(ctx, []int64{accountID}, in.Basis, in.Interval, starts, end)
+	if err != nil {
+		return BalanceHistory{}, err
+	}
+	return BalanceHistory{
+		AccountID: accountID, Currency: acc.Currency, Basis: in.Basis, Interval: in.Interval,
+		From: starts[0], To: end, Points: points[accountID],
+	}, nil
+}
+
+// histories computes History's points for each of ids. Every series is read
+// for all the accounts in one query, so the round trips do not grow with
+// the number of accounts.
+func (s *BalanceService) histories(ctx context.Context, ids []int64, basis, interval string, starts []time.Time, end time.Time) (map[int64][]BalancePoint, error) {
+	out := make(map[int64][]BalancePoint, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	open := make(map[int64]int64, len(ids))
+	deltas := make(map[int64]map[time.Time]int64, len(ids))
+	snaps := make(map[int64]map[time.Time]int64, len(ids))
+	for _, id := range ids {
+		deltas[id] = make(map[time.Time]int64)
+		snaps[id] = make(map[time.Time]int64)
+	}
+	// As in BalanceAsOf, the usable snapshots are those of days ending
+	// before the opening instant.
+	snapDate := dayOf(starts[0].AddDate(0, 0, -1))
+	if basis == BasisRecorded {
+		opening, err := s.q.OpeningBalancesRecorded(ctx, sqlc.OpeningBalancesRecordedParams{AccountIds: ids, SnapshotDate: snapDate, AsOf: starts[0]})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range opening {
+			open[r.AccountID] = r.LedgerMinor
+		}
+		rows, err := s.q.DailyPostingsRecorded(ctx, sqlc.DailyPostingsRecordedParams{AccountIds: ids, FromTime: starts[0], ToTime: end})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			deltas[r.AccountID][r.Day.UTC()] = r.Delta
+		}
+		ss, err := s.q.ListSnapshotsBetween(ctx, sqlc.ListSnapshotsBetweenParams{
+			AccountIds: ids, FromDate: starts[0], ToDate: end.AddDate(0, 0, -1),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, sn := range ss {
+			snaps[sn.AccountID][sn.AsOfDate.UTC()] = sn.BalanceMinor
+		}
+	} else {
+		opening, err := s.q.OpeningBalancesOccurred(ctx, sqlc.OpeningBalancesOccurredParams{AccountIds: ids, SnapshotDate: snapDate, AsOf: starts[0]})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range opening {
+			open[r.AccountID] = r.LedgerMinor
+		}
+		rows, err := s.q.DailyPostingsOccurred(ctx, sqlc.DailyPostingsOccurredParams{AccountIds: ids, FromTime: starts[0], ToTime: end})
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			deltas[r.AccountID][r.Day.UTC()] = r.Delta
+		}
+	}
+	for _, id := range ids {
+		out[id] = balancePoints(starts, end, interval, open[id], deltas[id], snaps[id])
+	}
+	return out, nil
+}
+
+// balancePoints walks the days from starts[0] to end, adding each day's
+// delta to the opening balance (or taking that day's snapshot), and emits
+// the balance on the last day of every period.
+func balancePoints(starts []time.Time, end time.Time, interval string, open int64, deltas, snaps map[time.Time]int64) []BalancePoint {
+	points := make([]BalancePoint, 0, len(starts))
+	running := open
+	next := 0
+	for d := starts[0]; d.Before(end); d = d.AddDate(0, 0, 1) {
+		running += deltas[d]
+		if v, ok := snaps[d]; ok {
+			running = v
+		}
+		if next < len(starts) && !d.AddDate(0, 0, 1).Before(stepBucket(starts[next], interval, 1)) {
+			points = append(points, BalancePoint{Date: d.Format("2006-01-02"), Balance: running})
+			next++
+		}
+	}
+	return points
+}
+
+// NetWorthPoint is net worth at the end of a period, per currency.
+type NetWorthPoint struct {
+	Date   string           `json:"date"`
+	Totals []CurrencyAmount `json:"totals"`
+}
+
+type NetWorthHistory struct {
+	Basis    string          `json:"basis"`
+	Interval string          `json:"interval"`
+	From     time.Time       `json:"from"`
+	To       time.Time       `json:"to"` // exclusive
+	Points   []NetWorthPoint `json:"points"`
+}
+
+// NetWorthHistory sums the History of the user's asset and liability
+// accounts per currency, like NetWorth, at the end of every period. All the
+// accounts are read together, in as many queries as a single History.
+func (s *BalanceService) NetWorthHistory(ctx context.Context, userID int64, in HistoryInput) (NetWorthHistory, error) {
+	starts, end, err := in.periods()
+	if err != nil {
+		return NetWorthHistory{}, err
+	}
+	accounts, err := s.q.ListNormalAccountsByUser(ctx, userID)
+	if err != nil {
+		return NetWorthHistory{}, err
+	}
+	var ids []int64
+	for _, a := range accounts {
+		if a.Type == AccountAsset || a.Type == AccountLiability {
+			ids = append(ids, a.ID)
+		}
+	}
+	points, err := s.histories(ctx, ids, in.Basis, in.Interval, starts, end)
+	if err != nil {
+		return NetWorthHistory{}, err
+	}
+	var order []string
+	sums := make([]map[string]int64, len(starts))
+	for i := range sums {
+		sums[i] = make(map[string]int64)
+	}
+	for _, a := range accounts {
+		if a.Type != AccountAsset && a.Type != AccountLiability {
+			continue
+		}
+		if _, ok := sums[0][a.Currency]; !ok {
+			order = append(order, a.Currency)
+		}
+		for i, p := range points[a.ID] {
+			sums[i][a.Currency] += p.Balance
+		}
+	}
+	nw := NetWorthHistory{Basis: in.Basis, Interval: in.Interval, From: starts[0], To: end, Points: make([]NetWorthPoint, 0, len(starts))}
+	for i, b := range starts {
+		p := NetWorthPoint{Date: stepBucket(b, in.Interval, 1).AddDate(0, 0, -1).Format("2006-01-02"), Totals: []CurrencyAmount{}}
+		for _, c := range order {
+			p.Totals = append(p.Totals, CurrencyAmount{Currency: c, AmountMinor: sums[i][c]})
+		}
+		nw.Points = append(nw.Points, p)
+	}
+	return nw, nil
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBalancePoints(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	deltas := map[time.Time]int64{day(6): 100, day(8): -30, day(14): 50, day(20): 7}
+	// Weeks starting Monday the 6th and 13th.
+	starts := bucketStarts(day(6), day(15), IntervalWeek)
+	end := stepBucket(starts[len(starts)-1], IntervalWeek, 1)
+	points := balancePoints(starts, end, IntervalWeek, 1000, deltas, nil)
+	if len(points) != 2 || points[0] != (BalancePoint{"2025-01-12", 1070}) || points[1] != (BalancePoint{"2025-01-19", 1120}) {
+		t.Fatalf("weekly: %+v", points)
+	}
+
+	// A snapshot overrides the running figure from its day on.
+	daily := bucketStarts(day(7), day(10), IntervalDay)
+	points = balancePoints(daily, day(10), IntervalDay, 1000, deltas, map[time.Time]int64{day(8): 2000})
+	want := []BalancePoint{{"2025-01-07", 1000}, {"2025-01-08", 2000}, {"2025-01-09", 2000}}
+	if len(points) != len(want) {
+		t.Fatalf("daily: %+v", points)
+	}
+	for i := range want {
+		if points[i] != want[i] {
+			t.Fatalf("daily[%d] = %+v, want %+v", i, points[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var (
+	ErrHoldNotFound   = errors.New("hold not found")
+	ErrHoldNotPending = errors.New("hold is no longer pending")
+	ErrHoldAmount     = errors.New("hold amount must be positive and a capture cannot exceed it")
+	ErrHoldAccount    = errors.New("holds can only be placed on normal accounts")
+	ErrHoldExpiry     = errors.New("hold expiry must be in the future")
+)
+
+// defaultHoldTTL is how long a hold reserves funds when the caller does not
+// say; card authorizations typically lapse after about a week.
+const defaultHoldTTL = 7 * 24 * time.Hour
+
+// HoldService manages holds: reservations that lower an account's available
+// balance without posting to the ledger until they are captured.
+type HoldService struct {
+	pool  *pgxpool.Pool
+	q     *sqlc.Queries
+	txSvc *TransactionService
+}
+
+func NewHoldService(pool *pgxpool.Pool, q *sqlc.Queries, txSvc *TransactionService) *HoldService {
+	return &HoldService{pool: pool, q: q, txSvc: txSvc}
+}
+
+type HoldDTO struct {
+	ID            int64     `json:"id"`
+	AccountID     int64     `json:"account_id"`
+	AmountMinor   int64     `json:"amount_minor"`
+	Currency      string    `json:"currency"`
+	Note          string    `json:"note,omitempty"`
+	Status        string    `json:"status"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	TransactionID int64     `json:"transaction_id,omitempty"`
+	CapturedMinor int64     `json:"captured_minor,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func toHoldDTO(h sqlc.Hold) HoldDTO {
+	return HoldDTO{
+		ID:            h.ID,
+		AccountID:     h.AccountID,
+		AmountMinor:   h.AmountMinor,
+		Currency:      h.Currency,
+		Note:          h.Note.String,
+		Status:        h.Status,
+		ExpiresAt:     h.ExpiresAt,
+		TransactionID: h.TransactionID.Int64,
+		CapturedMinor: h.CapturedMinor.Int64,
+		CreatedAt:     h.CreatedAt,
+		UpdatedAt:     h.UpdatedAt,
+	}
+}
+
+type PlaceHoldInput struct {
+	UserID      int64
+	AccountID   int64
+	AmountMinor int64 // positive amount to reserve
+	Currency    string
+	Note        string
+	ExpiresAt   time.Time // zero = now + defaultHoldTTL
+}
+
+// Place reserves AmountMinor on one of the user's normal accounts.
+func (s *HoldService) Place(ctx context.Context, in PlaceHoldInput) (HoldDTO, error) {
+	if in.AmountMinor <= 0 {
+		return HoldDTO{}, ErrHoldAmount
+	}
+	now := time.Now().UTC()
+	if in.ExpiresAt.IsZero() {
+		in.ExpiresAt = now.Add(defaultHoldTTL)
+	}
+	if !in.ExpiresAt.After(now) {
+		return HoldDTO{}, ErrHoldExpiry
+	}
+
+	acc, err := s.q.GetAccount(ctx, in.AccountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return HoldDTO{}, ErrAccountNotFound
+	}
+	if err != nil {
+		return HoldDTO{}, err
+	}
+	if acc.UserID != in.UserID {
+		return HoldDTO{}, ErrForbidden
+	}
+	if !acc.IsActive {
+		return HoldDTO{}, ErrAccountInactive
+	}
+	if acc.Kind != "normal" {
+		return HoldDTO{}, ErrHoldAccount
+	}
+	if acc.Currency != in.Currency {
+		return HoldDTO{}, ErrCurrencyMismatch
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return HoldDTO{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	// A hold reserves funds, so it must fit above the account's floor just
+	// like a posting would.
+	if err := enforceFloors(ctx, qtx, map[int64]int64{in.AccountID: -in.AmountMinor}); err != nil {
+		return HoldDTO{}, err
+	}
+	h, err := qtx.CreateHold(ctx, sqlc.CreateHoldParams{
+		UserID:      in.UserID,
+		AccountID:   in.AccountID,
+		AmountMinor: in.AmountMinor,
+		Currency:    in.Currency,
+		Note:        pgtype.Text{String: in.Note, Valid: in.Note != ""},
+		ExpiresAt:   in.ExpiresAt,
+	})
+	if err != nil {
+		return HoldDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return HoldDTO{}, err
+	}
+	return toHoldDTO(h), nil
+}
+
+type CaptureHoldInput struct {
+	UserID         int64
+	HoldID         int64
+	AmountMinor    int64     // 0 = the full hold; less captures partially and releases the rest
+	OccurredAt     time.Time // zero = now
+	IdempotencyKey string
+}
+
+type CaptureResult struct {
+	Hold        HoldDTO        `json:"hold"`
+	Transaction TransactionDTO `json:"transaction"`
+}
+
+// Capture turns a pending hold into a real expense against its account, with
+// the user's external account on the other side. Locking the hold row makes
+// capture, void and expiry mutually exclusive; a hold captures at most once.
+func (s *HoldService) Capture(ctx context.Context, in CaptureHoldInput) (CaptureResult, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return CaptureResult{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	hash := captureHash(in)
+	cached, err := claimIdempotency(ctx, qtx, in.UserID, in.IdempotencyKey, hash)
+	if err != nil {
+		return CaptureResult{}, err
+	}
+	if cached != nil {
+		h, err := qtx.GetHoldForUser(ctx, sqlc.GetHoldForUserParams{ID: in.HoldID, UserID: in.UserID})
+		if err != nil {
+			return CaptureResult{}, err
+		}
+		return CaptureResult{Hold: toHoldDTO(h), Transaction: *cached}, nil
+	}
+
+	h, err := qtx.LockHoldForUser(ctx, sqlc.LockHoldForUserParams{ID: in.HoldID, UserID: in.UserID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CaptureResult{}, ErrHoldNotFound
+	}
+	if err != nil {
+		return CaptureResult{}, err
+	}
+	now := time.Now().UTC()
+	// An expired hold the sweep has not reached yet is already void.
+	if h.Status != "pending" || !h.ExpiresAt.After(now) {
+		return CaptureResult{}, ErrHoldNotPending
+	}
+	amount := in.AmountMinor
+	if amount == 0 {
+		amount = h.AmountMinor
+	}
+	if amount < 0 || amount > h.AmountMinor {
+		return CaptureResult{}, ErrHoldAmount
+	}
+	occurredAt := in.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = now
+	}
+
+	bin := balancedInput{
+		UserID:         in.UserID,
+		Legs:           []Leg{{AccountID: h.AccountID, AmountMinor: -amount}},
+		UseExternalLeg: true,
+		FundedByHold:   true,
+		Currency:       h.Currency,
+		OccurredAt:     occurredAt,
+		Note:           h.Note.String,
+	}
+	if err := validateLegs(withCurrency(bin.Legs, bin.Currency), true); err != nil {
+		return CaptureResult{}, err
+	}
+	dto, legs, err := s.txSvc.postBalanced(ctx, qtx, bin)
+	if err != nil {
+		return CaptureResult{}, err
+	}
+	captured, err := qtx.CaptureHold(ctx, sqlc.CaptureHoldParams{
+		TransactionID: pgtype.Int8{Int64: dto.ID, Valid: true},
+		CapturedMinor: pgtype.Int8{Int64: amount, Valid: true},
+		ID:            h.ID,
+	})
+	if err != nil {
+		return CaptureResult{}, err
+	}
+	if err := emitTransactionEvent(ctx, qtx, "TransactionCreated", dto, map[string]any{"hold_id": h.ID}); err != nil {
+		return CaptureResult{}, err
+	}
+	if err := markIdempotency(ctx, qtx, in.UserID, in.IdempotencyKey, hash, dto); err != nil {
+		return CaptureResult{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return CaptureResult{}, err
+	}
+
+	s.txSvc.invalidate(ctx, legs, occurredAt)
+	return CaptureResult{Hold: toHoldDTO(captured), Transaction: dto}, nil
+}
+
+// Void releases a pending hold without posting anything.
+func (s *HoldService) Void(ctx context.Context, userID, holdID int64) (HoldDTO, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return HoldDTO{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	h, err := qtx.LockHoldForUser(ctx, sqlc.LockHoldForUserParams{ID: holdID, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return HoldDTO{}, ErrHoldNotFound
+	}
+	if err != nil {
+		return HoldDTO{}, err
+	}
+	if h.Status != "pending" {
+		return HoldDTO{}, ErrHoldNotPending
+	}
+	voided, err := qtx.VoidHold(ctx, h.ID)
+	if err != nil {
+		return HoldDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return HoldDTO{}, err
+	}
+	return toHoldDTO(voided), nil
+}
+
+// List returns the account's holds, newest first, optionally filtered by
+// status. The caller checks account ownership.
+func (s *HoldService) List(ctx context.Context, accountID int64, status string, limit int32) ([]HoldDTO, error) {
+	rows, err := s.q.ListHoldsByAccount(ctx, sqlc.ListHoldsByAccountParams{
+		AccountID: accountID,
+		Status:    pgtype.Text{String: status, Valid: status != ""},
+		PageLimit: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]HoldDTO, 0, len(rows))
+	for _, h := range rows {
+		out = append(out, toHoldDTO(h))
+	}
+	return out, nil
+}
+
+func captureHash(in CaptureHoldInput) string {
+	payload := struct {
+		UserID      int64     `json:"user_id"`
+		Op          string    `json:"op"`
+		HoldID      int64     `json:"hold_id"`
+		AmountMinor int64     `json:"amount_minor"`
+		OccurredAt  time.Time `json:"occurred_at"`
+	}{in.UserID, "capture", in.HoldID, in.AmountMinor, in.OccurredAt.UTC()}
+	b, _ := json.Marshal(payload)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var ErrInvalidLimits = errors.New("overdraft limit must not be negative")
+
+// Limits are an account's optional balance constraints. Nil means unset.
+type Limits struct {
+	MinBalanceMinor     *int64 `json:"min_balance_minor"`
+	OverdraftLimitMinor *int64 `json:"overdraft_limit_minor"`
+	NeverNegative       bool   `json:"never_negative"`
+}
+
+func toLimits(l sqlc.AccountLimit) Limits {
+	var out Limits
+	if l.MinBalanceMinor.Valid {
+		v := l.MinBalanceMinor.Int64
+		out.MinBalanceMinor = &v
+	}
+	if l.OverdraftLimitMinor.Valid {
+		v := l.OverdraftLimitMinor.Int64
+		out.OverdraftLimitMinor = &v
+	}
+	out.NeverNegative = l.NeverNegative
+	return out
+}
+
+// Limits returns the account's constraints; an account never configured is
+// unconstrained.
+func (s *BalanceService) Limits(ctx context.Context, accountID int64) (Limits, error) {
+	row, err := s.q.GetAccountLimits(ctx, accountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Limits{}, nil
+	}
+	if err != nil {
+		return Limits{}, err
+	}
+	return toLimits(row), nil
+}
+
+// SetLimits replaces the account's constraints. New limits apply to future
+// postings only; an account already below its new floor stays as it is.
+func (s *BalanceService) SetLimits(ctx context.Context, accountID int64, l Limits) (Limits, error) {
+	if l.OverdraftLimitMinor != nil && *l.OverdraftLimitMinor < 0 {
+		return Limits{}, ErrInvalidLimits
+	}
+	row, err := s.q.UpsertAccountLimits(ctx, l.params(accountID))
+	if err != nil {
+		return Limits{}, err
+	}
+	return toLimits(row), nil
+}
+
+func (l Limits) params(accountID int64) sqlc.UpsertAccountLimitsParams {
+	p := sqlc.UpsertAccountLimitsParams{AccountID: accountID, NeverNegative: l.NeverNegative}
+	if l.MinBalanceMinor != nil {
+		p.MinBalanceMinor = pgtype.Int8{Int64: *l.MinBalanceMinor, Valid: true}
+	}
+	if l.OverdraftLimitMinor != nil {
+		p.OverdraftLimitMinor = pgtype.Int8{Int64: *l.OverdraftLimitMinor, Valid: true}
+	}
+	return p
+}
+
+// Floor is the lowest available balance the constraints allow. Every set
+// constraint applies, so the effective floor is the highest of them; ok is
+// false when the account is unconstrained.
+func (l Limits) Floor() (floor int64, ok bool) {
+	raise := func(v int64) {
+		if !ok || v > floor {
+			floor, ok = v, true
+		}
+	}
+	if l.MinBalanceMinor != nil {
+		raise(*l.MinBalanceMinor)
+	}
+	if l.OverdraftLimitMinor != nil {
+		raise(-*l.OverdraftLimitMinor)
+	}
+	if l.NeverNegative {
+		raise(0)
+	}
+	return floor, ok
+}
+
+// debitsOf keeps the accounts of net (account id -> net amount) that money
+// leaves, in the form enforceFloors takes.
+func debitsOf(net map[int64]int64) map[int64]int64 {
+	debits := make(map[int64]int64)
+	for id, amt := range net {
+		if amt < 0 {
+			debits[id] = amt
+		}
+	}
+	return debits
+}
+
+// enforceFloors locks the limits of every account in debits (account id ->
+// amount leaving it, as a negative number) and checks that each constrained
+// account's available balance stays at or above its floor. It runs inside
+// the posting transaction: the row lock serializes concurrent debits, and
+// the balance is read after the lock, so it includes whatever the previous
+// holder committed. Credits are never checked — money coming in is always
+// allowed, even into an account already below its floor.
+func enforceFloors(ctx context.Context, qtx *sqlc.Queries, debits map[int64]int64) error {
+	if len(debits) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(debits))
+	for id := range debits {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	rows, err := qtx.LockAccountLimits(ctx, ids)
+	if err != nil {
+		return err
+	}
+	bal := NewBalanceService(qtx)
+	now := time.Now()
+	for _, row := range rows {
+		floor, ok := toLimits(row).Floor()
+		if !ok {
+			continue
+		}
+		b, err := bal.CurrentBalance(ctx, row.AccountID, now)
+		if err != nil {
+			return err
+		}
+		if b.Available+debits[row.AccountID] < floor {
+			return ErrInsufficientFunds
+		}
+	}
+	return nil
+}
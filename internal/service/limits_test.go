@@ -0,0 +1,30 @@
+package service
+
+import "testing"
+
+func TestLimitsFloor(t *testing.T) {
+	i64 := func(v int64) *int64 { return &v }
+	cases := []struct {
+		name   string
+		limits Limits
+		want   int64
+		ok     bool
+	}{
+		{"unconstrained", Limits{}, 0, false},
+		{"never negative", Limits{NeverNegative: true}, 0, true},
+		{"minimum balance", Limits{MinBalanceMinor: i64(5000)}, 5000, true},
+		{"overdraft", Limits{OverdraftLimitMinor: i64(20000)}, -20000, true},
+		{"zero overdraft", Limits{OverdraftLimitMinor: i64(0)}, 0, true},
+		{"negative minimum", Limits{MinBalanceMinor: i64(-100)}, -100, true},
+		{"highest floor wins", Limits{MinBalanceMinor: i64(-500), OverdraftLimitMinor: i64(1000), NeverNegative: true}, 0, true},
+		{"minimum above never-negative", Limits{MinBalanceMinor: i64(100), NeverNegative: true}, 100, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, ok := c.limits.Floor()
+			if got != c.want || ok != c.ok {
+				t.Fatalf("Floor() = %d, %v; want %d, %v", got, ok, c.want, c.ok)
+			}
+		})
+	}
+}
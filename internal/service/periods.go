@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var (
+	ErrPeriodClosed        = errors.New("accounting period is closed")
+	ErrPeriodAlreadyClosed = errors.New("accounting period is already closed")
+	ErrPeriodNotClosed     = errors.New("accounting period is not closed")
+)
+
+// AdminActor is the actor recorded for global closes made with the admin
+// token.
+const AdminActor = "admin"
+
+// PeriodService closes and reopens accounting months. Postings dated in a
+// closed month are refused (ErrPeriodClosed), so reported figures stay put;
+// corrections land in the current open period instead.
+type PeriodService struct {
+	pool *pgxpool.Pool
+	q    *sqlc.Queries
+}
+
+func NewPeriodService(pool *pgxpool.Pool, q *sqlc.Queries) *PeriodService {
+	return &PeriodService{pool: pool, q: q}
+}
+
+type ClosedPeriodDTO struct {
+	Month    string    `json:"month"` // YYYY-MM
+	Global   bool      `json:"global"`
+	ClosedBy string    `json:"closed_by"`
+	ClosedAt time.Time `json:"closed_at"`
+}
+
+type PeriodAuditDTO struct {
+	Month  string    `json:"month"`
+	Global bool      `json:"global"`
+	Action string    `json:"action"` // close | reopen
+	Actor  string    `json:"actor"`
+	At     time.Time `json:"at"`
+}
+
+// UserActor is the actor recorded when a user closes or reopens their own
+// period.
+func UserActor(userID int64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// Close closes month for userID, or for everyone when userID is 0.
+func (s *PeriodService) Close(ctx context.Context, userID int64, month time.Time, actor string) (ClosedPeriodDTO, error) {
+	month = monthOf(month)
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return ClosedPeriodDTO{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	// Waits for postings already past their period check to commit.
+	if err := lockPeriodsExclusive(ctx, qtx, userID); err != nil {
+		return ClosedPeriodDTO{}, err
+	}
+	p, err := qtx.ClosePeriod(ctx, sqlc.ClosePeriodParams{UserID: periodScope(userID), Month: month, ClosedBy: actor})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ClosedPeriodDTO{}, ErrPeriodAlreadyClosed
+	}
+	if err != nil {
+		return ClosedPeriodDTO{}, err
+	}
+	if err := qtx.InsertPeriodAudit(ctx, sqlc.InsertPeriodAuditParams{
+		UserID: periodScope(userID), Month: month, Action: "close", Actor: actor,
+	}); err != nil {
+		return ClosedPeriodDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return ClosedPeriodDTO{}, err
+	}
+	return toClosedPeriodDTO(p), nil
+}
+
+// Reopen undoes Close for the same scope. A user cannot reopen a global
+// close.
+func (s *PeriodService) Reopen(ctx context.Context, userID int64, month time.Time, actor string) error {
+	month = monthOf(month)
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	if err := lockPeriodsExclusive(ctx, qtx, userID); err != nil {
+		return err
+	}
+	n, err := qtx.ReopenPeriod(ctx, sqlc.ReopenPeriodParams{Month: month, UserID: periodScope(userID)})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrPeriodNotClosed
+	}
+	if err := qtx.InsertPeriodAudit(ctx, sqlc.InsertPeriodAuditParams{
+		UserID: periodScope(userID), Month: month, Action: "reopen", Actor: actor,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// List returns the months closed for the user (their own and global ones)
+// and the latest close/reopen history, newest first.
+func (s *PeriodService) List(ctx context.Context, userID int64) ([]ClosedPeriodDTO, []PeriodAuditDTO, error) {
+	rows, err := s.q.ListClosedPeriods(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	closed := make([]ClosedPeriodDTO, 0, len(rows))
+	for _, p := range rows {
+		closed = append(closed, toClosedPeriodDTO(p))
+	}
+	audit, err := s.q.ListPeriodAudit(ctx, sqlc.ListPeriodAuditParams{UserID: userID, PageLimit: 100})
+	if err != nil {
+		return nil, nil, err
+	}
+	history := make([]PeriodAuditDTO, 0, len(audit))
+	for _, a := range audit {
+		history = append(history, PeriodAuditDTO{
+			Month: a.Month.Format("2006-01"), Global: !a.UserID.Valid, Action: a.Action, Actor: a.Actor, At: a.CreatedAt,
+		})
+	}
+	return closed, history, nil
+}
+
+// checkPeriodOpen refuses a posting dated at in a month closed for the user.
+// The shared period lock is held until the caller's transaction ends.
+func checkPeriodOpen(ctx context.Context, qtx *sqlc.Queries, userID int64, at time.Time) error {
+	if err := qtx.LockPeriodsShared(ctx, userID); err != nil {
+		return err
+	}
+	closed, err := qtx.IsPeriodClosed(ctx, sqlc.IsPeriodClosedParams{Month: monthOf(at), UserID: userID})
+	if err != nil {
+		return err
+	}
+	if closed {
+		return fmt.Errorf("%w: %s", ErrPeriodClosed, monthOf(at).Format("2006-01"))
+	}
+	return nil
+}
+
+// lockPeriodsExclusive takes the user's period lock, or the global one for a
+// global close (userID 0), which every posting also holds shared.
+func lockPeriodsExclusive(ctx context.Context, qtx *sqlc.Queries, userID int64) error {
+	if userID == 0 {
+		return qtx.LockPeriodsExclusive(ctx)
+	}
+	return qtx.LockUserPeriodsExclusive(ctx, userID)
+}
+
+func periodScope(userID int64) pgtype.Int8 {
+	return pgtype.Int8{Int64: userID, Valid: userID != 0}
+}
+
+func toClosedPeriodDTO(p sqlc.ClosedPeriod) ClosedPeriodDTO {
+	return ClosedPeriodDTO{
+		Month: p.Month.Format("2006-01"), Global: !p.UserID.Valid, ClosedBy: p.ClosedBy, ClosedAt: p.ClosedAt,
+	}
+}
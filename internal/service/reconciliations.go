@@ -0,0 +1,552 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var (
+	ErrReconciliationNotFound   = errors.New("reconciliation not found")
+	ErrReconciliationCompleted  = errors.New("reconciliation is already completed")
+	ErrPostingMatched           = errors.New("posting is already matched or reconciled")
+	ErrInvalidReconciliation    = errors.New("invalid reconciliation")
+	ErrReconciliationDifference = errors.New("statement and cleared balances differ")
+)
+
+// Auto-match date window, in days either side of a statement line.
+const (
+	DefaultMatchWindowDays = 3
+	MaxMatchWindowDays     = 31
+)
+
+// MaxStatementLines bounds the lines of one uploaded bank statement.
+const MaxStatementLines = 10000
+
+// ReconciliationService reconciles an account against its bank statements.
+// A session holds the statement's lines; each line is matched, automatically
+// or by hand, to at most one posting on the account. Completing a session
+// marks its matched postings reconciled, which it only does once the
+// cleared balance equals the statement's closing balance.
+type ReconciliationService struct {
+	pool *pgxpool.Pool
+	q    *sqlc.Queries
+}
+
+func NewReconciliationService(pool *pgxpool.Pool, q *sqlc.Queries) *ReconciliationService {
+	return &ReconciliationService{pool: pool, q: q}
+}
+
+// BankLine is one line of an uploaded bank statement. Amounts are signed
+// like the account's balance: money into an asset account is positive.
+type BankLine struct {
+	Date        time.Time
+	AmountMinor int64
+	Description string
+}
+
+type ReconciliationInput struct {
+	UserID         int64
+	AccountID      int64
+	StatementDate  time.Time // the statement's last day
+	ClosingBalance int64
+	Lines          []BankLine
+	WindowDays     int // auto-match window, 0..MaxMatchWindowDays
+}
+
+type ReconciliationDTO struct {
+	ID             int64      `json:"id"`
+	AccountID      int64      `json:"account_id"`
+	StatementDate  string     `json:"statement_date"` // YYYY-MM-DD
+	Currency       string     `json:"currency"`
+	Status         string     `json:"status"` // open | completed
+	ClosingBalance int64      `json:"closing_balance_minor"`
+	ClearedBalance *int64     `json:"cleared_balance_minor,omitempty"`
+	Difference     *int64     `json:"difference_minor,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+type ReconciliationLineDTO struct {
+	ID          int64  `json:"id"`
+	LineNo      int32  `json:"line_no"`
+	Date        string `json:"date"`
+	AmountMinor int64  `json:"amount_minor"`
+	Description string `json:"description,omitempty"`
+	PostingID   *int64 `json:"posting_id,omitempty"`
+	MatchedBy   string `json:"matched_by,omitempty"` // auto | manual
+}
+
+// ReconciliationPostingDTO is a ledger entry no statement line accounts for.
+type ReconciliationPostingDTO struct {
+	PostingID     int64     `json:"posting_id"`
+	TransactionID int64     `json:"transaction_id"`
+	OccurredAt    time.Time `json:"occurred_at"`
+	AmountMinor   int64     `json:"amount_minor"`
+	Payee         string    `json:"payee,omitempty"`
+	Note          string    `json:"note,omitempty"`
+}
+
+// ReconciliationReport is a session with its lines. While it is open,
+// Outstanding lists the account's unreconciled postings up to the statement
+// date that no line is matched to, and the balances are live.
+type ReconciliationReport struct {
+	ReconciliationDTO
+	Lines          []ReconciliationLineDTO    `json:"lines"`
+	UnmatchedLines int                        `json:"unmatched_lines"`
+	Outstanding    []ReconciliationPostingDTO `json:"outstanding_postings"`
+}
+
+func toReconciliationDTO(r sqlc.Reconciliation) ReconciliationDTO {
+	dto := ReconciliationDTO{
+		ID: r.ID, AccountID: r.AccountID, StatementDate: r.StatementDate.Format("2006-01-02"),
+		Currency: r.Currency, Status: r.Status, ClosingBalance: r.ClosingBalanceMinor, CreatedAt: r.CreatedAt,
+	}
+	if r.ClearedBalanceMinor.Valid {
+		dto.ClearedBalance = &r.ClearedBalanceMinor.Int64
+	}
+	if r.DifferenceMinor.Valid {
+		dto.Difference = &r.DifferenceMinor.Int64
+	}
+	if r.CompletedAt.Valid {
+		dto.CompletedAt = &r.CompletedAt.Time
+	}
+	return dto
+}
+
+// Create opens a session for a bank statement of the account and
+// auto-matches its lines.
+func (s *ReconciliationService) Create(ctx context.Context, in ReconciliationInput) (ReconciliationReport, error) {
+	if in.StatementDate.IsZero() {
+		return ReconciliationReport{}, fmt.Errorf("%w: statement_date is required", ErrInvalidReconciliation)
+	}
+	if len(in.Lines) > MaxStatementLines {
+		return ReconciliationReport{}, fmt.Errorf("%w: %d lines exceeds the limit of %d", ErrInvalidReconciliation, len(in.Lines), MaxStatementLines)
+	}
+	if err := checkMatchWindow(in.WindowDays); err != nil {
+		return ReconciliationReport{}, err
+	}
+	statementDate := dayOf(in.StatementDate)
+	for i, l := range in.Lines {
+		switch {
+		case l.Date.IsZero():
+			return ReconciliationReport{}, fmt.Errorf("%w: line %d: date is required", ErrInvalidReconciliation, i+1)
+		case l.AmountMinor == 0:
+			return ReconciliationReport{}, fmt.Errorf("%w: line %d: amount must not be zero", ErrInvalidReconciliation, i+1)
+		case dayOf(l.Date).After(statementDate):
+			return ReconciliationReport{}, fmt.Errorf("%w: line %d: dated after the statement date", ErrInvalidReconciliation, i+1)
+		}
+	}
+	acc, err := ownedAccount(ctx, s.q, in.UserID, in.AccountID)
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	if acc.Kind != "normal" || (acc.Type != AccountAsset && acc.Type != AccountLiability) {
+		return ReconciliationReport{}, fmt.Errorf("%w: only asset and liability accounts are reconciled", ErrAccountType)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	rec, err := qtx.CreateReconciliation(ctx, sqlc.CreateReconciliationParams{
+		UserID: in.UserID, AccountID: in.AccountID, StatementDate: statementDate,
+		ClosingBalanceMinor: in.ClosingBalance, Currency: acc.Currency,
+	})
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	for i, l := range in.Lines {
+		if err := qtx.CreateReconciliationLine(ctx, sqlc.CreateReconciliationLineParams{
+			ReconciliationID: rec.ID, LineNo: int32(i + 1), OccurredOn: dayOf(l.Date),
+			AmountMinor: l.AmountMinor, Description: strings.TrimSpace(l.Description),
+		}); err != nil {
+			return ReconciliationReport{}, err
+		}
+	}
+	if err := autoMatch(ctx, qtx, rec, in.WindowDays); err != nil {
+		return ReconciliationReport{}, err
+	}
+	report, err := reconciliationReport(ctx, qtx, rec)
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return ReconciliationReport{}, err
+	}
+	return report, nil
+}
+
+// List returns the account's sessions, latest statement first.
+func (s *ReconciliationService) List(ctx context.Context, userID, accountID int64) ([]ReconciliationDTO, error) {
+	if _, err := ownedAccount(ctx, s.q, userID, accountID); err != nil {
+		return nil, err
+	}
+	rows, err := s.q.ListReconciliationsByAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]ReconciliationDTO, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, toReconciliationDTO(r))
+	}
+	return out, nil
+}
+
+func (s *ReconciliationService) Get(ctx context.Context, userID, id int64) (ReconciliationReport, error) {
+	rec, err := s.q.GetReconciliation(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && rec.UserID != userID) {
+		return ReconciliationReport{}, ErrReconciliationNotFound
+	}
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	return reconciliationReport(ctx, s.q, rec)
+}
+
+// AutoMatch matches the session's unmatched lines again, e.g. after the
+// missing transactions have been booked.
+func (s *ReconciliationService) AutoMatch(ctx context.Context, userID, id int64, windowDays int) (ReconciliationReport, error) {
+	if err := checkMatchWindow(windowDays); err != nil {
+		return ReconciliationReport{}, err
+	}
+	return s.update(ctx, userID, id, func(qtx *sqlc.Queries, rec sqlc.Reconciliation) error {
+		return autoMatch(ctx, qtx, rec, windowDays)
+	})
+}
+
+// Match matches a line to a posting on the account by hand, replacing any
+// earlier match of the line. The amounts need not agree; a mismatch shows in
+// the difference. Postings dated after the statement date are refused.
+func (s *ReconciliationService) Match(ctx context.Context, userID, id, lineID, postingID int64) (ReconciliationReport, error) {
+	return s.update(ctx, userID, id, func(qtx *sqlc.Queries, rec sqlc.Reconciliation) error {
+		p, err := qtx.GetReconciliationPosting(ctx, postingID)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && p.AccountID != rec.AccountID) {
+			return fmt.Errorf("%w: posting %d is not on account %d", ErrInvalidReconciliation, postingID, rec.AccountID)
+		}
+		if err != nil {
+			return err
+		}
+		if dayOf(p.OccurredAt).After(rec.StatementDate) {
+			return fmt.Errorf("%w: posting %d is dated after the statement date", ErrInvalidReconciliation, postingID)
+		}
+		if p.Reconciled {
+			return ErrPostingMatched
+		}
+		n, err := qtx.MatchReconciliationLine(ctx, sqlc.MatchReconciliationLineParams{
+			PostingID: pgtype.Int8{Int64: postingID, Valid: true},
+			MatchedBy: pgtype.Text{String: "manual", Valid: true},
+			ID:        lineID, ReconciliationID: rec.ID,
+		})
+		if isUniqueViolation(err) {
+			return ErrPostingMatched
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: no line %d", ErrReconciliationNotFound, lineID)
+		}
+		return nil
+	})
+}
+
+// Unmatch clears a line's match.
+func (s *ReconciliationService) Unmatch(ctx context.Context, userID, id, lineID int64) (ReconciliationReport, error) {
+	return s.update(ctx, userID, id, func(qtx *sqlc.Queries, rec sqlc.Reconciliation) error {
+		n, err := qtx.UnmatchReconciliationLine(ctx, sqlc.UnmatchReconciliationLineParams{ID: lineID, ReconciliationID: rec.ID})
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("%w: no line %d", ErrReconciliationNotFound, lineID)
+		}
+		return nil
+	})
+}
+
+// Complete marks the matched postings reconciled and closes the session. It
+// is refused while the cleared balance differs from the statement's closing
+// balance; unmatched lines are bank entries the ledger is still missing.
+// Sessions on the same account complete one at a time.
+func (s *ReconciliationService) Complete(ctx context.Context, userID, id int64) (ReconciliationReport, error) {
+	return s.update(ctx, userID, id, func(qtx *sqlc.Queries, rec sqlc.Reconciliation) error {
+		if err := qtx.LockReconciliationAccount(ctx, rec.AccountID); err != nil {
+			return err
+		}
+		cleared, err := qtx.ClearedBalance(ctx, sqlc.ClearedBalanceParams{AccountID: rec.AccountID, ReconciliationID: rec.ID})
+		if err != nil {
+			return err
+		}
+		if diff := rec.ClosingBalanceMinor - cleared; diff != 0 {
+			return fmt.Errorf("%w: closing balance %d, cleared %d, difference %d",
+				ErrReconciliationDifference, rec.ClosingBalanceMinor, cleared, diff)
+		}
+		if _, err := qtx.InsertReconciledPostings(ctx, rec.ID); err != nil {
+			if isUniqueViolation(err) {
+				return ErrPostingMatched
+			}
+			return err
+		}
+		return qtx.CompleteReconciliation(ctx, sqlc.CompleteReconciliationParams{
+			ID:                  rec.ID,
+			ClearedBalanceMinor: pgtype.Int8{Int64: cleared, Valid: true},
+			DifferenceMinor:     pgtype.Int8{Int64: 0, Valid: true},
+		})
+	})
+}
+
+// Delete discards an open session; completed ones are kept.
+func (s *ReconciliationService) Delete(ctx context.Context, userID, id int64) error {
+	rec, err := s.q.GetReconciliation(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && rec.UserID != userID) {
+		return ErrReconciliationNotFound
+	}
+	if err != nil {
+		return err
+	}
+	n, err := s.q.DeleteOpenReconciliation(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrReconciliationCompleted
+	}
+	return nil
+}
+
+// update runs fn on the caller's open session under its row lock and returns
+// the session as it stands afterwards.
+func (s *ReconciliationService) update(ctx context.Context, userID, id int64, fn func(*sqlc.Queries, sqlc.Reconciliation) error) (ReconciliationReport, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	rec, err := qtx.LockReconciliation(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && rec.UserID != userID) {
+		return ReconciliationReport{}, ErrReconciliationNotFound
+	}
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	if rec.Status != "open" {
+		return ReconciliationReport{}, ErrReconciliationCompleted
+	}
+	if err := fn(qtx, rec); err != nil {
+		return ReconciliationReport{}, err
+	}
+	if rec, err = qtx.GetReconciliation(ctx, id); err != nil {
+		return ReconciliationReport{}, err
+	}
+	report, err := reconciliationReport(ctx, qtx, rec)
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return ReconciliationReport{}, err
+	}
+	return report, nil
+}
+
+func checkMatchWindow(days int) error {
+	if days < 0 || days > MaxMatchWindowDays {
+		return fmt.Errorf("%w: window_days must be 0 to %d", ErrInvalidReconciliation, MaxMatchWindowDays)
+	}
+	return nil
+}
+
+// autoMatch matches the session's unmatched lines to the account's open
+// postings (see matchLines).
+func autoMatch(ctx context.Context, qtx *sqlc.Queries, rec sqlc.Reconciliation, window int) error {
+	lines, err := qtx.ListReconciliationLines(ctx, rec.ID)
+	if err != nil {
+		return err
+	}
+	var from, to time.Time
+	for _, l := range lines {
+		if l.PostingID.Valid {
+			continue
+		}
+		if from.IsZero() || l.OccurredOn.Before(from) {
+			from = l.OccurredOn
+		}
+		if l.OccurredOn.After(to) {
+			to = l.OccurredOn
+		}
+	}
+	if from.IsZero() {
+		return nil
+	}
+	// Like a manual match, never past the statement date.
+	end := to.AddDate(0, 0, window+1)
+	if last := rec.StatementDate.AddDate(0, 0, 1); end.After(last) {
+		end = last
+	}
+	postings, err := qtx.ListReconciliationCandidates(ctx, sqlc.ListReconciliationCandidatesParams{
+		AccountID: rec.AccountID, ReconciliationID: rec.ID,
+		FromTime: from.AddDate(0, 0, -window), ToTime: end,
+	})
+	if err != nil {
+		return err
+	}
+	for lineID, postingID := range matchLines(lines, postings, window) {
+		if _, err := qtx.MatchReconciliationLine(ctx, sqlc.MatchReconciliationLineParams{
+			PostingID: pgtype.Int8{Int64: postingID, Valid: true},
+			MatchedBy: pgtype.Text{String: "auto", Valid: true},
+			ID:        lineID, ReconciliationID: rec.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchLines pairs unmatched statement lines with postings of the same
+// amount dated at most window days apart, keyed by line id. The closest
+// dates pair first; ties go to the earlier line, then the earlier posting
+// (lines come in line order, postings oldest first). Each line and posting
+// is used once.
+func matchLines(lines []sqlc.ReconciliationLine, postings []sqlc.ListReconciliationCandidatesRow, window int) map[int64]int64 {
+	type pair struct{ line, posting, days int }
+	var pairs []pair
+	for i, l := range lines {
+		if l.PostingID.Valid {
+			continue
+		}
+		for j, p := range postings {
+			if p.AmountMinor != l.AmountMinor {
+				continue
+			}
+			if d := daysApart(l.OccurredOn, p.OccurredAt); d <= window {
+				pairs = append(pairs, pair{i, j, d})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].days < pairs[b].days })
+
+	out := make(map[int64]int64)
+	usedPosting := make(map[int]bool)
+	for _, p := range pairs {
+		id := lines[p.line].ID
+		if _, ok := out[id]; ok || usedPosting[p.posting] {
+			continue
+		}
+		out[id] = postings[p.posting].PostingID
+		usedPosting[p.posting] = true
+	}
+	return out
+}
+
+// daysApart counts the UTC days between a and b, either way round.
+func daysApart(a, b time.Time) int {
+	d := int(dayOf(a).Sub(dayOf(b)).Hours() / 24)
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func reconciliationReport(ctx context.Context, q *sqlc.Queries, rec sqlc.Reconciliation) (ReconciliationReport, error) {
+	lines, err := q.ListReconciliationLines(ctx, rec.ID)
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	r := ReconciliationReport{
+		ReconciliationDTO: toReconciliationDTO(rec),
+		Lines:             make([]ReconciliationLineDTO, 0, len(lines)),
+		Outstanding:       []ReconciliationPostingDTO{},
+	}
+	for _, l := range lines {
+		dto := ReconciliationLineDTO{
+			ID: l.ID, LineNo: l.LineNo, Date: l.OccurredOn.Format("2006-01-02"),
+			AmountMinor: l.AmountMinor, Description: l.Description,
+		}
+		if l.PostingID.Valid {
+			dto.PostingID = &l.PostingID.Int64
+			dto.MatchedBy = l.MatchedBy.String
+		} else {
+			r.UnmatchedLines++
+		}
+		r.Lines = append(r.Lines, dto)
+	}
+	if rec.Status != "open" {
+		return r, nil
+	}
+
+	cleared, err := q.ClearedBalance(ctx, sqlc.ClearedBalanceParams{AccountID: rec.AccountID, ReconciliationID: rec.ID})
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	diff := rec.ClosingBalanceMinor - cleared
+	r.ClearedBalance, r.Difference = &cleared, &diff
+	postings, err := q.ListReconciliationCandidates(ctx, sqlc.ListReconciliationCandidatesParams{
+		AccountID: rec.AccountID, ReconciliationID: rec.ID,
+		FromTime: time.Time{}, ToTime: rec.StatementDate.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		return ReconciliationReport{}, err
+	}
+	for _, p := range postings {
+		r.Outstanding = append(r.Outstanding, ReconciliationPostingDTO{
+			PostingID: p.PostingID, TransactionID: p.TransactionID, OccurredAt: p.OccurredAt,
+			AmountMinor: p.AmountMinor, Payee: p.Payee.String, Note: p.Note.String,
+		})
+	}
+	return r, nil
+}
+
+// ParseBankLinesCSV reads "date,amount_minor[,description]" lines
+// (YYYY-MM-DD dates). A header row is skipped if present.
+func ParseBankLinesCSV(r io.Reader) ([]BankLine, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var out []BankLine
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && strings.EqualFold(rec[0], "date") {
+			continue
+		}
+		if len(rec) < 2 || len(rec) > 3 {
+			return nil, fmt.Errorf("line %d: want date,amount_minor[,description]", line)
+		}
+		d, err := time.Parse("2006-01-02", rec[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: date must be YYYY-MM-DD", line)
+		}
+		amount, err := strconv.ParseInt(strings.TrimSpace(rec[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: amount_minor must be an integer", line)
+		}
+		l := BankLine{Date: d, AmountMinor: amount}
+		if len(rec) == 3 {
+			l.Description = rec[2]
+		}
+		out = append(out, l)
+	}
+	return out, nil
+}
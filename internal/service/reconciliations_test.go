@@ -0,0 +1,64 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+func TestMatchLines(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC) }
+	lines := []sqlc.ReconciliationLine{
+		{ID: 1, OccurredOn: day(5), AmountMinor: -4599},
+		{ID: 2, OccurredOn: day(6), AmountMinor: -4599},
+		{ID: 3, OccurredOn: day(10), AmountMinor: 250000},
+		{ID: 4, OccurredOn: day(20), AmountMinor: -100},                                                // nothing close enough
+		{ID: 5, OccurredOn: day(1), AmountMinor: -700, PostingID: pgtype.Int8{Int64: 99, Valid: true}}, // already matched
+	}
+	postings := []sqlc.ListReconciliationCandidatesRow{
+		{PostingID: 10, OccurredAt: day(4).Add(20 * time.Hour), AmountMinor: -4599},
+		{PostingID: 11, OccurredAt: day(6).Add(9 * time.Hour), AmountMinor: -4599},
+		{PostingID: 12, OccurredAt: day(8), AmountMinor: 250000},
+		{PostingID: 13, OccurredAt: day(9), AmountMinor: 250000},
+		{PostingID: 14, OccurredAt: day(16), AmountMinor: -100},
+		{PostingID: 15, OccurredAt: day(1), AmountMinor: -700},
+	}
+	got := matchLines(lines, postings, 3)
+	// Same-day pairs go first, so line 2 takes posting 11 and line 1 is left
+	// with posting 10 a day earlier; line 3 takes the closer salary posting.
+	want := map[int64]int64{1: 10, 2: 11, 3: 13}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for l, p := range want {
+		if got[l] != p {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	// A zero window only matches the same day.
+	if got := matchLines(lines, postings, 0); len(got) != 1 || got[2] != 11 {
+		t.Fatalf("same-day window: %v", got)
+	}
+}
+
+func TestParseBankLinesCSV(t *testing.T) {
+	lines, err := ParseBankLinesCSV(strings.NewReader("date,amount_minor,description\n2025-03-05,-4599,\"Grocer, Main St\"\n2025-03-10, 250000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0].Description != "Grocer, Main St" || lines[1].AmountMinor != 250000 {
+		t.Fatalf("unexpected lines: %+v", lines)
+	}
+
+	if _, err := ParseBankLinesCSV(strings.NewReader("2025-03-05,45.99\n")); err == nil {
+		t.Fatal("decimal amounts must be rejected")
+	}
+	if _, err := ParseBankLinesCSV(strings.NewReader("05/03/2025,-4599\n")); err == nil {
+		t.Fatal("non-ISO date must be rejected")
+	}
+}
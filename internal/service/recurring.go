@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var (
+	ErrRuleNotFound    = errors.New("recurring rule not found")
+	ErrInvalidRule     = errors.New("invalid recurring rule")
+	ErrInvalidSchedule = errors.New("invalid recurrence schedule")
+)
+
+// Schedule is a small RRULE subset. All dates are UTC calendar days.
+//
+//   - monthly: every Interval months on DayOfMonth, clamped to the month's
+//     last day (31 books on Feb 28/29, Apr 30, ...)
+//   - weekly: every Interval weeks on Start's weekday
+//   - last_business_day: every Interval months on the last Monday–Friday
+//     of the month (no holiday calendar)
+type Schedule struct {
+	Frequency  string
+	Interval   int
+	DayOfMonth int // monthly only; 0 = Start's day
+	Start      time.Time
+}
+
+func (s Schedule) Validate() error {
+	if s.Interval < 1 || s.Start.IsZero() {
+		return ErrInvalidSchedule
+	}
+	switch s.Frequency {
+	case "monthly":
+		if s.DayOfMonth < 0 || s.DayOfMonth > 31 {
+			return ErrInvalidSchedule
+		}
+	case "weekly", "last_business_day":
+	default:
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// occurrence returns the n-th (0-based) candidate date. Candidates grow
+// strictly with n; the first may fall before Start.
+func (s Schedule) occurrence(n int) time.Time {
+	start := dayOf(s.Start)
+	if s.Frequency == "weekly" {
+		return start.AddDate(0, 0, 7*s.Interval*n)
+	}
+	first := time.Date(start.Year(), start.Month()+time.Month(n*s.Interval), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+	if s.Frequency == "last_business_day" {
+		for last.Weekday() == time.Saturday || last.Weekday() == time.Sunday {
+			last = last.AddDate(0, 0, -1)
+		}
+		return last
+	}
+	dom := s.DayOfMonth
+	if dom == 0 {
+		dom = start.Day()
+	}
+	if dom > last.Day() {
+		dom = last.Day()
+	}
+	return first.AddDate(0, 0, dom-1)
+}
+
+// Next returns the first occurrence strictly after the given day.
+func (s Schedule) Next(after time.Time) time.Time {
+	after = dayOf(after)
+	start := dayOf(s.Start)
+	for n := 0; ; n++ {
+		d := s.occurrence(n)
+		if !d.Before(start) && d.After(after) {
+			return d
+		}
+	}
+}
+
+// OnOrAfter returns the first occurrence on or after day.
+func (s Schedule) OnOrAfter(day time.Time) time.Time {
+	return s.Next(dayOf(day).AddDate(0, 0, -1))
+}
+
+func dayOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// RecurringService manages recurring rules and books their occurrences
+// through the TransactionService.
+type RecurringService struct {
+	q     *sqlc.Queries
+	txSvc *TransactionService
+}
+
+func NewRecurringService(q *sqlc.Queries, txSvc *TransactionService) *RecurringService {
+	return &RecurringService{q: q, txSvc: txSvc}
+}
+
+type RuleDTO struct {
+	ID          int64     `json:"id"`
+	Kind        string    `json:"kind"`
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id,omitempty"`
+	AmountMinor int64     `json:"amount_minor"`
+	Currency    string    `json:"currency"`
+	Note        string    `json:"note,omitempty"`
+	Frequency   string    `json:"frequency"`
+	Interval    int32     `json:"interval"`
+	DayOfMonth  int32     `json:"day_of_month,omitempty"`
+	StartDate   string    `json:"start_date"`
+	EndDate     string    `json:"end_date,omitempty"`
+	NextRunDate string    `json:"next_run_date"`
+	Paused      bool      `json:"paused"`
+	LastError   string    `json:"last_error,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func toRuleDTO(r sqlc.RecurringRule) RuleDTO {
+	dto := RuleDTO{
+		ID:          r.ID,
+		Kind:        r.Kind,
+		AccountID:   r.AccountID,
+		ToAccountID: r.ToAccountID.Int64,
+		AmountMinor: r.AmountMinor,
+		Currency:    r.Currency,
+		Note:        r.Note.String,
+		Frequency:   r.Frequency,
+		Interval:    r.IntervalCount,
+		DayOfMonth:  r.DayOfMonth.Int32,
+		StartDate:   r.StartDate.Format("2006-01-02"),
+		NextRunDate: r.NextRunDate.Format("2006-01-02"),
+		Paused:      r.Paused,
+		LastError:   r.LastError.String,
+		CreatedAt:   r.CreatedAt,
+		UpdatedAt:   r.UpdatedAt,
+	}
+	if r.EndDate.Valid {
+		dto.EndDate = r.EndDate.Time.Format("2006-01-02")
+	}
+	return dto
+}
+
+func scheduleOf(r sqlc.RecurringRule) Schedule {
+	return Schedule{
+		Frequency:  r.Frequency,
+		Interval:   int(r.IntervalCount),
+		DayOfMonth: int(r.DayOfMonth.Int32),
+		Start:      r.StartDate,
+	}
+}
+
+type RuleInput struct {
+	UserID      int64
+	Kind        string // "create" (income/expense) or "transfer"
+	AccountID   int64  // the account for create; the source for transfer
+	ToAccountID int64  // transfer only
+	AmountMinor int64
+	Currency    string
+	Note        string
+	Schedule    Schedule
+	EndDate     *time.Time
+}
+
+// Create stores a rule. Occurrences before today are never booked: the
+// first run is the first occurrence on or after max(start, today).
+func (s *RecurringService) Create(ctx context.Context, in RuleInput, today time.Time) (RuleDTO, error) {
+	if err := s.validate(ctx, in); err != nil {
+		return RuleDTO{}, err
+	}
+	var toAcc pgtype.Int8
+	if in.Kind == "transfer" {
+		toAcc = pgtype.Int8{Int64: in.ToAccountID, Valid: true}
+	}
+	r, err := s.q.CreateRecurringRule(ctx, sqlc.CreateRecurringRuleParams{
+		UserID:        in.UserID,
+		Kind:          in.Kind,
+		AccountID:     in.AccountID,
+		ToAccountID:   toAcc,
+		AmountMinor:   in.AmountMinor,
+		Currency:      in.Currency,
+		Note:          pgtype.Text{String: in.Note, Valid: in.Note != ""},
+		Frequency:     in.Schedule.Frequency,
+		IntervalCount: int32(in.Schedule.Interval),
+		DayOfMonth:    dayOfMonthParam(in.Schedule),
+		StartDate:     dayOf(in.Schedule.Start),
+		EndDate:       endDateParam(in.EndDate),
+		NextRunDate:   firstRun(in.Schedule, today),
+	})
+	if err != nil {
+		return RuleDTO{}, err
+	}
+	return toRuleDTO(r), nil
+}
+
+func (s *RecurringService) validate(ctx context.Context, in RuleInput) error {
+	if err := in.Schedule.Validate(); err != nil {
+		return err
+	}
+	if in.EndDate != nil && dayOf(*in.EndDate).Before(dayOf(in.Schedule.Start)) {
+		return fmt.Errorf("%w: end_date is before start_date", ErrInvalidSchedule)
+	}
+	switch in.Kind {
+	case "create":
+		if in.AmountMinor == 0 {
+			return ErrZeroAmount
+		}
+	case "transfer":
+		if in.AmountMinor <= 0 || in.ToAccountID == 0 || in.ToAccountID == in.AccountID {
+			return fmt.Errorf("%w: a transfer needs a positive amount and two different accounts", ErrInvalidRule)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be create or transfer", ErrInvalidRule)
+	}
+	ids := []int64{in.AccountID}
+	if in.Kind == "transfer" {
+		ids = append(ids, in.ToAccountID)
+	}
+	for _, id := range ids {
+		acc, err := s.q.GetAccount(ctx, id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrAccountNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if acc.UserID != in.UserID {
+			return ErrAccountNotFound
+		}
+		if acc.Currency != in.Currency {
+			return ErrCurrencyMismatch
+		}
+	}
+	return nil
+}
+
+func (s *RecurringService) Get(ctx context.Context, userID, id int64) (RuleDTO, error) {
+	r, err := s.get(ctx, userID, id)
+	if err != nil {
+		return RuleDTO{}, err
+	}
+	return toRuleDTO(r), nil
+}
+
+func (s *RecurringService) get(ctx context.Context, userID, id int64) (sqlc.RecurringRule, error) {
+	r, err := s.q.GetRecurringRuleForUser(ctx, sqlc.GetRecurringRuleForUserParams{ID: id, UserID: userID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sqlc.RecurringRule{}, ErrRuleNotFound
+	}
+	return r, err
+}
+
+func (s *RecurringService) List(ctx context.Context, userID int64) ([]RuleDTO, error) {
+	rows, err := s.q.ListRecurringRulesByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RuleDTO, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, toRuleDTO(r))
+	}
+	return out, nil
+}
+
+// Update replaces the amount, note, schedule and end date. Kind and
+// accounts are fixed; delete and recreate the rule to change them. The next
+// run is recomputed from the new schedule.
+func (s *RecurringService) Update(ctx context.Context, id int64, in RuleInput, today time.Time) (RuleDTO, error) {
+	cur, err := s.get(ctx, in.UserID, id)
+	if err != nil {
+		return RuleDTO{}, err
+	}
+	in.Kind, in.AccountID, in.ToAccountID, in.Currency = cur.Kind, cur.AccountID, cur.ToAccountID.Int64, cur.Currency
+	if err := s.validate(ctx, in); err != nil {
+		return RuleDTO{}, err
+	}
+	r, err := s.q.UpdateRecurringRule(ctx, sqlc.UpdateRecurringRuleParams{
+		AmountMinor:   in.AmountMinor,
+		Note:          pgtype.Text{String: in.Note, Valid: in.Note != ""},
+		Frequency:     in.Schedule.Frequency,
+		IntervalCount: int32(in.Schedule.Interval),
+		DayOfMonth:    dayOfMonthParam(in.Schedule),
+		StartDate:     dayOf(in.Schedule.Start),
+		EndDate:       endDateParam(in.EndDate),
+		NextRunDate:   firstRun(in.Schedule, today),
+		ID:            id,
+		UserID:        in.UserID,
+	})
+	if err != nil {
+		return RuleDTO{}, err
+	}
+	return toRuleDTO(r), nil
+}
+
+func (s *RecurringService) Delete(ctx context.Context, userID, id int64) error {
+	n, err := s.q.DeleteRecurringRule(ctx, sqlc.DeleteRecurringRuleParams{ID: id, UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrRuleNotFound
+	}
+	return nil
+}
+
+// SetPaused pauses or resumes a rule. Resuming skips every occurrence that
+// fell due while the rule was paused.
+func (s *RecurringService) SetPaused(ctx context.Context, userID, id int64, paused bool, today time.Time) (RuleDTO, error) {
+	cur, err := s.get(ctx, userID, id)
+	if err != nil {
+		return RuleDTO{}, err
+	}
+	next := cur.NextRunDate
+	if !paused && cur.Paused {
+		next = firstRun(scheduleOf(cur), today)
+	}
+	return s.setState(ctx, cur, paused, next)
+}
+
+// Skip drops the rule's next occurrence without booking it.
+func (s *RecurringService) Skip(ctx context.Context, userID, id int64) (RuleDTO, error) {
+	cur, err := s.get(ctx, userID, id)
+	if err != nil {
+		return RuleDTO{}, err
+	}
+	return s.setState(ctx, cur, cur.Paused, scheduleOf(cur).Next(cur.NextRunDate))
+}
+
+func (s *RecurringService) setState(ctx context.Context, cur sqlc.RecurringRule, paused bool, next time.Time) (RuleDTO, error) {
+	r, err := s.q.SetRecurringRuleState(ctx, sqlc.SetRecurringRuleStateParams{
+		Paused: paused, NextRunDate: next, ID: cur.ID, UserID: cur.UserID,
+	})
+	if err != nil {
+		return RuleDTO{}, err
+	}
+	return toRuleDTO(r), nil
+}
+
+// RunDue books every occurrence due on or before today, catching up on any
+// runs the worker missed. Each occurrence is booked with the idempotency key
+// "recurring:<rule>:<date>", so rerunning after a crash between booking and
+// advancing replays the booked transaction instead of creating another.
+// An occurrence the ledger rejects (insufficient funds, inactive account, ...)
+// is recorded in last_error and skipped; anything else aborts the run so the
+// task is retried.
+func (s *RecurringService) RunDue(ctx context.Context, today time.Time) (int, error) {
+	today = dayOf(today)
+	rules, err := s.q.ListDueRecurringRules(ctx, today)
+	if err != nil {
+		return 0, err
+	}
+	booked := 0
+	for _, r := range rules {
+		sched := scheduleOf(r)
+		for date := r.NextRunDate; !date.After(today); {
+			if r.EndDate.Valid && date.After(r.EndDate.Time) {
+				break
+			}
+			var lastErr pgtype.Text
+			switch err := s.book(ctx, r, date); {
+			case err == nil:
+				booked++
+			case isRejected(err):
+				lastErr = pgtype.Text{String: fmt.Sprintf("%s: %v", date.Format("2006-01-02"), err), Valid: true}
+			default:
+				return booked, fmt.Errorf("rule %d on %s: %w", r.ID, date.Format("2006-01-02"), err)
+			}
+			next := sched.Next(date)
+			n, err := s.q.AdvanceRecurringRule(ctx, sqlc.AdvanceRecurringRuleParams{
+				NextRunDate: next, LastError: lastErr, ID: r.ID, CurrentRunDate: date,
+			})
+			if err != nil {
+				return booked, err
+			}
+			if n == 0 {
+				break // another worker advanced (or the user edited) the rule
+			}
+			date = next
+		}
+	}
+	return booked, nil
+}
+
+func (s *RecurringService) book(ctx context.Context, r sqlc.RecurringRule, date time.Time) error {
+	key := fmt.Sprintf("recurring:%d:%s", r.ID, date.Format("2006-01-02"))
+	var err error
+	if r.Kind == "transfer" {
+		_, err = s.txSvc.Transfer(ctx, TransferInput{
+			UserID:         r.UserID,
+			FromAccountID:  r.AccountID,
+			ToAccountID:    r.ToAccountID.Int64,
+			AmountMinor:    r.AmountMinor,
+			Currency:       r.Currency,
+			OccurredAt:     date,
+			Note:           r.Note.String,
+			IdempotencyKey: key,
+		})
+	} else {
+		_, err = s.txSvc.Create(ctx, CreateInput{
+			UserID:         r.UserID,
+			AccountID:      r.AccountID,
+			AmountMinor:    r.AmountMinor,
+			Currency:       r.Currency,
+			OccurredAt:     date,
+			Note:           r.Note.String,
+			IdempotencyKey: key,
+		})
+	}
+	// The key was used with a different payload: the occurrence was booked
+	// before the rule was edited. It is booked either way.
+	if errors.Is(err, ErrIdempotencyConflict) {
+		return nil
+	}
+	return err
+}
+
+// isRejected reports whether the ledger refused the posting for a reason
+// retrying will not fix.
+func isRejected(err error) bool {
+	for _, target := range []error{
+		ErrAccountNotFound, ErrForbidden, ErrAccountInactive, ErrCurrencyMismatch,
+		ErrUnbalanced, ErrZeroAmount, ErrInsufficientFunds, ErrPeriodClosed,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstRun(s Schedule, today time.Time) time.Time {
+	from := dayOf(s.Start)
+	if t := dayOf(today); t.After(from) {
+		from = t
+	}
+	return s.OnOrAfter(from)
+}
+
+func dayOfMonthParam(s Schedule) pgtype.Int4 {
+	if s.Frequency != "monthly" {
+		return pgtype.Int4{}
+	}
+	dom := s.DayOfMonth
+	if dom == 0 {
+		dom = dayOf(s.Start).Day()
+	}
+	return pgtype.Int4{Int32: int32(dom), Valid: true}
+}
+
+func endDateParam(end *time.Time) pgtype.Date {
+	if end == nil {
+		return pgtype.Date{}
+	}
+	return pgtype.Date{Time: dayOf(*end), Valid: true}
+}
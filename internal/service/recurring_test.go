@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestScheduleNext(t *testing.T) {
+	cases := []struct {
+		name  string
+		sched Schedule
+		after string
+		want  string
+	}{
+		{"monthly same month", Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 15, Start: date("2026-01-01")}, "2026-01-10", "2026-01-15"},
+		{"monthly rolls over", Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 15, Start: date("2026-01-01")}, "2026-01-15", "2026-02-15"},
+		{"monthly clamps to short month", Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 31, Start: date("2026-01-31")}, "2026-01-31", "2026-02-28"},
+		{"monthly clamps to leap day", Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 31, Start: date("2028-01-31")}, "2028-01-31", "2028-02-29"},
+		{"monthly clamp does not stick", Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 31, Start: date("2026-01-31")}, "2026-02-28", "2026-03-31"},
+		{"monthly defaults to start day", Schedule{Frequency: "monthly", Interval: 1, Start: date("2026-01-20")}, "2026-01-20", "2026-02-20"},
+		{"monthly skips day before start", Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 5, Start: date("2026-01-20")}, "2025-12-31", "2026-02-05"},
+		{"every three months", Schedule{Frequency: "monthly", Interval: 3, DayOfMonth: 1, Start: date("2026-01-01")}, "2026-01-01", "2026-04-01"},
+		{"monthly across year end", Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 10, Start: date("2026-11-10")}, "2026-12-10", "2027-01-10"},
+		{"every two weeks", Schedule{Frequency: "weekly", Interval: 2, Start: date("2026-03-02")}, "2026-03-02", "2026-03-16"},
+		{"every two weeks mid-cycle", Schedule{Frequency: "weekly", Interval: 2, Start: date("2026-03-02")}, "2026-03-20", "2026-03-30"},
+		{"weekly before start", Schedule{Frequency: "weekly", Interval: 1, Start: date("2026-03-02")}, "2026-01-01", "2026-03-02"},
+		{"last business day weekday", Schedule{Frequency: "last_business_day", Interval: 1, Start: date("2026-01-01")}, "2026-03-01", "2026-03-31"},
+		{"last business day skips saturday", Schedule{Frequency: "last_business_day", Interval: 1, Start: date("2026-01-01")}, "2026-01-01", "2026-01-30"},
+		{"last business day skips sunday", Schedule{Frequency: "last_business_day", Interval: 1, Start: date("2026-01-01")}, "2026-05-01", "2026-05-29"},
+		{"last business day next month", Schedule{Frequency: "last_business_day", Interval: 1, Start: date("2026-01-01")}, "2026-05-29", "2026-06-30"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.sched.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			got := c.sched.Next(date(c.after)).Format("2006-01-02")
+			if got != c.want {
+				t.Fatalf("Next(%s) = %s, want %s", c.after, got, c.want)
+			}
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	bad := []Schedule{
+		{Frequency: "daily", Interval: 1, Start: date("2026-01-01")},
+		{Frequency: "monthly", Interval: 0, Start: date("2026-01-01")},
+		{Frequency: "monthly", Interval: 1, DayOfMonth: 32, Start: date("2026-01-01")},
+		{Frequency: "weekly", Interval: 1},
+	}
+	for _, s := range bad {
+		if err := s.Validate(); err == nil {
+			t.Fatalf("Validate(%+v) = nil, want error", s)
+		}
+	}
+}
+
+func TestFirstRunNeverBeforeToday(t *testing.T) {
+	s := Schedule{Frequency: "monthly", Interval: 1, DayOfMonth: 1, Start: date("2020-01-01")}
+	if got := firstRun(s, date("2026-03-15")).Format("2006-01-02"); got != "2026-04-01" {
+		t.Fatalf("firstRun = %s, want 2026-04-01", got)
+	}
+	if got := firstRun(s, date("2026-04-01")).Format("2006-01-02"); got != "2026-04-01" {
+		t.Fatalf("firstRun on an occurrence day = %s, want 2026-04-01", got)
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+var (
+	ErrOverRefund    = errors.New("refund exceeds the amount not yet reversed")
+	ErrRefundPosting = errors.New("posting is not part of the transaction")
+)
+
+// RefundLine reverses AmountMinor (positive) of one posting of the original.
+type RefundLine struct {
+	PostingID   int64 `json:"posting_id"`
+	AmountMinor int64 `json:"amount_minor"`
+}
+
+type RefundInput struct {
+	UserID        int64
+	TransactionID int64
+	// AmountMinor refunds this much of a transaction with one posting on a
+	// real account (income or expense), or of both sides of a two-posting
+	// transfer. Mutually exclusive with Lines.
+	AmountMinor int64
+	// Lines picks the postings and amounts to reverse. A currency left
+	// unbalanced is offset on the original's external posting.
+	Lines          []RefundLine
+	Note           string // optional; defaults to "refund of transaction N"
+	IdempotencyKey string
+}
+
+// reversalLeg is one planned posting of a reversal.
+type reversalLeg struct {
+	PostingID   int64 // the original posting it negates
+	AccountID   int64
+	AmountMinor int64
+	Currency    string
+	CategoryID  pgtype.Int8
+}
+
+// Refund reverses part of a transaction, e.g. one returned item of an
+// order. Each refund is a reversal of the original; together they can never
+// negate more than the original posted.
+func (s *TransactionService) Refund(ctx context.Context, in RefundInput) (TransactionDTO, error) {
+	if (in.AmountMinor == 0) == (len(in.Lines) == 0) {
+		return TransactionDTO{}, fmt.Errorf("%w: give either amount_minor or lines", ErrZeroAmount)
+	}
+	if in.AmountMinor < 0 {
+		return TransactionDTO{}, fmt.Errorf("%w: amount_minor must be positive", ErrZeroAmount)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return TransactionDTO{}, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+	qtx := s.q.WithTx(tx)
+
+	hash := refundHash(in)
+	cached, err := claimIdempotency(ctx, qtx, in.UserID, in.IdempotencyKey, hash)
+	if err != nil {
+		return TransactionDTO{}, err
+	}
+	if cached != nil {
+		return *cached, nil
+	}
+
+	note := in.Note
+	if note == "" {
+		note = fmt.Sprintf("refund of transaction %d", in.TransactionID)
+	}
+	now := time.Now().UTC()
+	dto, orig, err := postReversal(ctx, qtx, in.UserID, in.TransactionID, in.AmountMinor, in.Lines, note, now)
+	if err != nil {
+		return TransactionDTO{}, err
+	}
+
+	if err := emitTransactionEvent(ctx, qtx, "TransactionRefunded", dto, nil); err != nil {
+		return TransactionDTO{}, err
+	}
+	if err := markIdempotency(ctx, qtx, in.UserID, in.IdempotencyKey, hash, dto); err != nil {
+		return TransactionDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return TransactionDTO{}, err
+	}
+	s.invalidate(ctx, postingLegs(dto.Postings), orig.OccurredAt, now)
+	return dto, nil
+}
+
+// amountLines expands a plain refund amount: the single posting on a real
+// account (the external side balances it), or both postings of a
+// same-currency two-posting transfer.
+func amountLines(ps []sqlc.ListReversiblePostingsRow, amount int64) ([]RefundLine, error) {
+	var normal []int64
+	for _, p := range ps {
+		if p.AccountKind == "normal" {
+			normal = append(normal, p.ID)
+		}
+	}
+	switch {
+	case len(normal) == 1:
+		return []RefundLine{{PostingID: normal[0], AmountMinor: amount}}, nil
+	case len(ps) == 2 && len(normal) == 2 && ps[0].Currency == ps[1].Currency:
+		return []RefundLine{{PostingID: ps[0].ID, AmountMinor: amount}, {PostingID: ps[1].ID, AmountMinor: amount}}, nil
+	}
+	return nil, fmt.Errorf("%w: amount_minor is ambiguous for this transaction; pass lines", ErrUnbalanced)
+}
+
+// planReversal works out the postings of a reversal of ps. Nil lines mean
+// everything not yet reversed. Otherwise each line negates part of its
+// posting; a currency the lines leave unbalanced is offset on the single
+// external posting in that currency. No posting may end up reversed beyond
+// its original amount.
+func planReversal(ps []sqlc.ListReversiblePostingsRow, lines []RefundLine) ([]reversalLeg, error) {
+	// remaining has the sign of the original posting.
+	remaining := make(map[int64]int64, len(ps))
+	kind := make(map[int64]string, len(ps))
+	for _, p := range ps {
+		remaining[p.ID] = p.AmountMinor + p.ReversedMinor
+		kind[p.ID] = p.AccountKind
+	}
+
+	neg := make(map[int64]int64)
+	if lines == nil {
+		for _, p := range ps {
+			if r := remaining[p.ID]; r != 0 {
+				neg[p.ID] = -r
+			}
+		}
+		if len(neg) == 0 {
+			return nil, ErrAlreadyReversed
+		}
+	} else {
+		for _, l := range lines {
+			// Lines name the user's own postings; the external and FX
+			// sides follow from them.
+			r, ok := remaining[l.PostingID]
+			if !ok || kind[l.PostingID] != "normal" {
+				return nil, ErrRefundPosting
+			}
+			if l.AmountMinor <= 0 {
+				return nil, ErrZeroAmount
+			}
+			if r < 0 {
+				neg[l.PostingID] += l.AmountMinor
+			} else {
+				neg[l.PostingID] -= l.AmountMinor
+			}
+		}
+		sums := make(map[string]int64)
+		for _, p := range ps {
+			sums[p.Currency] += neg[p.ID]
+		}
+		for cur, sum := range sums {
+			if sum == 0 {
+				continue
+			}
+			var ext []int64
+			for _, p := range ps {
+				if p.AccountKind == "external" && p.Currency == cur {
+					ext = append(ext, p.ID)
+				}
+			}
+			if len(ext) != 1 {
+				return nil, fmt.Errorf("%w: refund lines in %s must balance", ErrUnbalanced, cur)
+			}
+			neg[ext[0]] -= sum
+		}
+	}
+
+	out := make([]reversalLeg, 0, len(neg))
+	for _, p := range ps {
+		n := neg[p.ID]
+		if n == 0 {
+			continue
+		}
+		// A reversal must run against the original and stay within it.
+		r := remaining[p.ID]
+		if (r > 0 && (n > 0 || -n > r)) || (r < 0 && (n < 0 || n > -r)) || r == 0 {
+			return nil, ErrOverRefund
+		}
+		out = append(out, reversalLeg{
+			PostingID: p.ID, AccountID: p.AccountID, AmountMinor: n, Currency: p.Currency, CategoryID: p.CategoryID,
+		})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: the refund lines cancel out", ErrZeroAmount)
+	}
+	return out, nil
+}
+
+func refundHash(in RefundInput) string {
+	payload := struct {
+		UserID        int64        `json:"user_id"`
+		Op            string       `json:"op"`
+		TransactionID int64        `json:"transaction_id"`
+		AmountMinor   int64        `json:"amount_minor,omitempty"`
+		Lines         []RefundLine `json:"lines,omitempty"`
+		Note          string       `json:"note,omitempty"`
+	}{in.UserID, "refund", in.TransactionID, in.AmountMinor, in.Lines, in.Note}
+	b, _ := json.Marshal(payload)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+// A split expense: 30.00 of food and 20.00 of household from account 1,
+// balanced by the external account 9.
+func splitExpense(reversedFood, reversedHouse int64) []sqlc.ListReversiblePostingsRow {
+	return []sqlc.ListReversiblePostingsRow{
+		{ID: 11, AccountID: 1, AmountMinor: -3000, Currency: "USD", CategoryID: pgtype.Int8{Int64: 5, Valid: true}, AccountKind: "normal", ReversedMinor: reversedFood},
+		{ID: 12, AccountID: 1, AmountMinor: -2000, Currency: "USD", CategoryID: pgtype.Int8{Int64: 6, Valid: true}, AccountKind: "normal", ReversedMinor: reversedHouse},
+		{ID: 13, AccountID: 9, AmountMinor: 5000, Currency: "USD", AccountKind: "external", ReversedMinor: -reversedFood - reversedHouse},
+	}
+}
+
+func TestPlanReversalOffsetsOnExternalPosting(t *testing.T) {
+	got, err := planReversal(splitExpense(0, 0), []RefundLine{{PostingID: 12, AmountMinor: 500}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []reversalLeg{
+		{PostingID: 12, AccountID: 1, AmountMinor: 500, Currency: "USD", CategoryID: pgtype.Int8{Int64: 6, Valid: true}},
+		{PostingID: 13, AccountID: 9, AmountMinor: -500, Currency: "USD"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestPlanReversalFullReversesWhatIsLeft(t *testing.T) {
+	got, err := planReversal(splitExpense(1000, 2000), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Household is fully refunded already; food has 20.00 left.
+	if len(got) != 2 || got[0].PostingID != 11 || got[0].AmountMinor != 2000 || got[1].PostingID != 13 || got[1].AmountMinor != -2000 {
+		t.Fatalf("got %+v", got)
+	}
+
+	if _, err := planReversal(splitExpense(3000, 2000), nil); !errors.Is(err, ErrAlreadyReversed) {
+		t.Fatalf("nothing left: want ErrAlreadyReversed, got %v", err)
+	}
+}
+
+func TestPlanReversalRejects(t *testing.T) {
+	for name, tc := range map[string]struct {
+		ps    []sqlc.ListReversiblePostingsRow
+		lines []RefundLine
+		want  error
+	}{
+		"over the posting":      {splitExpense(0, 0), []RefundLine{{PostingID: 12, AmountMinor: 2001}}, ErrOverRefund},
+		"over what is left":     {splitExpense(0, 1500), []RefundLine{{PostingID: 12, AmountMinor: 600}}, ErrOverRefund},
+		"repeated lines add up": {splitExpense(0, 0), []RefundLine{{PostingID: 12, AmountMinor: 1500}, {PostingID: 12, AmountMinor: 600}}, ErrOverRefund},
+		"foreign posting":       {splitExpense(0, 0), []RefundLine{{PostingID: 99, AmountMinor: 1}}, ErrRefundPosting},
+		"non-positive amount":   {splitExpense(0, 0), []RefundLine{{PostingID: 11, AmountMinor: 0}}, ErrZeroAmount},
+		"external posting":      {splitExpense(0, 0), []RefundLine{{PostingID: 13, AmountMinor: 500}}, ErrRefundPosting},
+		"no lines":              {splitExpense(0, 0), []RefundLine{}, ErrZeroAmount},
+		"unbalanced without external": {
+			[]sqlc.ListReversiblePostingsRow{
+				{ID: 1, AccountID: 1, AmountMinor: -100, Currency: "USD", AccountKind: "normal"},
+				{ID: 2, AccountID: 2, AmountMinor: 100, Currency: "USD", AccountKind: "normal"},
+			},
+			[]RefundLine{{PostingID: 1, AmountMinor: 40}},
+			ErrUnbalanced,
+		},
+	} {
+		if _, err := planReversal(tc.ps, tc.lines); !errors.Is(err, tc.want) {
+			t.Errorf("%s: want %v, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestAmountLines(t *testing.T) {
+	expense := []sqlc.ListReversiblePostingsRow{
+		{ID: 1, AmountMinor: -500, Currency: "USD", AccountKind: "normal"},
+		{ID: 2, AmountMinor: 500, Currency: "USD", AccountKind: "external"},
+	}
+	if got, err := amountLines(expense, 200); err != nil || !reflect.DeepEqual(got, []RefundLine{{PostingID: 1, AmountMinor: 200}}) {
+		t.Fatalf("expense: %+v, %v", got, err)
+	}
+	transfer := []sqlc.ListReversiblePostingsRow{
+		{ID: 1, AmountMinor: -500, Currency: "USD", AccountKind: "normal"},
+		{ID: 2, AmountMinor: 500, Currency: "USD", AccountKind: "normal"},
+	}
+	if got, err := amountLines(transfer, 200); err != nil || len(got) != 2 {
+		t.Fatalf("transfer: %+v, %v", got, err)
+	}
+	if _, err := amountLines(splitExpense(0, 0), 200); !errors.Is(err, ErrUnbalanced) {
+		t.Fatalf("split expense is ambiguous, got %v", err)
+	}
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+// ErrLedgerImbalance means a report's figures do not net to zero. The
+// postings trigger makes that impossible, so it signals corruption, not bad
+// input.
+var ErrLedgerImbalance = errors.New("ledger does not balance")
+
+var ErrReportRange = errors.New("invalid report range")
+
+// MaxStatementMonths bounds the month columns of one income statement.
+const MaxStatementMonths = 120
+
+// ReportService builds ledger-wide financial reports.
+type ReportService struct{ q *sqlc.Queries }
+
+func NewReportService(q *sqlc.Queries) *ReportService { return &ReportService{q: q} }
+
+// TrialBalanceLine is one account's activity in one currency. Debit and
+// credit are turnover totals; Balance is their difference, debit-positive.
+type TrialBalanceLine struct {
+	AccountID     int64  `json:"account_id"`
+	Name          string `json:"name"`
+	Kind          string `json:"kind"`
+	Type          string `json:"type"`
+	NormalBalance string `json:"normal_balance"`
+	ParentID      int64  `json:"parent_id,omitempty"`
+	Currency      string `json:"currency"`
+	Debit         int64  `json:"debit_minor"`
+	Credit        int64  `json:"credit_minor"`
+	Balance       int64  `json:"balance_minor"`
+}
+
+// TrialBalanceTotal sums every line of one currency. Debits equal credits
+// and Balance is zero in a consistent ledger.
+type TrialBalanceTotal struct {
+	Currency string `json:"currency"`
+	Debit    int64  `json:"debit_minor"`
+	Credit   int64  `json:"credit_minor"`
+	Balance  int64  `json:"balance_minor"`
+	Balanced bool   `json:"balanced"`
+}
+
+type TrialBalance struct {
+	AsOf     time.Time           `json:"as_of"` // entries dated before this instant
+	Accounts []TrialBalanceLine  `json:"accounts"`
+	Totals   []TrialBalanceTotal `json:"totals"`
+}
+
+// TrialBalance lists every account of the user with its turnover and
+// closing balance over entries with occurred_at before asOf. Because every
+// transaction's postings sum to zero per currency, so does the report; a
+// currency that does not is returned with balanced=false alongside
+// ErrLedgerImbalance.
+func (s *ReportService) TrialBalance(ctx context.Context, userID int64, asOf time.Time) (TrialBalance, error) {
+	rows, err := s.q.TrialBalance(ctx, sqlc.TrialBalanceParams{Before: asOf, UserID: userID})
+	if err != nil {
+		return TrialBalance{}, err
+	}
+	tb := TrialBalance{AsOf: asOf.UTC(), Accounts: make([]TrialBalanceLine, 0, len(rows))}
+	for _, r := range rows {
+		tb.Accounts = append(tb.Accounts, TrialBalanceLine{
+			AccountID:     r.AccountID,
+			Name:          r.Name,
+			Kind:          r.Kind,
+			Type:          r.Type,
+			NormalBalance: NormalBalance(r.Type),
+			ParentID:      r.ParentID.Int64,
+			Currency:      r.Currency,
+			Debit:         r.DebitMinor,
+			Credit:        r.CreditMinor,
+			Balance:       r.BalanceMinor,
+		})
+	}
+	tb.Totals, err = trialBalanceTotals(tb.Accounts)
+	return tb, err
+}
+
+// trialBalanceTotals sums the lines per currency, in order of first
+// appearance, and reports any currency that does not net to zero.
+func trialBalanceTotals(lines []TrialBalanceLine) ([]TrialBalanceTotal, error) {
+	idx := make(map[string]int)
+	totals := []TrialBalanceTotal{}
+	for _, l := range lines {
+		i, ok := idx[l.Currency]
+		if !ok {
+			i = len(totals)
+			idx[l.Currency] = i
+			totals = append(totals, TrialBalanceTotal{Currency: l.Currency})
+		}
+		totals[i].Debit += l.Debit
+		totals[i].Credit += l.Credit
+		totals[i].Balance += l.Balance
+	}
+	var err error
+	for i := range totals {
+		t := &totals[i]
+		t.Balanced = t.Balance == 0 && t.Debit == t.Credit
+		if !t.Balanced && err == nil {
+			err = fmt.Errorf("%w: %s is off by %d", ErrLedgerImbalance, t.Currency, t.Balance)
+		}
+	}
+	return totals, err
+}
+
+// StatementLine is one account on a balance sheet, in its normal sign. The
+// computed current-earnings line has no account.
+type StatementLine struct {
+	AccountID int64  `json:"account_id,omitempty"`
+	Name      string `json:"name"`
+	ParentID  int64  `json:"parent_id,omitempty"`
+	Amount    int64  `json:"amount_minor"`
+}
+
+// BalanceSheetCurrency is the balance sheet of one currency. Equity ends with
+// the current earnings (income less expenses to date), which is what makes
+// assets equal liabilities plus equity.
+type BalanceSheetCurrency struct {
+	Currency         string          `json:"currency"`
+	Assets           []StatementLine `json:"assets"`
+	Liabilities      []StatementLine `json:"liabilities"`
+	Equity           []StatementLine `json:"equity"`
+	TotalAssets      int64           `json:"total_assets_minor"`
+	TotalLiabilities int64           `json:"total_liabilities_minor"`
+	TotalEquity      int64           `json:"total_equity_minor"`
+	Balanced         bool            `json:"balanced"`
+}
+
+type BalanceSheet struct {
+	AsOf       time.Time              `json:"as_of"` // entries dated before this instant
+	Currencies []BalanceSheetCurrency `json:"currencies"`
+}
+
+// CurrentEarnings names the equity line carrying income less expenses.
+const CurrentEarnings = "Current earnings"
+
+// BalanceSheet reports assets, liabilities and equity per currency over
+// entries with occurred_at before asOf. A currency where assets do not equal
+// liabilities plus equity is returned with balanced=false alongside
+// ErrLedgerImbalance.
+func (s *ReportService) BalanceSheet(ctx context.Context, userID int64, asOf time.Time) (BalanceSheet, error) {
+	tb, err := s.TrialBalance(ctx, userID, asOf)
+	if err != nil && !errors.Is(err, ErrLedgerImbalance) {
+		return BalanceSheet{}, err
+	}
+	return buildBalanceSheet(tb.AsOf, tb.Accounts)
+}
+
+// buildBalanceSheet groups trial balance lines by currency, in order of first
+// appearance, folding income and expense accounts into current earnings.
+func buildBalanceSheet(asOf time.Time, lines []TrialBalanceLine) (BalanceSheet, error) {
+	bs := BalanceSheet{AsOf: asOf, Currencies: []BalanceSheetCurrency{}}
+	idx := make(map[string]int)
+	earnings := make(map[string]int64)
+	for _, l := range lines {
+		i, ok := idx[l.Currency]
+		if !ok {
+			i = len(bs.Currencies)
+			idx[l.Currency] = i
+			bs.Currencies = append(bs.Currencies, BalanceSheetCurrency{
+				Currency: l.Currency, Assets: []StatementLine{}, Liabilities: []StatementLine{}, Equity: []StatementLine{},
+			})
+		}
+		c := &bs.Currencies[i]
+		sl := StatementLine{AccountID: l.AccountID, Name: l.Name, ParentID: l.ParentID, Amount: NormalSign(l.Type, l.Balance)}
+		switch l.Type {
+		case AccountAsset:
+			c.Assets = append(c.Assets, sl)
+			c.TotalAssets += sl.Amount
+		case AccountLiability:
+			c.Liabilities = append(c.Liabilities, sl)
+			c.TotalLiabilities += sl.Amount
+		case AccountEquity:
+			c.Equity = append(c.Equity, sl)
+			c.TotalEquity += sl.Amount
+		default:
+			earnings[l.Currency] -= l.Balance
+		}
+	}
+	var err error
+	for i := range bs.Currencies {
+		c := &bs.Currencies[i]
+		if e := earnings[c.Currency]; e != 0 {
+			c.Equity = append(c.Equity, StatementLine{Name: CurrentEarnings, Amount: e})
+			c.TotalEquity += e
+		}
+		c.Balanced = c.TotalAssets == c.TotalLiabilities+c.TotalEquity
+		if !c.Balanced && err == nil {
+			err = fmt.Errorf("%w: %s assets differ from liabilities plus equity by %d",
+				ErrLedgerImbalance, c.Currency, c.TotalAssets-c.TotalLiabilities-c.TotalEquity)
+		}
+	}
+	return bs, err
+}
+
+// WriteCSV writes one row per line and per total:
+// currency, section, account_id, account, amount_minor.
+func (bs BalanceSheet) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"currency", "section", "account_id", "account", "amount_minor"})
+	for _, c := range bs.Currencies {
+		for _, sec := range []struct {
+			name  string
+			lines []StatementLine
+			total int64
+		}{
+			{"assets", c.Assets, c.TotalAssets},
+			{"liabilities", c.Liabilities, c.TotalLiabilities},
+			{"equity", c.Equity, c.TotalEquity},
+		} {
+			for _, l := range sec.lines {
+				_ = cw.Write([]string{c.Currency, sec.name, csvID(l.AccountID), l.Name, strconv.FormatInt(l.Amount, 10)})
+			}
+			_ = cw.Write([]string{c.Currency, sec.name, "", "Total " + sec.name, strconv.FormatInt(sec.total, 10)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// PeriodAmounts holds one figure per month column and their sum.
+type PeriodAmounts struct {
+	Months []int64 `json:"months_minor"`
+	Total  int64   `json:"total_minor"`
+}
+
+func newPeriodAmounts(n int) PeriodAmounts { return PeriodAmounts{Months: make([]int64, n)} }
+
+func (p *PeriodAmounts) add(month int, amount int64) {
+	p.Months[month] += amount
+	p.Total += amount
+}
+
+// IncomeStatementLine is one income or expense account, in its normal sign:
+// income earned and expenses incurred are both positive.
+type IncomeStatementLine struct {
+	AccountID int64  `json:"account_id"`
+	Name      string `json:"name"`
+	ParentID  int64  `json:"parent_id,omitempty"`
+	PeriodAmounts
+}
+
+type IncomeStatementCurrency struct {
+	Currency      string                `json:"currency"`
+	Income        []IncomeStatementLine `json:"income"`
+	Expenses      []IncomeStatementLine `json:"expenses"`
+	TotalIncome   PeriodAmounts         `json:"total_income"`
+	TotalExpenses PeriodAmounts         `json:"total_expenses"`
+	NetIncome     PeriodAmounts         `json:"net_income"`
+}
+
+type IncomeStatement struct {
+	From       time.Time                 `json:"from"`
+	To         time.Time                 `json:"to"`     // exclusive
+	Months     []string                  `json:"months"` // YYYY-MM, one per column
+	Currencies []IncomeStatementCurrency `json:"currencies"`
+}
+
+// StatementMonths lists the UTC calendar months, as YYYY-MM, that [from, to)
+// touches.
+func StatementMonths(from, to time.Time) []string {
+	var months []string
+	for m := monthOf(from); m.Before(to); m = m.AddDate(0, 1, 0) {
+		months = append(months, m.Format("2006-01"))
+	}
+	return months
+}
+
+// IncomeStatement reports income, expenses and net income per currency over
+// entries with occurred_at in [from, to), with one column per calendar month.
+func (s *ReportService) IncomeStatement(ctx context.Context, userID int64, from, to time.Time) (IncomeStatement, error) {
+	if !to.After(from) {
+		return IncomeStatement{}, fmt.Errorf("%w: to must be after from", ErrReportRange)
+	}
+	if n := len(StatementMonths(from, to)); n > MaxStatementMonths {
+		return IncomeStatement{}, fmt.Errorf("%w: %d months exceeds the limit of %d", ErrReportRange, n, MaxStatementMonths)
+	}
+	rows, err := s.q.IncomeStatement(ctx, sqlc.IncomeStatementParams{UserID: userID, FromTime: from, ToTime: to})
+	if err != nil {
+		return IncomeStatement{}, err
+	}
+	return buildIncomeStatement(from, to, rows), nil
+}
+
+// buildIncomeStatement lays out monthly rows, in the query's order, into
+// per-currency sections with month columns.
+func buildIncomeStatement(from, to time.Time, rows []sqlc.IncomeStatementRow) IncomeStatement {
+	months := StatementMonths(from, to)
+	col := make(map[string]int, len(months))
+	for i, m := range months {
+		col[m] = i
+	}
+	is := IncomeStatement{From: from.UTC(), To: to.UTC(), Months: months, Currencies: []IncomeStatementCurrency{}}
+	idx := make(map[string]int)
+	var prev *sqlc.IncomeStatementRow
+	for k, r := range rows {
+		i, ok := idx[r.Currency]
+		if !ok {
+			i = len(is.Currencies)
+			idx[r.Currency] = i
+			is.Currencies = append(is.Currencies, IncomeStatementCurrency{
+				Currency: r.Currency, Income: []IncomeStatementLine{}, Expenses: []IncomeStatementLine{},
+				TotalIncome: newPeriodAmounts(len(months)), TotalExpenses: newPeriodAmounts(len(months)), NetIncome: newPeriodAmounts(len(months)),
+			})
+		}
+		c := &is.Currencies[i]
+		lines := &c.Expenses
+		if r.Type == AccountIncome {
+			lines = &c.Income
+		}
+		// Rows of one account and currency are adjacent, so a new pair
+		// starts a new line and the current one is always the last.
+		if prev == nil || prev.AccountID != r.AccountID || prev.Currency != r.Currency {
+			*lines = append(*lines, IncomeStatementLine{
+				AccountID: r.AccountID, Name: r.Name, ParentID: r.ParentID.Int64, PeriodAmounts: newPeriodAmounts(len(months)),
+			})
+		}
+		prev = &rows[k]
+		m := col[r.Month.Format("2006-01")]
+		amount := NormalSign(r.Type, r.AmountMinor)
+		(*lines)[len(*lines)-1].add(m, amount)
+		if r.Type == AccountIncome {
+			c.TotalIncome.add(m, amount)
+			c.NetIncome.add(m, amount)
+		} else {
+			c.TotalExpenses.add(m, amount)
+			c.NetIncome.add(m, -amount)
+		}
+	}
+	return is
+}
+
+// WriteCSV writes one row per account and per total: currency, section,
+// account_id, account, then one column per month and the total.
+func (is IncomeStatement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := append([]string{"currency", "section", "account_id", "account"}, is.Months...)
+	_ = cw.Write(append(header, "total"))
+	row := func(currency, section, id, name string, p PeriodAmounts) {
+		rec := []string{currency, section, id, name}
+		for _, v := range p.Months {
+			rec = append(rec, strconv.FormatInt(v, 10))
+		}
+		_ = cw.Write(append(rec, strconv.FormatInt(p.Total, 10)))
+	}
+	for _, c := range is.Currencies {
+		for _, l := range c.Income {
+			row(c.Currency, "income", csvID(l.AccountID), l.Name, l.PeriodAmounts)
+		}
+		row(c.Currency, "income", "", "Total income", c.TotalIncome)
+		for _, l := range c.Expenses {
+			row(c.Currency, "expenses", csvID(l.AccountID), l.Name, l.PeriodAmounts)
+		}
+		row(c.Currency, "expenses", "", "Total expenses", c.TotalExpenses)
+		row(c.Currency, "net", "", "Net income", c.NetIncome)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvID renders an account id, leaving computed lines (id 0) blank.
+func csvID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+func TestTrialBalanceTotals(t *testing.T) {
+	lines := []TrialBalanceLine{
+		{AccountID: 1, Currency: "USD", Debit: 10000, Credit: 2500, Balance: 7500},
+		{AccountID: 2, Currency: "EUR", Debit: 900, Balance: 900},
+		{AccountID: 3, Currency: "USD", Debit: 2500, Credit: 10000, Balance: -7500},
+		{AccountID: 3, Currency: "EUR", Credit: 900, Balance: -900},
+	}
+	totals, err := trialBalanceTotals(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) != 2 || totals[0].Currency != "USD" || totals[1].Currency != "EUR" {
+		t.Fatalf("totals: %+v", totals)
+	}
+	if usd := totals[0]; usd.Debit != 12500 || usd.Credit != 12500 || usd.Balance != 0 || !usd.Balanced {
+		t.Fatalf("USD: %+v", usd)
+	}
+
+	lines[3].Balance, lines[3].Credit = -800, 800
+	totals, err = trialBalanceTotals(lines)
+	if !errors.Is(err, ErrLedgerImbalance) {
+		t.Fatalf("want ErrLedgerImbalance, got %v", err)
+	}
+	if !totals[0].Balanced || totals[1].Balanced || totals[1].Balance != 100 {
+		t.Fatalf("imbalanced totals: %+v", totals)
+	}
+	if totals, err := trialBalanceTotals(nil); err != nil || len(totals) != 0 {
+		t.Fatalf("empty: %v %v", totals, err)
+	}
+}
+
+func TestBuildBalanceSheet(t *testing.T) {
+	// Salary 5000 into checking, 1200 of groceries on the card, 300 opening
+	// equity in cash.
+	lines := []TrialBalanceLine{
+		{AccountID: 1, Name: "Checking", Type: AccountAsset, Currency: "USD", Balance: 5000},
+		{AccountID: 2, Name: "Cash", Type: AccountAsset, Currency: "USD", Balance: 300},
+		{AccountID: 3, Name: "Card", Type: AccountLiability, Currency: "USD", Balance: -1200},
+		{AccountID: 4, Name: "Opening", Type: AccountEquity, Currency: "USD", Balance: -300},
+		{AccountID: 5, Name: "Salary", Type: AccountIncome, Currency: "USD", Balance: -5000},
+		{AccountID: 6, Name: "Groceries", Type: AccountExpense, Currency: "USD", Balance: 1200},
+	}
+	bs, err := buildBalanceSheet(time.Time{}, lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bs.Currencies) != 1 {
+		t.Fatalf("currencies: %+v", bs.Currencies)
+	}
+	usd := bs.Currencies[0]
+	if usd.TotalAssets != 5300 || usd.TotalLiabilities != 1200 || usd.TotalEquity != 4100 || !usd.Balanced {
+		t.Fatalf("USD: %+v", usd)
+	}
+	last := usd.Equity[len(usd.Equity)-1]
+	if last.Name != CurrentEarnings || last.AccountID != 0 || last.Amount != 3800 {
+		t.Fatalf("earnings line: %+v", last)
+	}
+
+	var buf bytes.Buffer
+	if err := bs.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "USD,equity,,Current earnings,3800\n") ||
+		!strings.Contains(buf.String(), "USD,assets,,Total assets,5300\n") {
+		t.Fatalf("csv:\n%s", buf.String())
+	}
+
+	lines[0].Balance = 4900
+	bs, err = buildBalanceSheet(time.Time{}, lines)
+	if !errors.Is(err, ErrLedgerImbalance) || bs.Currencies[0].Balanced {
+		t.Fatalf("want imbalance, got %v %+v", err, bs.Currencies)
+	}
+}
+
+func TestBuildIncomeStatement(t *testing.T) {
+	from := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	jan := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := []sqlc.IncomeStatementRow{
+		{AccountID: 5, Name: "Salary", Type: AccountIncome, Currency: "USD", Month: jan, AmountMinor: -5000},
+		{AccountID: 5, Name: "Salary", Type: AccountIncome, Currency: "USD", Month: mar, AmountMinor: -5000},
+		{AccountID: 6, Name: "Groceries", Type: AccountExpense, Currency: "USD", Month: jan, AmountMinor: 1200},
+		{AccountID: 6, Name: "Groceries", Type: AccountExpense, Currency: "EUR", Month: mar, AmountMinor: 90},
+		{AccountID: 7, Name: "Rent", Type: AccountExpense, Currency: "USD", Month: mar, AmountMinor: 2000},
+	}
+	is := buildIncomeStatement(from, to, rows)
+	if strings.Join(is.Months, ",") != "2025-01,2025-02,2025-03" {
+		t.Fatalf("months: %v", is.Months)
+	}
+	if len(is.Currencies) != 2 || is.Currencies[0].Currency != "USD" || is.Currencies[1].Currency != "EUR" {
+		t.Fatalf("currencies: %+v", is.Currencies)
+	}
+	usd := is.Currencies[0]
+	if len(usd.Income) != 1 || len(usd.Expenses) != 2 {
+		t.Fatalf("USD lines: %+v", usd)
+	}
+	if got := usd.Income[0].Months; got[0] != 5000 || got[1] != 0 || got[2] != 5000 {
+		t.Fatalf("salary: %v", got)
+	}
+	if got := usd.NetIncome; got.Months[0] != 3800 || got.Months[2] != 3000 || got.Total != 6800 {
+		t.Fatalf("net income: %+v", got)
+	}
+	if eur := is.Currencies[1]; eur.TotalExpenses.Total != 90 || eur.NetIncome.Total != -90 || len(eur.Income) != 0 {
+		t.Fatalf("EUR: %+v", eur)
+	}
+
+	var buf bytes.Buffer
+	if err := is.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "currency,section,account_id,account,2025-01,2025-02,2025-03,total\n" +
+		"USD,income,5,Salary,5000,0,5000,10000\n"
+	if !strings.HasPrefix(buf.String(), want) || !strings.Contains(buf.String(), "USD,net,,Net income,3800,0,3000,6800\n") {
+		t.Fatalf("csv:\n%s", buf.String())
+	}
+}
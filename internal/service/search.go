@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+// ErrSearchQuery: the full-text query has no words left to search for once
+// common English words ("the", "and", ...) are dropped.
+var ErrSearchQuery = errors.New("search query has no searchable words")
+
+// SearchFilter narrows a user-wide transaction search. Zero values mean "no
+// filter".
+type SearchFilter struct {
+	UserID     int64
+	From, To   time.Time // occurred_at in [From, To)
+	AccountIDs []int64   // touches any of these accounts
+	MinAmount  *int64    // absolute posting amount, inclusive
+	MaxAmount  *int64
+	CategoryID int64  // header or line category, including sub-categories
+	Reversal   string // "reversed", "reversal" or "none"
+	Note       string // case-insensitive substring of the note
+	Query      string // full-text query over payee and note; ranks results
+
+	// Keyset position: rows strictly before (CursorRank, CursorOccurredAt,
+	// CursorID). CursorRank is ignored without a Query.
+	CursorRank       float32
+	CursorOccurredAt time.Time
+	CursorID         int64
+	Limit            int32
+}
+
+// SearchHit is one search result. Rank and Highlight are only set for a
+// full-text query; Highlight holds the matching fragments of payee and note
+// with the matched words wrapped in <mark></mark>.
+type SearchHit struct {
+	TransactionDTO
+	Rank      float32 `json:"rank,omitempty"`
+	Highlight string  `json:"highlight,omitempty"`
+}
+
+// Search returns the user's transactions matching f, each with all of its
+// postings: most relevant first for a full-text query, else newest first.
+func (s *TransactionService) Search(ctx context.Context, f SearchFilter) ([]SearchHit, error) {
+	tsq := prefixQuery(f.Query)
+	if tsq != "" {
+		n, err := s.q.TextQueryNodes(ctx, tsq)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("%w: %q has only common words", ErrSearchQuery, f.Query)
+		}
+	} else {
+		// Every row ranks 0, so the cursor must too.
+		f.CursorRank = 0
+	}
+	p := sqlc.SearchTransactionsParams{
+		CategoryID:       categoryParam(f.CategoryID),
+		UserID:           f.UserID,
+		TextQuery:        pgtype.Text{String: tsq, Valid: tsq != ""},
+		CursorRank:       f.CursorRank,
+		CursorOccurredAt: f.CursorOccurredAt,
+		CursorID:         f.CursorID,
+		FromTime:         pgtype.Timestamptz{Time: f.From, Valid: !f.From.IsZero()},
+		ToTime:           pgtype.Timestamptz{Time: f.To, Valid: !f.To.IsZero()},
+		AccountIds:       f.AccountIDs,
+		Reversal:         pgtype.Text{String: f.Reversal, Valid: f.Reversal != ""},
+		NotePattern:      pgtype.Text{String: "%" + escapeLike(f.Note) + "%", Valid: f.Note != ""},
+		PageLimit:        f.Limit,
+	}
+	if p.AccountIds == nil {
+		p.AccountIds = []int64{}
+	}
+	if f.MinAmount != nil {
+		p.MinAmount = pgtype.Int8{Int64: *f.MinAmount, Valid: true}
+	}
+	if f.MaxAmount != nil {
+		p.MaxAmount = pgtype.Int8{Int64: *f.MaxAmount, Valid: true}
+	}
+	rows, err := s.q.SearchTransactions(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return []SearchHit{}, nil
+	}
+
+	ids := make([]int64, 0, len(rows))
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	postings, err := s.q.ListPostingsByTransactions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byTx := make(map[int64][]PostingDTO, len(rows))
+	for _, ps := range postings {
+		byTx[ps.TransactionID] = append(byTx[ps.TransactionID], toPostingDTO(ps))
+	}
+
+	out := make([]SearchHit, 0, len(rows))
+	for _, r := range rows {
+		ps := byTx[r.ID]
+		if ps == nil {
+			ps = []PostingDTO{}
+		}
+		out = append(out, SearchHit{
+			TransactionDTO: TransactionDTO{
+				ID:         r.ID,
+				UserID:     r.UserID,
+				Currency:   r.Currency,
+				OccurredAt: r.OccurredAt,
+				Note:       r.Note.String,
+				Payee:      r.Payee.String,
+				ReversalOf: r.ReversalOf.Int64,
+				Amends:     r.Amends.Int64,
+				FXRate:     rateText(r.FxRate),
+				CategoryID: r.CategoryID.Int64,
+				Postings:   ps,
+				CreatedAt:  r.CreatedAt,
+			},
+			Rank:      r.Rank,
+			Highlight: r.Highlight,
+		})
+	}
+	return out, nil
+}
+
+// prefixQuery turns free text into a to_tsquery expression that requires
+// every word, each as a prefix, so "amaz ref" finds "Amazon refund". Only
+// letters and digits survive; tsquery operators in the input are not
+// interpreted. Returns "" when no words remain.
+func prefixQuery(q string) string {
+	words := strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// escapeLike makes s match literally inside an ILIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
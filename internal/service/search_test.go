@@ -0,0 +1,18 @@
+package service
+
+import "testing"
+
+func TestPrefixQuery(t *testing.T) {
+	for in, want := range map[string]string{
+		"Amazon refund":       "amazon:* & refund:*",
+		"  café  ":            "café:*",
+		"o'reilly & !x | (y)": "o:* & reilly:* & x:* & y:*",
+		"march 2026":          "march:* & 2026:*",
+		"&|!():*":             "",
+		"":                    "",
+	} {
+		if got := prefixQuery(in); got != want {
+			t.Errorf("prefixQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+	"github.com/EftikharAzim/ledgerx/internal/storage"
+)
+
+var (
+	ErrStatementNotFound = errors.New("statement not found")
+	ErrStatementNotReady = errors.New("statement is not ready")
+)
+
+// MaxStatementDays bounds the period of one statement.
+const MaxStatementDays = 366
+
+// Statement formats.
+const (
+	StatementCSV  = "csv"
+	StatementHTML = "html"
+)
+
+// StatementService produces bank-style account statements. Rows are
+// requested here and generated by a worker, which stores the CSV and HTML
+// renderings in the same storage backend as exports.
+type StatementService struct {
+	q     *sqlc.Queries
+	store storage.Storage
+	bal   *BalanceService
+}
+
+func NewStatementService(q *sqlc.Queries, store storage.Storage) *StatementService {
+	return &StatementService{q: q, store: store, bal: NewBalanceService(q)}
+}
+
+type StatementDTO struct {
+	ID          int64     `json:"id"`
+	AccountID   int64     `json:"account_id"`
+	PeriodStart string    `json:"period_start"` // YYYY-MM-DD
+	PeriodEnd   string    `json:"period_end"`   // YYYY-MM-DD, inclusive
+	Currency    string    `json:"currency"`
+	Status      string    `json:"status"` // pending | done | error
+	Opening     int64     `json:"opening_balance_minor"`
+	Closing     int64     `json:"closing_balance_minor"`
+	Inflow      int64     `json:"inflow_minor"`
+	Outflow     int64     `json:"outflow_minor"`
+	Entries     int32     `json:"entry_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func toStatementDTO(s sqlc.Statement) StatementDTO {
+	return StatementDTO{
+		ID: s.ID, AccountID: s.AccountID,
+		PeriodStart: s.PeriodStart.Format("2006-01-02"), PeriodEnd: s.PeriodEnd.Format("2006-01-02"),
+		Currency: s.Currency, Status: s.Status,
+		Opening: s.OpeningMinor, Closing: s.ClosingMinor, Inflow: s.InflowMinor, Outflow: s.OutflowMinor,
+		Entries: s.EntryCount, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt,
+	}
+}
+
+// Request records a pending statement of accountID for the days start to
+// end inclusive, or resets an existing one of the same period to pending so
+// it is generated afresh. The caller enqueues the generation.
+func (s *StatementService) Request(ctx context.Context, userID, accountID int64, start, end time.Time) (StatementDTO, error) {
+	start, end = dayOf(start), dayOf(end)
+	if end.Before(start) {
+		return StatementDTO{}, fmt.Errorf("%w: the period ends before it starts", ErrReportRange)
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > MaxStatementDays {
+		return StatementDTO{}, fmt.Errorf("%w: %d days exceeds the limit of %d", ErrReportRange, days, MaxStatementDays)
+	}
+	acc, err := ownedAccount(ctx, s.q, userID, accountID)
+	if err != nil {
+		return StatementDTO{}, err
+	}
+	if acc.Kind != "normal" {
+		return StatementDTO{}, fmt.Errorf("%w: statements cover your own accounts only", ErrAccountType)
+	}
+	st, err := s.q.UpsertStatement(ctx, sqlc.UpsertStatementParams{
+		UserID: userID, AccountID: accountID, PeriodStart: start, PeriodEnd: end, Currency: acc.Currency,
+	})
+	if err != nil {
+		return StatementDTO{}, err
+	}
+	return toStatementDTO(st), nil
+}
+
+// RequestMonthEnd requests last month's statement for every active asset and
+// liability account and returns their ids, for the month-end job.
+func (s *StatementService) RequestMonthEnd(ctx context.Context, month time.Time) ([]int64, error) {
+	start := monthOf(month)
+	end := start.AddDate(0, 1, -1)
+	accounts, err := s.q.ListStatementAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(accounts))
+	for _, a := range accounts {
+		st, err := s.q.UpsertStatement(ctx, sqlc.UpsertStatementParams{
+			UserID: a.UserID, AccountID: a.ID, PeriodStart: start, PeriodEnd: end, Currency: a.Currency,
+		})
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, st.ID)
+	}
+	return ids, nil
+}
+
+func (s *StatementService) Get(ctx context.Context, userID, id int64) (StatementDTO, error) {
+	st, err := s.owned(ctx, userID, id)
+	if err != nil {
+		return StatementDTO{}, err
+	}
+	return toStatementDTO(st), nil
+}
+
+// List returns the user's statements, newest period first, optionally of one
+// account (accountID 0 = all).
+func (s *StatementService) List(ctx context.Context, userID, accountID int64, limit, offset int32) ([]StatementDTO, error) {
+	rows, err := s.q.ListStatementsByUser(ctx, sqlc.ListStatementsByUserParams{
+		UserID:     userID,
+		AccountID:  pgtype.Int8{Int64: accountID, Valid: accountID != 0},
+		PageLimit:  limit,
+		PageOffset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StatementDTO, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, toStatementDTO(r))
+	}
+	return out, nil
+}
+
+// Open returns a generated statement's rendering in format (csv or html);
+// the caller must close it.
+func (s *StatementService) Open(ctx context.Context, userID, id int64, format string) (io.ReadCloser, error) {
+	st, err := s.owned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if st.Status != "done" {
+		return nil, ErrStatementNotReady
+	}
+	key := st.CsvPath
+	if format == StatementHTML {
+		key = st.HtmlPath
+	}
+	return s.store.Open(ctx, key.String)
+}
+
+// Generate builds the statement, stores both renderings and marks it done,
+// or marks it failed if any step errors.
+func (s *StatementService) Generate(ctx context.Context, id int64) error {
+	row, err := s.q.GetStatement(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.generate(ctx, row); err != nil {
+		_ = s.q.FailStatement(ctx, id)
+		return err
+	}
+	return nil
+}
+
+func (s *StatementService) generate(ctx context.Context, row sqlc.Statement) error {
+	acc, err := s.q.GetAccount(ctx, row.AccountID)
+	if err != nil {
+		return err
+	}
+	from, to := row.PeriodStart, row.PeriodEnd.AddDate(0, 0, 1)
+	opening, err := s.bal.BalanceAsOf(ctx, row.AccountID, from, BasisOccurred)
+	if err != nil {
+		return err
+	}
+	entries, err := s.q.ListStatementEntries(ctx, sqlc.ListStatementEntriesParams{AccountID: row.AccountID, FromTime: from, ToTime: to})
+	if err != nil {
+		return err
+	}
+	st := buildStatement(opening.Ledger, entries)
+	st.AccountID, st.AccountName, st.Type, st.Currency = acc.ID, acc.Name, acc.Type, row.Currency
+	st.PeriodStart, st.PeriodEnd = row.PeriodStart, row.PeriodEnd
+
+	base := fmt.Sprintf("statement_%d_%d_%s_%s", row.UserID, row.AccountID,
+		row.PeriodStart.Format("20060102"), row.PeriodEnd.Format("20060102"))
+	var csvBuf, htmlBuf bytes.Buffer
+	if err := st.WriteCSV(&csvBuf); err != nil {
+		return err
+	}
+	if err := st.WriteHTML(&htmlBuf); err != nil {
+		return err
+	}
+	if err := s.store.Save(ctx, base+".csv", bytes.NewReader(csvBuf.Bytes())); err != nil {
+		return err
+	}
+	if err := s.store.Save(ctx, base+".html", bytes.NewReader(htmlBuf.Bytes())); err != nil {
+		return err
+	}
+	return s.q.CompleteStatement(ctx, sqlc.CompleteStatementParams{
+		ID: row.ID, OpeningMinor: st.Opening, ClosingMinor: st.Closing,
+		InflowMinor: st.Inflow, OutflowMinor: st.Outflow, EntryCount: int32(len(st.Entries)),
+		CsvPath:  pgtype.Text{String: base + ".csv", Valid: true},
+		HtmlPath: pgtype.Text{String: base + ".html", Valid: true},
+	})
+}
+
+// owned loads a statement of userID; other users' statements are reported
+// as not found.
+func (s *StatementService) owned(ctx context.Context, userID, id int64) (sqlc.Statement, error) {
+	st, err := s.q.GetStatement(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && st.UserID != userID) {
+		return sqlc.Statement{}, ErrStatementNotFound
+	}
+	return st, err
+}
+
+// StatementEntry is one posting with the balance after it. Amounts are
+// debit-positive, like balances.
+type StatementEntry struct {
+	Date          time.Time
+	TransactionID int64
+	Payee         string
+	Note          string
+	Amount        int64
+	Balance       int64
+}
+
+// Statement is the content of a statement: the opening balance, every entry
+// dated in the period with a running balance, the period's totals and the
+// closing balance.
+type Statement struct {
+	AccountID   int64
+	AccountName string
+	Type        string
+	Currency    string
+	PeriodStart time.Time
+	PeriodEnd   time.Time // inclusive
+	Opening     int64
+	Closing     int64
+	Inflow      int64
+	Outflow     int64
+	Entries     []StatementEntry
+}
+
+// buildStatement runs the balance from opening through the entries, which
+// come oldest first.
+func buildStatement(opening int64, rows []sqlc.ListStatementEntriesRow) Statement {
+	st := Statement{Opening: opening, Closing: opening, Entries: make([]StatementEntry, 0, len(rows))}
+	for _, r := range rows {
+		st.Closing += r.AmountMinor
+		if r.AmountMinor > 0 {
+			st.Inflow += r.AmountMinor
+		} else {
+			st.Outflow -= r.AmountMinor
+		}
+		st.Entries = append(st.Entries, StatementEntry{
+			Date: r.OccurredAt.UTC(), TransactionID: r.TransactionID,
+			Payee: r.Payee.String, Note: r.Note.String,
+			Amount: r.AmountMinor, Balance: st.Closing,
+		})
+	}
+	return st
+}
+
+// WriteCSV writes the opening balance, one row per entry and the closing
+// balance: date, transaction_id, payee, note, amount_minor, balance_minor.
+func (st Statement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"date", "transaction_id", "payee", "note", "amount_minor", "balance_minor"})
+	_ = cw.Write([]string{st.PeriodStart.Format("2006-01-02"), "", "", "Opening balance", "", strconv.FormatInt(st.Opening, 10)})
+	for _, e := range st.Entries {
+		_ = cw.Write([]string{
+			e.Date.Format("2006-01-02"), strconv.FormatInt(e.TransactionID, 10), e.Payee, e.Note,
+			strconv.FormatInt(e.Amount, 10), strconv.FormatInt(e.Balance, 10),
+		})
+	}
+	_ = cw.Write([]string{st.PeriodEnd.Format("2006-01-02"), "", "", "Closing balance", "", strconv.FormatInt(st.Closing, 10)})
+	cw.Flush()
+	return cw.Error()
+}
+
+//go:embed statement.html.tmpl
+var statementHTML string
+
+var statementTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"date":  func(t time.Time) string { return t.Format("2 Jan 2006") },
+	"money": formatMinor,
+}).Parse(statementHTML))
+
+// WriteHTML renders the statement as a self-contained printable page;
+// printing it from a browser gives the PDF.
+func (st Statement) WriteHTML(w io.Writer) error {
+	return statementTemplate.Execute(w, st)
+}
+
+// formatMinor renders a minor-unit amount in major units: -123456 USD is
+// "-1,234.56".
+func formatMinor(v int64, currency string) string {
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+	digits := minorUnits(currency)
+	div := int64(1)
+	for range digits {
+		div *= 10
+	}
+	whole := strconv.FormatInt(v/div, 10)
+	var b strings.Builder
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	if digits > 0 {
+		fmt.Fprintf(&b, ".%0*d", digits, v%div)
+	}
+	return sign + b.String()
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+)
+
+func TestBuildStatement(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 9, 0, 0, 0, time.UTC) }
+	rows := []sqlc.ListStatementEntriesRow{
+		{TransactionID: 10, OccurredAt: day(1), AmountMinor: 250000, Payee: pgtype.Text{String: "Acme Payroll", Valid: true}},
+		{TransactionID: 11, OccurredAt: day(4), AmountMinor: -4599, Note: pgtype.Text{String: "<groceries>", Valid: true}},
+		{TransactionID: 12, OccurredAt: day(9), AmountMinor: -120000, Payee: pgtype.Text{String: "Landlord", Valid: true}},
+	}
+	st := buildStatement(10000, rows)
+	if st.Closing != 135401 || st.Inflow != 250000 || st.Outflow != 124599 {
+		t.Fatalf("totals: %+v", st)
+	}
+	if st.Opening+st.Inflow-st.Outflow != st.Closing {
+		t.Fatal("opening + in - out != closing")
+	}
+	if b := st.Entries[1].Balance; b != 255401 {
+		t.Fatalf("running balance after the second entry = %d", b)
+	}
+
+	st.AccountName, st.Currency = "Checking", "USD"
+	st.PeriodStart, st.PeriodEnd = day(1), time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	var csvBuf bytes.Buffer
+	if err := st.WriteCSV(&csvBuf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvBuf.String()), "\n")
+	if len(lines) != 6 || lines[1] != "2025-03-01,,,Opening balance,,10000" || lines[5] != "2025-03-31,,,Closing balance,,135401" {
+		t.Fatalf("csv:\n%s", csvBuf.String())
+	}
+
+	var htmlBuf bytes.Buffer
+	if err := st.WriteHTML(&htmlBuf); err != nil {
+		t.Fatal(err)
+	}
+	out := htmlBuf.String()
+	if !strings.Contains(out, "1,354.01") || !strings.Contains(out, "&lt;groceries&gt;") || strings.Contains(out, "<groceries>") {
+		t.Fatalf("html:\n%s", out)
+	}
+}
+
+func TestFormatMinor(t *testing.T) {
+	for _, c := range []struct {
+		v        int64
+		currency string
+		want     string
+	}{
+		{0, "USD", "0.00"},
+		{-5, "USD", "-0.05"},
+		{123456789, "EUR", "1,234,567.89"},
+		{1500, "JPY", "1,500"},
+		{12345, "KWD", "12.345"},
+	} {
+		if got := formatMinor(c.v, c.currency); got != c.want {
+			t.Errorf("formatMinor(%d, %s) = %q, want %q", c.v, c.currency, got, c.want)
+		}
+	}
+}
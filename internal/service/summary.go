@@ -58,6 +58,62 @@ func (s *SummaryService) GetMonthlySummary(ctx context.Context, accountID int64,
 	return ms, nil
 }
 
+// CurrencySummary is a MonthlySummary in one currency.
+type CurrencySummary struct {
+	Currency string `json:"currency"`
+	MonthlySummary
+}
+
+// GetSubtreeSummary totals a month over an account and every account below
+// it, per currency. Not cached: moving an account would have to bust every
+// ancestor's entry.
+func (s *SummaryService) GetSubtreeSummary(ctx context.Context, accountID int64, month time.Time) ([]CurrencySummary, error) {
+	rows, err := s.q.GetSubtreeMonthlySummary(ctx, sqlc.GetSubtreeMonthlySummaryParams{AccountID: accountID, Month: month})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CurrencySummary, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, CurrencySummary{
+			Currency:       r.Currency,
+			MonthlySummary: MonthlySummary{Inflow: r.Inflow, Outflow: r.Outflow, Net: r.Net},
+		})
+	}
+	return out, nil
+}
+
+// CategoryTotal is one line category's flow on the user's asset and
+// liability accounts in one currency; CategoryID 0 collects uncategorized
+// lines.
+type CategoryTotal struct {
+	CategoryID int64  `json:"category_id,omitempty"`
+	Currency   string `json:"currency"`
+	Inflow     int64  `json:"inflow"`
+	Outflow    int64  `json:"outflow"`
+	Net        int64  `json:"net"`
+}
+
+// GetCategorySummary totals a month per line category, so each part of a
+// split transaction counts under its own category. Not cached: a
+// re-categorization would have to bust it, and it has no account key.
+func (s *SummaryService) GetCategorySummary(ctx context.Context, userID int64, month time.Time) ([]CategoryTotal, error) {
+	rows, err := s.q.GetCategorySummary(ctx, sqlc.GetCategorySummaryParams{UserID: userID, Month: month})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]CategoryTotal, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, CategoryTotal{
+			CategoryID: r.CategoryID.Int64,
+			Currency:   r.Currency,
+			Inflow:     r.Inflow,
+			Outflow:    r.Outflow,
+			Net:        r.Net,
+		})
+	}
+	return out, nil
+}
+
 // Bust cache when new tx happens
 func (s *SummaryService) Invalidate(ctx context.Context, accountID int64, month time.Time) {
 	key := fmt.Sprintf("summary:%d:%s", accountID, month.Format("2006-01"))
@@ -30,6 +30,8 @@ var (
 	ErrTxNotFound          = errors.New("transaction not found")
 	ErrAlreadyReversed     = errors.New("transaction has already been reversed")
 	ErrIsReversal          = errors.New("a reversal cannot itself be reversed")
+	ErrInsufficientFunds   = errors.New("posting would take the account below its balance floor")
+	ErrSplitMismatch       = errors.New("split amounts must add up to the transaction amount")
 )
 
 type TransactionService struct {
@@ -42,16 +44,24 @@ func NewTransactionService(pool *pgxpool.Pool, q *sqlc.Queries, summarySvc *Summ
 	return &TransactionService{pool: pool, q: q, summarySvc: summarySvc}
 }
 
-// Leg is one side of a balanced transaction.
+// Leg is one side of a balanced transaction. An empty Currency means the
+// transaction's header currency; a zero CategoryID means the header's
+// category.
 type Leg struct {
-	AccountID   int64 `json:"account_id"`
-	AmountMinor int64 `json:"amount_minor"`
+	AccountID   int64  `json:"account_id"`
+	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency,omitempty"`
+	CategoryID  int64  `json:"category_id,omitempty"`
 }
 
 type PostingDTO struct {
-	ID          int64 `json:"id"`
-	AccountID   int64 `json:"account_id"`
-	AmountMinor int64 `json:"amount_minor"`
+	ID          int64  `json:"id"`
+	AccountID   int64  `json:"account_id"`
+	AmountMinor int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
+	CategoryID  int64  `json:"category_id,omitempty"`
+	// ReversedMinor is only filled in by Detail.
+	ReversedMinor int64 `json:"reversed_minor,omitempty"`
 }
 
 type TransactionDTO struct {
@@ -60,7 +70,11 @@ type TransactionDTO struct {
 	Currency   string       `json:"currency"`
 	OccurredAt time.Time    `json:"occurred_at"`
 	Note       string       `json:"note,omitempty"`
+	Payee      string       `json:"payee,omitempty"`
 	ReversalOf int64        `json:"reversal_of,omitempty"`
+	Amends     int64        `json:"amends,omitempty"`
+	FXRate     string       `json:"fx_rate,omitempty"`
+	CategoryID int64        `json:"category_id,omitempty"`
 	Postings   []PostingDTO `json:"postings"`
 	CreatedAt  time.Time    `json:"created_at"`
 }
@@ -72,6 +86,25 @@ type CreateInput struct {
 	Currency       string
 	OccurredAt     time.Time
 	Note           string
+	Payee          string  // optional; who the money went to or came from
+	CategoryID     int64   // optional
+	Splits         []Split // optional; apportions AmountMinor across categories
+	IdempotencyKey string
+}
+
+// Split is one categorized line of a split transaction.
+type Split struct {
+	AmountMinor int64 `json:"amount_minor"`
+	CategoryID  int64 `json:"category_id"`
+}
+
+type JournalInput struct {
+	UserID         int64
+	Legs           []Leg // two or more explicit legs that sum to zero
+	Currency       string
+	OccurredAt     time.Time
+	Note           string
+	CategoryID     int64 // optional
 	IdempotencyKey string
 }
 
@@ -79,21 +112,36 @@ type TransferInput struct {
 	UserID         int64
 	FromAccountID  int64
 	ToAccountID    int64
-	AmountMinor    int64 // must be positive
+	AmountMinor    int64 // must be positive, in Currency
 	Currency       string
+	ToCurrency     string // destination currency; empty = same as Currency
+	FXRate         string // units of ToCurrency per one unit of Currency
 	OccurredAt     time.Time
 	Note           string
+	CategoryID     int64 // optional
 	IdempotencyKey string
 }
 
 // Create records an income/expense against one account. The offsetting leg
-// goes to the user's external account (the outside world), keeping the
-// ledger balanced.
+// goes to the outside world, keeping the ledger balanced: the user's
+// default income or expense account if set, else the external account.
 func (s *TransactionService) Create(ctx context.Context, in CreateInput) (TransactionDTO, error) {
 	if in.AmountMinor == 0 {
 		return TransactionDTO{}, ErrZeroAmount
 	}
 	legs := []Leg{{AccountID: in.AccountID, AmountMinor: in.AmountMinor}}
+	if len(in.Splits) > 0 {
+		// One account leg per split; the external leg still takes the total.
+		legs = legs[:0]
+		var sum int64
+		for _, sp := range in.Splits {
+			legs = append(legs, Leg{AccountID: in.AccountID, AmountMinor: sp.AmountMinor, CategoryID: sp.CategoryID})
+			sum += sp.AmountMinor
+		}
+		if sum != in.AmountMinor {
+			return TransactionDTO{}, ErrSplitMismatch
+		}
+	}
 	// externalLegAmount: the external account absorbs the opposite of the sum
 	// of the explicit legs; resolved inside the DB transaction.
 	return s.createBalanced(ctx, balancedInput{
@@ -103,11 +151,16 @@ func (s *TransactionService) Create(ctx context.Context, in CreateInput) (Transa
 		Currency:       in.Currency,
 		OccurredAt:     in.OccurredAt,
 		Note:           in.Note,
+		Payee:          in.Payee,
+		CategoryID:     in.CategoryID,
 		IdempotencyKey: in.IdempotencyKey,
 	})
 }
 
-// Transfer moves money between two of the user's accounts.
+// Transfer moves money between two of the user's accounts. When the
+// destination currency differs, the amount is converted at FXRate and the
+// user's FX trading account takes the opposite side of both legs, so each
+// currency balances on its own.
 func (s *TransactionService) Transfer(ctx context.Context, in TransferInput) (TransactionDTO, error) {
 	if in.AmountMinor <= 0 {
 		return TransactionDTO{}, fmt.Errorf("%w: transfer amount must be positive", ErrZeroAmount)
@@ -115,16 +168,72 @@ func (s *TransactionService) Transfer(ctx context.Context, in TransferInput) (Tr
 	if in.FromAccountID == in.ToAccountID {
 		return TransactionDTO{}, fmt.Errorf("%w: cannot transfer to the same account", ErrUnbalanced)
 	}
+	if in.ToCurrency == "" || in.ToCurrency == in.Currency {
+		if in.FXRate != "" {
+			return TransactionDTO{}, fmt.Errorf("%w: fx_rate given for a same-currency transfer", ErrInvalidRate)
+		}
+		legs := []Leg{
+			{AccountID: in.FromAccountID, AmountMinor: -in.AmountMinor},
+			{AccountID: in.ToAccountID, AmountMinor: in.AmountMinor},
+		}
+		return s.createBalanced(ctx, balancedInput{
+			UserID:         in.UserID,
+			Legs:           legs,
+			Currency:       in.Currency,
+			OccurredAt:     in.OccurredAt,
+			Note:           in.Note,
+			CategoryID:     in.CategoryID,
+			IdempotencyKey: in.IdempotencyKey,
+		})
+	}
+
+	rate, err := parseRate(in.FXRate)
+	if err != nil {
+		return TransactionDTO{}, err
+	}
+	// Convert at the precision the rate is stored with, so the booked
+	// amounts can always be re-derived from the recorded rate.
+	if rate, err = parseRate(formatRate(rate)); err != nil {
+		return TransactionDTO{}, fmt.Errorf("%w: fx_rate rounds to zero at 10 decimal places", ErrInvalidRate)
+	}
+	toAmount, err := convertMinor(in.AmountMinor, in.Currency, in.ToCurrency, rate)
+	if err != nil {
+		return TransactionDTO{}, err
+	}
+	if toAmount == 0 {
+		return TransactionDTO{}, fmt.Errorf("%w: converted amount rounds to zero", ErrZeroAmount)
+	}
 	legs := []Leg{
-		{AccountID: in.FromAccountID, AmountMinor: -in.AmountMinor},
-		{AccountID: in.ToAccountID, AmountMinor: in.AmountMinor},
+		{AccountID: in.FromAccountID, AmountMinor: -in.AmountMinor, Currency: in.Currency},
+		{AccountID: in.ToAccountID, AmountMinor: toAmount, Currency: in.ToCurrency},
 	}
 	return s.createBalanced(ctx, balancedInput{
 		UserID:         in.UserID,
 		Legs:           legs,
+		UseFXLegs:      true,
+		Currency:       in.Currency,
+		FXRate:         formatRate(rate),
+		OccurredAt:     in.OccurredAt,
+		Note:           in.Note,
+		CategoryID:     in.CategoryID,
+		IdempotencyKey: in.IdempotencyKey,
+	})
+}
+
+// Journal records a general N-leg entry, e.g. a paycheck split across
+// checking, savings, tax and retirement accounts. The legs must balance on
+// their own; no external leg is added.
+func (s *TransactionService) Journal(ctx context.Context, in JournalInput) (TransactionDTO, error) {
+	if len(in.Legs) < 2 {
+		return TransactionDTO{}, fmt.Errorf("%w: a journal entry needs at least two legs", ErrUnbalanced)
+	}
+	return s.createBalanced(ctx, balancedInput{
+		UserID:         in.UserID,
+		Legs:           in.Legs,
 		Currency:       in.Currency,
 		OccurredAt:     in.OccurredAt,
 		Note:           in.Note,
+		CategoryID:     in.CategoryID,
 		IdempotencyKey: in.IdempotencyKey,
 	})
 }
@@ -133,28 +242,75 @@ type balancedInput struct {
 	UserID         int64
 	Legs           []Leg
 	UseExternalLeg bool
+	UseFXLegs      bool // the FX trading account offsets each currency's imbalance
+	FundedByHold   bool // capture of a hold: floors were enforced at placement
 	Currency       string
+	FXRate         string
 	OccurredAt     time.Time
 	Note           string
+	Payee          string
+	CategoryID     int64
+	Amends         int64 // the transaction this one replaces
 	IdempotencyKey string
 }
 
-// validateLegs enforces the double-entry invariant before touching the DB.
-// The DB-level deferred trigger is the backstop; this gives clean errors.
-func validateLegs(legs []Leg, useExternalLeg bool) error {
-	var sum int64
+// validateLegs enforces the double-entry invariant before touching the DB:
+// the legs of each currency must sum to zero on their own. autoBalanced
+// skips the sum check when an external or FX leg will absorb the
+// difference. The DB-level deferred trigger is the backstop; this gives
+// clean errors.
+func validateLegs(legs []Leg, autoBalanced bool) error {
+	sums := make(map[string]int64)
 	for _, l := range legs {
 		if l.AmountMinor == 0 {
 			return ErrZeroAmount
 		}
-		sum += l.AmountMinor
+		sums[l.Currency] += l.AmountMinor
 	}
-	if !useExternalLeg && sum != 0 {
-		return ErrUnbalanced
+	if autoBalanced {
+		return nil
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return ErrUnbalanced
+		}
 	}
 	return nil
 }
 
+// withCurrency returns a copy of legs with empty currencies set to the
+// header currency.
+func withCurrency(legs []Leg, currency string) []Leg {
+	out := make([]Leg, len(legs))
+	for i, l := range legs {
+		if l.Currency == "" {
+			l.Currency = currency
+		}
+		out[i] = l
+	}
+	return out
+}
+
+// currencyImbalances returns the non-zero per-currency sums of legs in
+// first-seen currency order, so the resulting offset legs are deterministic.
+func currencyImbalances(legs []Leg) []Leg {
+	var order []string
+	sums := make(map[string]int64)
+	for _, l := range legs {
+		if _, ok := sums[l.Currency]; !ok {
+			order = append(order, l.Currency)
+		}
+		sums[l.Currency] += l.AmountMinor
+	}
+	var out []Leg
+	for _, c := range order {
+		if sums[c] != 0 {
+			out = append(out, Leg{Currency: c, AmountMinor: sums[c]})
+		}
+	}
+	return out
+}
+
 // requestHash canonicalizes the request for idempotency comparison. Struct
 // field order makes the JSON deterministic.
 func requestHash(in balancedInput) string {
@@ -163,16 +319,19 @@ func requestHash(in balancedInput) string {
 		Legs       []Leg     `json:"legs"`
 		External   bool      `json:"external"`
 		Currency   string    `json:"currency"`
+		FXRate     string    `json:"fx_rate,omitempty"`
 		OccurredAt time.Time `json:"occurred_at"`
 		Note       string    `json:"note"`
-	}{in.UserID, in.Legs, in.UseExternalLeg, in.Currency, in.OccurredAt.UTC(), in.Note}
+		Payee      string    `json:"payee,omitempty"`
+		CategoryID int64     `json:"category_id,omitempty"`
+	}{in.UserID, in.Legs, in.UseExternalLeg, in.Currency, in.FXRate, in.OccurredAt.UTC(), in.Note, in.Payee, in.CategoryID}
 	b, _ := json.Marshal(payload)
 	sum := sha256.Sum256(b)
 	return hex.EncodeToString(sum[:])
 }
 
 func (s *TransactionService) createBalanced(ctx context.Context, in balancedInput) (TransactionDTO, error) {
-	if err := validateLegs(in.Legs, in.UseExternalLeg); err != nil {
+	if err := validateLegs(withCurrency(in.Legs, in.Currency), in.UseExternalLeg || in.UseFXLegs); err != nil {
 		return TransactionDTO{}, err
 	}
 
@@ -195,64 +354,137 @@ func (s *TransactionService) createBalanced(ctx context.Context, in balancedInpu
 		return *cached, nil
 	}
 
-	// 2) Resolve the external leg if requested.
-	legs := in.Legs
+	// 2) Resolve offset legs, check every account, write header + postings.
+	dto, legs, err := s.postBalanced(ctx, qtx, in)
+	if err != nil {
+		return TransactionDTO{}, err
+	}
+
+	// 3) Outbox event in the same transaction — atomic with the ledger write.
+	if err := emitTransactionEvent(ctx, qtx, "TransactionCreated", dto, nil); err != nil {
+		return TransactionDTO{}, err
+	}
+
+	// 4) Cache the response for idempotent replays, then commit.
+	if err := markIdempotency(ctx, qtx, in.UserID, in.IdempotencyKey, hash, dto); err != nil {
+		return TransactionDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return TransactionDTO{}, err
+	}
+
+	// 5) Best-effort cache invalidation after commit.
+	s.invalidate(ctx, legs, in.OccurredAt)
+	return dto, nil
+}
+
+// postBalanced writes one balanced transaction inside the caller's DB
+// transaction: it refuses dates in a closed period, appends the external/FX
+// offset legs, checks ownership, state and currency of every account, then
+// inserts the header and postings and checks the category's budgets. It
+// returns the full leg list for cache invalidation. Callers own idempotency,
+// the outbox event and the commit.
+func (s *TransactionService) postBalanced(ctx context.Context, qtx *sqlc.Queries, in balancedInput) (TransactionDTO, []Leg, error) {
+	if err := checkPeriodOpen(ctx, qtx, in.UserID, in.OccurredAt); err != nil {
+		return TransactionDTO{}, nil, err
+	}
+
+	// Resolve the external or FX offset legs if requested.
+	legs := withCurrency(in.Legs, in.Currency)
 	if in.UseExternalLeg {
 		var sum int64
 		for _, l := range legs {
 			sum += l.AmountMinor
 		}
-		var extID int64
-		ext, err := qtx.GetExternalAccount(ctx, in.UserID)
+		extID, err := externalCounterpart(ctx, qtx, in.UserID, in.Currency, sum)
+		if err != nil {
+			return TransactionDTO{}, nil, err
+		}
+		legs = append(legs, Leg{AccountID: extID, AmountMinor: -sum, Currency: in.Currency})
+	}
+	if in.UseFXLegs {
+		var fxID int64
+		fx, err := qtx.GetFXAccount(ctx, in.UserID)
 		switch {
 		case err == nil:
-			extID = ext.ID
+			fxID = fx.ID
 		case errors.Is(err, pgx.ErrNoRows):
-			// Users created before the double-entry migration get one lazily.
-			created, err := qtx.CreateExternalAccount(ctx, sqlc.CreateExternalAccountParams{
+			created, err := qtx.CreateFXAccount(ctx, sqlc.CreateFXAccountParams{
 				UserID: in.UserID, Currency: in.Currency,
 			})
 			if err != nil {
-				return TransactionDTO{}, err
+				return TransactionDTO{}, nil, err
 			}
-			extID = created.ID
+			fxID = created.ID
 		default:
-			return TransactionDTO{}, err
+			return TransactionDTO{}, nil, err
+		}
+		for _, imb := range currencyImbalances(legs) {
+			legs = append(legs, Leg{AccountID: fxID, AmountMinor: -imb.AmountMinor, Currency: imb.Currency})
 		}
-		legs = append(legs, Leg{AccountID: extID, AmountMinor: -sum})
 	}
 
-	// 3) Ownership / state / currency checks on every leg.
+	// Ownership / state / currency checks on every leg.
+	net := make(map[int64]int64)
 	for _, l := range legs {
 		acc, err := qtx.GetAccount(ctx, l.AccountID)
 		if errors.Is(err, pgx.ErrNoRows) {
-			return TransactionDTO{}, ErrAccountNotFound
+			return TransactionDTO{}, nil, ErrAccountNotFound
 		}
 		if err != nil {
-			return TransactionDTO{}, err
+			return TransactionDTO{}, nil, err
 		}
 		if acc.UserID != in.UserID {
-			return TransactionDTO{}, ErrForbidden
+			return TransactionDTO{}, nil, ErrForbidden
 		}
 		if !acc.IsActive {
-			return TransactionDTO{}, ErrAccountInactive
+			return TransactionDTO{}, nil, ErrAccountInactive
+		}
+		// External and FX accounts are currency-agnostic by design; real
+		// accounts must match the currency of the leg posted to them.
+		if acc.Kind == "normal" && acc.Currency != l.Currency {
+			return TransactionDTO{}, nil, ErrCurrencyMismatch
+		}
+		if acc.Kind == "normal" {
+			net[l.AccountID] += l.AmountMinor
+		}
+	}
+
+	// Balance floors, for accounts this transaction takes money out of. A
+	// captured hold was checked when it was placed.
+	if !in.FundedByHold {
+		if err := enforceFloors(ctx, qtx, debitsOf(net)); err != nil {
+			return TransactionDTO{}, nil, err
+		}
+	}
+
+	// Header and line categories must all be the user's own.
+	var categories []int64
+	seen := make(map[int64]bool)
+	for _, id := range append([]int64{in.CategoryID}, legCategories(legs)...) {
+		if id == 0 || seen[id] {
+			continue
 		}
-		// The external account is currency-agnostic by design; real accounts
-		// must match the transaction currency.
-		if acc.Kind != "external" && acc.Currency != in.Currency {
-			return TransactionDTO{}, ErrCurrencyMismatch
+		if _, err := ownedCategory(ctx, qtx, in.UserID, id); err != nil {
+			return TransactionDTO{}, nil, err
 		}
+		seen[id] = true
+		categories = append(categories, id)
 	}
 
-	// 4) Header + postings.
+	// Header + postings.
 	header, err := qtx.CreateTransactionHeader(ctx, sqlc.CreateTransactionHeaderParams{
 		UserID:     in.UserID,
 		Currency:   in.Currency,
+		CategoryID: categoryParam(in.CategoryID),
 		OccurredAt: in.OccurredAt,
 		Note:       pgtype.Text{String: in.Note, Valid: in.Note != ""},
+		Payee:      pgtype.Text{String: in.Payee, Valid: in.Payee != ""},
+		Amends:     pgtype.Int8{Int64: in.Amends, Valid: in.Amends != 0},
+		FxRate:     pgtype.Text{String: in.FXRate, Valid: in.FXRate != ""},
 	})
 	if err != nil {
-		return TransactionDTO{}, err
+		return TransactionDTO{}, nil, err
 	}
 	dto := TransactionDTO{
 		ID:         header.ID,
@@ -260,92 +492,158 @@ func (s *TransactionService) createBalanced(ctx context.Context, in balancedInpu
 		Currency:   header.Currency,
 		OccurredAt: header.OccurredAt,
 		Note:       in.Note,
+		Payee:      in.Payee,
+		Amends:     in.Amends,
+		FXRate:     in.FXRate,
+		CategoryID: in.CategoryID,
 		CreatedAt:  header.CreatedAt,
 	}
 	for _, l := range legs {
 		p, err := qtx.CreatePosting(ctx, sqlc.CreatePostingParams{
-			TransactionID: header.ID, AccountID: l.AccountID, AmountMinor: l.AmountMinor,
+			TransactionID: header.ID, AccountID: l.AccountID, AmountMinor: l.AmountMinor, Currency: l.Currency,
+			CategoryID: categoryParam(l.CategoryID),
 		})
 		if err != nil {
-			return TransactionDTO{}, err
+			return TransactionDTO{}, nil, err
 		}
-		dto.Postings = append(dto.Postings, PostingDTO{ID: p.ID, AccountID: p.AccountID, AmountMinor: p.AmountMinor})
+		dto.Postings = append(dto.Postings, toPostingDTO(p))
+	}
+	if err := checkBudgets(ctx, qtx, in.UserID, header.ID, categories, in.OccurredAt); err != nil {
+		return TransactionDTO{}, nil, err
 	}
+	return dto, legs, nil
+}
 
-	// 5) Outbox event in the same transaction — atomic with the ledger write.
-	event, err := json.Marshal(map[string]any{
-		"transaction_id": header.ID,
-		"user_id":        header.UserID,
-		"currency":       header.Currency,
-		"occurred_at":    header.OccurredAt,
+// emitTransactionEvent queues an outbox event describing dto; extra fields
+// are merged into the payload.
+func emitTransactionEvent(ctx context.Context, qtx *sqlc.Queries, eventType string, dto TransactionDTO, extra map[string]any) error {
+	payload := map[string]any{
+		"transaction_id": dto.ID,
+		"user_id":        dto.UserID,
+		"currency":       dto.Currency,
+		"occurred_at":    dto.OccurredAt,
 		"postings":       dto.Postings,
-	})
+	}
+	if dto.FXRate != "" {
+		payload["fx_rate"] = dto.FXRate
+	}
+	if dto.ReversalOf != 0 {
+		payload["reversal_of"] = dto.ReversalOf
+	}
+	if dto.Amends != 0 {
+		payload["amends"] = dto.Amends
+	}
+	if dto.CategoryID != 0 {
+		payload["category_id"] = dto.CategoryID
+	}
+	for k, v := range extra {
+		payload[k] = v
+	}
+	event, err := json.Marshal(payload)
 	if err != nil {
-		return TransactionDTO{}, err
+		return err
 	}
-	if _, err := qtx.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
-		EventType: "TransactionCreated", Payload: event,
-	}); err != nil {
-		return TransactionDTO{}, err
+	_, err = qtx.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{EventType: eventType, Payload: event})
+	return err
+}
+
+// markIdempotency caches resp as the response for key. An empty key is a
+// no-op.
+func markIdempotency(ctx context.Context, qtx *sqlc.Queries, userID int64, key, hash string, resp any) error {
+	if key == "" {
+		return nil
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return err
 	}
+	return qtx.MarkIdempotencySuccess(ctx, sqlc.MarkIdempotencySuccessParams{
+		UserID: userID, Key: key, RequestHash: hash,
+		ResponseJson: pgtype.Text{String: string(body), Valid: true},
+	})
+}
 
-	// 6) Cache the response for idempotent replays, then commit.
-	if in.IdempotencyKey != "" {
-		resp, err := json.Marshal(dto)
-		if err != nil {
-			return TransactionDTO{}, err
-		}
-		if err := qtx.MarkIdempotencySuccess(ctx, sqlc.MarkIdempotencySuccessParams{
-			UserID: in.UserID, Key: in.IdempotencyKey, RequestHash: hash,
-			ResponseJson: pgtype.Text{String: string(resp), Valid: true},
-		}); err != nil {
-			return TransactionDTO{}, err
+// invalidate busts the cached monthly summaries of every leg's account for
+// each month touched. Best-effort, after commit.
+func (s *TransactionService) invalidate(ctx context.Context, legs []Leg, at ...time.Time) {
+	for _, l := range legs {
+		for _, t := range at {
+			month := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+			s.summarySvc.Invalidate(ctx, l.AccountID, month)
 		}
 	}
-	if err := tx.Commit(ctx); err != nil {
-		return TransactionDTO{}, err
+}
+
+func toPostingDTO(p sqlc.Posting) PostingDTO {
+	return PostingDTO{
+		ID: p.ID, AccountID: p.AccountID, AmountMinor: p.AmountMinor, Currency: p.Currency,
+		CategoryID: p.CategoryID.Int64,
 	}
+}
 
-	// 7) Best-effort cache invalidation after commit.
-	month := time.Date(in.OccurredAt.Year(), in.OccurredAt.Month(), 1, 0, 0, 0, 0, time.UTC)
+// postingLegs returns a leg per posting, for cache invalidation.
+func postingLegs(ps []PostingDTO) []Leg {
+	legs := make([]Leg, 0, len(ps))
+	for _, p := range ps {
+		legs = append(legs, Leg{AccountID: p.AccountID})
+	}
+	return legs
+}
+
+// legCategories lists the line categories set on legs, in leg order.
+func legCategories(legs []Leg) []int64 {
+	var out []int64
 	for _, l := range legs {
-		s.summarySvc.Invalidate(ctx, l.AccountID, month)
+		if l.CategoryID != 0 {
+			out = append(out, l.CategoryID)
+		}
 	}
-	return dto, nil
+	return out
 }
 
 // claimIdempotency claims key inside the caller's DB transaction. Returns a
 // non-nil DTO when this is a replay of an already-committed request; the
 // caller should return it as-is. A nil key is a no-op.
 func claimIdempotency(ctx context.Context, qtx *sqlc.Queries, userID int64, key, hash string) (*TransactionDTO, error) {
+	var dto TransactionDTO
+	replay, err := claimIdempotencyInto(ctx, qtx, userID, key, hash, &dto)
+	if err != nil || !replay {
+		return nil, err
+	}
+	return &dto, nil
+}
+
+// claimIdempotencyInto is claimIdempotency for responses other than a
+// single TransactionDTO: on a replay it decodes the cached response into
+// dst and reports true.
+func claimIdempotencyInto(ctx context.Context, qtx *sqlc.Queries, userID int64, key, hash string, dst any) (bool, error) {
 	if key == "" {
-		return nil, nil
+		return false, nil
 	}
 	_, err := qtx.InsertIdempotencyKey(ctx, sqlc.InsertIdempotencyKeyParams{
 		UserID: userID, Key: key, RequestHash: hash,
 	})
 	if err == nil {
-		return nil, nil // freshly claimed
+		return false, nil // freshly claimed
 	}
 	if !errors.Is(err, pgx.ErrNoRows) {
-		return nil, err
+		return false, err
 	}
 	// Key exists: replay, conflict, or in flight.
 	rec, err := qtx.GetIdempotency(ctx, sqlc.GetIdempotencyParams{UserID: userID, Key: key})
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 	if rec.RequestHash != hash {
-		return nil, ErrIdempotencyConflict
+		return false, ErrIdempotencyConflict
 	}
 	if rec.Status == "succeeded" && rec.ResponseJson.Valid {
-		var dto TransactionDTO
-		if err := json.Unmarshal([]byte(rec.ResponseJson.String), &dto); err != nil {
-			return nil, err
+		if err := json.Unmarshal([]byte(rec.ResponseJson.String), dst); err != nil {
+			return false, err
 		}
-		return &dto, nil
+		return true, nil
 	}
-	return nil, ErrIdempotencyInFlight
+	return false, ErrIdempotencyInFlight
 }
 
 type ReverseInput struct {
@@ -355,8 +653,9 @@ type ReverseInput struct {
 }
 
 // Reverse corrects a transaction the append-only way: a new transaction
-// whose postings negate the original's, linked via reversal_of. The UNIQUE
-// constraint on reversal_of makes double-reversal impossible at the DB level.
+// whose postings negate whatever of the original's is not yet reversed
+// (all of it, unless refunds came first), linked via reversal_of. Once
+// nothing is left it fails with ErrAlreadyReversed.
 func (s *TransactionService) Reverse(ctx context.Context, in ReverseInput) (TransactionDTO, error) {
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
@@ -374,42 +673,99 @@ func (s *TransactionService) Reverse(ctx context.Context, in ReverseInput) (Tran
 		return *cached, nil
 	}
 
-	orig, err := qtx.GetTransactionForUser(ctx, sqlc.GetTransactionForUserParams{
-		ID: in.TransactionID, UserID: in.UserID,
+	now := time.Now().UTC()
+	dto, orig, err := postReversal(ctx, qtx, in.UserID, in.TransactionID, 0, nil, "", now)
+	if err != nil {
+		return TransactionDTO{}, err
+	}
+
+	if err := emitTransactionEvent(ctx, qtx, "TransactionReversed", dto, nil); err != nil {
+		return TransactionDTO{}, err
+	}
+	if err := markIdempotency(ctx, qtx, in.UserID, in.IdempotencyKey, hash, dto); err != nil {
+		return TransactionDTO{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return TransactionDTO{}, err
+	}
+
+	// Invalidate both affected months (original's and the reversal's).
+	s.invalidate(ctx, postingLegs(dto.Postings), orig.OccurredAt, now)
+	return dto, nil
+}
+
+// postReversal writes a reversal of transaction txID at now inside the
+// caller's DB transaction: of amount (see RefundInput) or the given lines,
+// or with neither of everything not yet reversed. The original is locked first so concurrent
+// reversals and refunds see each other's postings. Callers own idempotency,
+// the outbox event and the commit.
+func postReversal(ctx context.Context, qtx *sqlc.Queries, userID, txID, amount int64, lines []RefundLine, note string, now time.Time) (TransactionDTO, sqlc.LockTransactionForUserRow, error) {
+	orig, err := qtx.LockTransactionForUser(ctx, sqlc.LockTransactionForUserParams{
+		ID: txID, UserID: userID,
 	})
 	if errors.Is(err, pgx.ErrNoRows) {
-		return TransactionDTO{}, ErrTxNotFound
+		return TransactionDTO{}, orig, ErrTxNotFound
 	}
 	if err != nil {
-		return TransactionDTO{}, err
+		return TransactionDTO{}, orig, err
 	}
 	if orig.ReversalOf.Valid {
-		return TransactionDTO{}, ErrIsReversal
+		return TransactionDTO{}, orig, ErrIsReversal
+	}
+	// The reversal is dated now, so only the current period must be open.
+	if err := checkPeriodOpen(ctx, qtx, userID, now); err != nil {
+		return TransactionDTO{}, orig, err
 	}
 
-	origPostings, err := qtx.ListPostingsByTransaction(ctx, orig.ID)
+	postings, err := qtx.ListReversiblePostings(ctx, orig.ID)
 	if err != nil {
-		return TransactionDTO{}, err
+		return TransactionDTO{}, orig, err
 	}
-	if len(origPostings) == 0 {
+	if len(postings) == 0 {
 		// Legacy zero-amount headers carry no postings; nothing to reverse.
-		return TransactionDTO{}, ErrTxNotFound
+		return TransactionDTO{}, orig, ErrTxNotFound
+	}
+	if amount != 0 {
+		if lines, err = amountLines(postings, amount); err != nil {
+			return TransactionDTO{}, orig, err
+		}
+	}
+	legs, err := planReversal(postings, lines)
+	if err != nil {
+		return TransactionDTO{}, orig, err
 	}
 
-	now := time.Now().UTC()
-	note := fmt.Sprintf("reversal of transaction %d", orig.ID)
+	// Balance floors, as for new postings: reversing a deposit takes the
+	// money back out of the account it went into.
+	kinds := make(map[int64]string, len(postings))
+	for _, p := range postings {
+		kinds[p.AccountID] = p.AccountKind
+	}
+	net := make(map[int64]int64)
+	for _, l := range legs {
+		if kinds[l.AccountID] == "normal" {
+			net[l.AccountID] += l.AmountMinor
+		}
+	}
+	if err := enforceFloors(ctx, qtx, debitsOf(net)); err != nil {
+		return TransactionDTO{}, orig, err
+	}
+
+	if note == "" {
+		note = fmt.Sprintf("reversal of transaction %d", orig.ID)
+	}
 	header, err := qtx.CreateTransactionHeader(ctx, sqlc.CreateTransactionHeaderParams{
-		UserID:     in.UserID,
+		UserID:     userID,
 		Currency:   orig.Currency,
+		CategoryID: orig.CategoryID, // so category totals net out too
 		OccurredAt: now,
 		Note:       pgtype.Text{String: note, Valid: true},
+		Payee:      orig.Payee,
 		ReversalOf: pgtype.Int8{Int64: orig.ID, Valid: true},
+		FxRate:     orig.FxRate,
 	})
 	if err != nil {
-		if isUniqueViolation(err) {
-			return TransactionDTO{}, ErrAlreadyReversed
-		}
-		return TransactionDTO{}, err
+		return TransactionDTO{}, orig, err
 	}
 
 	dto := TransactionDTO{
@@ -418,60 +774,24 @@ func (s *TransactionService) Reverse(ctx context.Context, in ReverseInput) (Tran
 		Currency:   header.Currency,
 		OccurredAt: header.OccurredAt,
 		Note:       note,
+		Payee:      orig.Payee.String,
 		ReversalOf: orig.ID,
+		FXRate:     rateText(header.FxRate),
+		CategoryID: header.CategoryID.Int64,
 		CreatedAt:  header.CreatedAt,
 	}
-	for _, p := range origPostings {
+	for _, l := range legs {
 		np, err := qtx.CreatePosting(ctx, sqlc.CreatePostingParams{
-			TransactionID: header.ID, AccountID: p.AccountID, AmountMinor: -p.AmountMinor,
+			TransactionID: header.ID, AccountID: l.AccountID, AmountMinor: l.AmountMinor, Currency: l.Currency,
+			CategoryID:        l.CategoryID, // split lines net out per category too
+			ReversesPostingID: pgtype.Int8{Int64: l.PostingID, Valid: true},
 		})
 		if err != nil {
-			return TransactionDTO{}, err
-		}
-		dto.Postings = append(dto.Postings, PostingDTO{ID: np.ID, AccountID: np.AccountID, AmountMinor: np.AmountMinor})
-	}
-
-	event, err := json.Marshal(map[string]any{
-		"transaction_id": header.ID,
-		"reversal_of":    orig.ID,
-		"user_id":        header.UserID,
-		"currency":       header.Currency,
-		"occurred_at":    header.OccurredAt,
-		"postings":       dto.Postings,
-	})
-	if err != nil {
-		return TransactionDTO{}, err
-	}
-	if _, err := qtx.CreateOutboxEvent(ctx, sqlc.CreateOutboxEventParams{
-		EventType: "TransactionReversed", Payload: event,
-	}); err != nil {
-		return TransactionDTO{}, err
-	}
-
-	if in.IdempotencyKey != "" {
-		resp, err := json.Marshal(dto)
-		if err != nil {
-			return TransactionDTO{}, err
-		}
-		if err := qtx.MarkIdempotencySuccess(ctx, sqlc.MarkIdempotencySuccessParams{
-			UserID: in.UserID, Key: in.IdempotencyKey, RequestHash: hash,
-			ResponseJson: pgtype.Text{String: string(resp), Valid: true},
-		}); err != nil {
-			return TransactionDTO{}, err
+			return TransactionDTO{}, orig, err
 		}
+		dto.Postings = append(dto.Postings, toPostingDTO(np))
 	}
-	if err := tx.Commit(ctx); err != nil {
-		return TransactionDTO{}, err
-	}
-
-	// Invalidate both affected months (original's and the reversal's).
-	for _, p := range origPostings {
-		for _, at := range []time.Time{orig.OccurredAt, now} {
-			month := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
-			s.summarySvc.Invalidate(ctx, p.AccountID, month)
-		}
-	}
-	return dto, nil
+	return dto, orig, nil
 }
 
 func reverseHash(in ReverseInput) string {
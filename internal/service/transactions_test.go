@@ -1,6 +1,8 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 )
@@ -12,12 +14,15 @@ func TestValidateLegsZeroSum(t *testing.T) {
 		external bool
 		wantErr  error
 	}{
-		{"balanced pair", []Leg{{1, -500}, {2, 500}}, false, nil},
-		{"unbalanced pair", []Leg{{1, -500}, {2, 400}}, false, ErrUnbalanced},
-		{"single leg without external", []Leg{{1, 500}}, false, ErrUnbalanced},
-		{"single leg with external offset", []Leg{{1, 500}}, true, nil},
-		{"zero amount leg", []Leg{{1, 0}, {2, 0}}, false, ErrZeroAmount},
-		{"three balanced legs", []Leg{{1, -300}, {2, 100}, {3, 200}}, false, nil},
+		{"balanced pair", []Leg{{AccountID: 1, AmountMinor: -500}, {AccountID: 2, AmountMinor: 500}}, false, nil},
+		{"unbalanced pair", []Leg{{AccountID: 1, AmountMinor: -500}, {AccountID: 2, AmountMinor: 400}}, false, ErrUnbalanced},
+		{"single leg without external", []Leg{{AccountID: 1, AmountMinor: 500}}, false, ErrUnbalanced},
+		{"single leg with external offset", []Leg{{AccountID: 1, AmountMinor: 500}}, true, nil},
+		{"zero amount leg", []Leg{{AccountID: 1, AmountMinor: 0}, {AccountID: 2, AmountMinor: 0}}, false, ErrZeroAmount},
+		{"three balanced legs", []Leg{{AccountID: 1, AmountMinor: -300}, {AccountID: 2, AmountMinor: 100}, {AccountID: 3, AmountMinor: 200}}, false, nil},
+		{"balanced per currency", []Leg{{AccountID: 1, AmountMinor: -1000, Currency: "USD"}, {AccountID: 9, AmountMinor: 1000, Currency: "USD"}, {AccountID: 9, AmountMinor: -920, Currency: "EUR"}, {AccountID: 2, AmountMinor: 920, Currency: "EUR"}}, false, nil},
+		{"balanced only globally", []Leg{{AccountID: 1, AmountMinor: -1000, Currency: "USD"}, {AccountID: 2, AmountMinor: 1000, Currency: "EUR"}}, false, ErrUnbalanced},
+		{"cross currency with fx offset", []Leg{{AccountID: 1, AmountMinor: -1000, Currency: "USD"}, {AccountID: 2, AmountMinor: 920, Currency: "EUR"}}, true, nil},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -25,7 +30,7 @@ func TestValidateLegsZeroSum(t *testing.T) {
 			if c.wantErr == nil && err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if c.wantErr != nil && err != c.wantErr {
+			if c.wantErr != nil && !errors.Is(err, c.wantErr) {
 				t.Fatalf("got %v, want %v", err, c.wantErr)
 			}
 		})
@@ -61,3 +66,35 @@ func TestRequestHashDeterministic(t *testing.T) {
 		t.Fatal("different payloads must hash differently")
 	}
 }
+
+func TestCreateRejectsSplitsNotAddingUp(t *testing.T) {
+	// The split check runs before any DB access, so no pool is needed.
+	s := &TransactionService{}
+	_, err := s.Create(context.Background(), CreateInput{
+		UserID: 1, AccountID: 2, AmountMinor: -1000, Currency: "USD", OccurredAt: time.Now(),
+		Splits: []Split{{AmountMinor: -600, CategoryID: 3}, {AmountMinor: -300, CategoryID: 4}},
+	})
+	if !errors.Is(err, ErrSplitMismatch) {
+		t.Fatalf("got %v, want ErrSplitMismatch", err)
+	}
+
+	// A categorized split also changes the idempotency hash.
+	plain := balancedInput{UserID: 1, Legs: []Leg{{AccountID: 2, AmountMinor: -1000}}, Currency: "USD"}
+	split := plain
+	split.Legs = []Leg{{AccountID: 2, AmountMinor: -1000, CategoryID: 3}}
+	if requestHash(plain) == requestHash(split) {
+		t.Fatal("line categories must be part of the request hash")
+	}
+}
+
+func TestTransferRejectsRateRoundingToZero(t *testing.T) {
+	// Rate validation runs before any DB access, so no pool is needed.
+	s := &TransactionService{}
+	_, err := s.Transfer(context.Background(), TransferInput{
+		UserID: 1, FromAccountID: 2, ToAccountID: 3, AmountMinor: 1000,
+		Currency: "USD", ToCurrency: "EUR", FXRate: "0.00000000001", OccurredAt: time.Now(),
+	})
+	if !errors.Is(err, ErrInvalidRate) {
+		t.Fatalf("got %v, want ErrInvalidRate", err)
+	}
+}
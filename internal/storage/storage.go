@@ -1,12 +1,14 @@
-// Package storage abstracts where export files live so the API can run
-// with local disk in dev and object storage (S3/MinIO) in real deployments,
-// where instances are replaceable and share nothing.
+// Package storage abstracts where export files and attachments live so the
+// API can run with local disk in dev and object storage (S3/MinIO) in real
+// deployments, where instances are replaceable and share nothing.
 package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 )
@@ -16,6 +18,8 @@ type Storage interface {
 	Save(ctx context.Context, key string, body io.Reader) error
 	// Open returns a reader for the object; the caller must close it.
 	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
 }
 
 // Local stores objects as files under a directory. Dev/demo only: files
@@ -61,3 +65,14 @@ func (l *Local) Open(_ context.Context, key string) (io.ReadCloser, error) {
 	}
 	return os.Open(p)
 }
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	p, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
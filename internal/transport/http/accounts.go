@@ -3,31 +3,32 @@ package httptransport
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
-	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+	"github.com/EftikharAzim/ledgerx/internal/service"
 	"github.com/go-chi/chi/v5"
 )
 
-type AccountsAPI struct{ q *sqlc.Queries }
+type AccountsAPI struct{ svc *service.AccountService }
 
-func NewAccountsAPI(q *sqlc.Queries) *AccountsAPI { return &AccountsAPI{q: q} }
+func NewAccountsAPI(svc *service.AccountService) *AccountsAPI { return &AccountsAPI{svc: svc} }
 
 type createAccountReq struct {
 	Name     string `json:"name"`
 	Currency string `json:"currency"` // e.g., "USD"
-}
-type accountDTO struct {
-	ID       int64  `json:"id"`
-	UserID   int64  `json:"user_id"`
-	Name     string `json:"name"`
-	Currency string `json:"currency"`
-	Active   bool   `json:"active"`
-	Kind     string `json:"kind"`
+	Type     string `json:"type"`     // asset (default), liability, equity, income, expense
+	ParentID int64  `json:"parent_id"`
+	// DefaultCounterpart makes an income or expense account the outside-world
+	// side of the user's income or spending, in place of the catch-all
+	// external account.
+	DefaultCounterpart bool `json:"default_counterpart"`
 }
 
 func (a *AccountsAPI) Routes(r chi.Router) {
 	r.Post("/accounts", a.Create)
 	r.Get("/accounts", a.List)
+	r.Get("/accounts/tree", a.Tree)
+	r.Put("/accounts/{id}/parent", a.SetParent)
 }
 
 func (a *AccountsAPI) Create(w http.ResponseWriter, r *http.Request) {
@@ -45,21 +46,16 @@ func (a *AccountsAPI) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "name required", http.StatusUnprocessableEntity)
 		return
 	}
-	if req.Currency == "" {
-		req.Currency = "USD"
-	}
 
-	row, err := a.q.CreateAccount(r.Context(), sqlc.CreateAccountParams{
-		UserID: uid, Name: req.Name, Currency: req.Currency,
+	acc, err := a.svc.Create(r.Context(), service.CreateAccountInput{
+		UserID: uid, Name: req.Name, Currency: req.Currency, Type: req.Type,
+		ParentID: req.ParentID, DefaultCounterpart: req.DefaultCounterpart,
 	})
 	if err != nil {
-		http.Error(w, "could not create (duplicate name?)", http.StatusConflict)
+		writeServiceError(w, err)
 		return
 	}
-	writeJSON(w, http.StatusCreated, accountDTO{
-		ID: row.ID, UserID: row.UserID, Name: row.Name, Currency: row.Currency,
-		Active: row.IsActive, Kind: row.Kind,
-	})
+	writeJSON(w, http.StatusCreated, acc)
 }
 
 func (a *AccountsAPI) List(w http.ResponseWriter, r *http.Request) {
@@ -71,22 +67,52 @@ func (a *AccountsAPI) List(w http.ResponseWriter, r *http.Request) {
 	limit := parseInt(r, "limit", 20)
 	offset := parseInt(r, "offset", 0)
 
-	rows, err := a.q.ListAccountsByUser(r.Context(), sqlc.ListAccountsByUserParams{
-		UserID: uid,
-		Limit:  int32(limit),
-		Offset: int32(offset),
-	})
+	out, err := a.svc.List(r.Context(), uid, int32(limit), int32(offset))
 	if err != nil {
 		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
+	writeJSON(w, http.StatusOK, out)
+}
 
-	out := make([]accountDTO, 0, len(rows))
-	for _, row := range rows {
-		out = append(out, accountDTO{
-			ID: row.ID, UserID: row.UserID, Name: row.Name, Currency: row.Currency,
-			Active: row.IsActive, Kind: row.Kind,
-		})
+// Tree lists all of the caller's accounts nested under their parents.
+func (a *AccountsAPI) Tree(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
 	}
-	writeJSON(w, http.StatusOK, out)
+	roots, err := a.svc.Tree(r.Context(), uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"accounts": roots})
+}
+
+// SetParent moves an account: {"parent_id": N}, or 0/null for the top level.
+func (a *AccountsAPI) SetParent(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+	var req struct {
+		ParentID int64 `json:"parent_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	acc, err := a.svc.SetParent(r.Context(), uid, id, req.ParentID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, acc)
 }
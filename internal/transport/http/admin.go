@@ -2,35 +2,54 @@ package httptransport
 
 import (
 	"crypto/subtle"
+	"io"
 	"net/http"
 	"os"
+	"strings"
 
+	"github.com/EftikharAzim/ledgerx/internal/service"
 	"github.com/EftikharAzim/ledgerx/internal/worker"
 	"github.com/go-chi/chi/v5"
 	"github.com/hibiken/asynq"
 )
 
-type AdminAPI struct{ client *asynq.Client }
+type AdminAPI struct {
+	client  *asynq.Client
+	fx      *service.FXService
+	periods *service.PeriodService
+}
 
-func NewAdminAPI(redisAddr string) *AdminAPI {
-	return &AdminAPI{client: asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr})}
+func NewAdminAPI(redisAddr string, fx *service.FXService, periods *service.PeriodService) *AdminAPI {
+	return &AdminAPI{client: asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr}), fx: fx, periods: periods}
 }
 
+// Every admin endpoint requires ADMIN_TOKEN to be configured and presented
+// via X-Admin-Token; with no token configured the endpoints are disabled.
 func (a *AdminAPI) Routes(r chi.Router) {
 	r.Post("/admin/snapshot/run", a.RunSnapshotAll)
+	r.Post("/admin/fx-rates/import", a.ImportFXRates)
+	r.Post("/admin/periods/{month}/close", a.ClosePeriod)
+	r.Post("/admin/periods/{month}/reopen", a.ReopenPeriod)
 }
 
-// RunSnapshotAll requires ADMIN_TOKEN to be configured and presented via
-// X-Admin-Token; with no token configured the endpoint is disabled.
-func (a *AdminAPI) RunSnapshotAll(w http.ResponseWriter, r *http.Request) {
+// authorized writes the rejection itself and returns false when the caller
+// is not the admin.
+func (a *AdminAPI) authorized(w http.ResponseWriter, r *http.Request) bool {
 	want := os.Getenv("ADMIN_TOKEN")
 	if want == "" {
 		http.NotFound(w, r)
-		return
+		return false
 	}
 	got := r.Header.Get("X-Admin-Token")
 	if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
 		http.Error(w, "forbidden", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (a *AdminAPI) RunSnapshotAll(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
 		return
 	}
 
@@ -42,3 +61,70 @@ func (a *AdminAPI) RunSnapshotAll(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusAccepted)
 	_, _ = w.Write([]byte("enqueued"))
 }
+
+// maxRatesUpload caps an FX import body; a full ECB history file is ~6 MB.
+const maxRatesUpload = 16 << 20
+
+// ImportFXRates loads exchange rates from the request body: an ECB-style XML
+// file when the Content-Type is XML (or ?format=ecb), else CSV lines of
+// date,base,quote,rate.
+func (a *AdminAPI) ImportFXRates(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
+		return
+	}
+	body := io.LimitReader(r.Body, maxRatesUpload)
+	var (
+		rows   []service.RateRow
+		err    error
+		source = "csv"
+	)
+	if r.URL.Query().Get("format") == "ecb" || strings.Contains(r.Header.Get("Content-Type"), "xml") {
+		source = "ecb"
+		rows, err = service.ParseECBXML(body)
+	} else {
+		rows, err = service.ParseRatesCSV(body)
+	}
+	if err != nil {
+		http.Error(w, "invalid rates file: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	n, err := a.fx.Import(r.Context(), rows, source)
+	if err != nil {
+		http.Error(w, "import failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"imported": n})
+}
+
+// ClosePeriod closes a month for every user.
+func (a *AdminAPI) ClosePeriod(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
+		return
+	}
+	month, ok := monthParam(w, r)
+	if !ok {
+		return
+	}
+	p, err := a.periods.Close(r.Context(), 0, month, service.AdminActor)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, p)
+}
+
+// ReopenPeriod lifts a global close; users' own closes are untouched.
+func (a *AdminAPI) ReopenPeriod(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
+		return
+	}
+	month, ok := monthParam(w, r)
+	if !ok {
+		return
+	}
+	if err := a.periods.Reopen(r.Context(), 0, month, service.AdminActor); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
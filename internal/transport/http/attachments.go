@@ -0,0 +1,143 @@
+package httptransport
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxAttachmentUpload caps one attachment; phone photos of receipts fit
+// comfortably.
+const maxAttachmentUpload = 10 << 20
+
+type AttachmentsAPI struct{ svc *service.AttachmentService }
+
+func NewAttachmentsAPI(svc *service.AttachmentService) *AttachmentsAPI {
+	return &AttachmentsAPI{svc: svc}
+}
+
+func (a *AttachmentsAPI) Routes(r chi.Router) {
+	r.Post("/transactions/{id}/attachments", a.Upload)
+	r.Get("/transactions/{id}/attachments", a.List)
+	r.Get("/transactions/{id}/attachments/{attachmentID}", a.Download)
+	r.Delete("/transactions/{id}/attachments/{attachmentID}", a.Delete)
+}
+
+// Upload takes a multipart/form-data body with the document in the "file"
+// field. The type is sniffed from the content: PDF, JPEG or PNG.
+func (a *AttachmentsAPI) Upload(w http.ResponseWriter, r *http.Request) {
+	uid, txID, ok := transactionParams(w, r)
+	if !ok {
+		return
+	}
+	// Leave headroom for the multipart framing around the file itself.
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentUpload+1<<20)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "attachment too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "multipart body with a \"file\" field is required", http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = file.Close() }()
+	body, err := io.ReadAll(io.LimitReader(file, maxAttachmentUpload+1))
+	if err != nil {
+		http.Error(w, "could not read upload", http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxAttachmentUpload {
+		http.Error(w, "attachment too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	dto, err := a.svc.Upload(r.Context(), service.UploadInput{
+		UserID:        uid,
+		TransactionID: txID,
+		Filename:      header.Filename,
+		Body:          body,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+func (a *AttachmentsAPI) List(w http.ResponseWriter, r *http.Request) {
+	uid, txID, ok := transactionParams(w, r)
+	if !ok {
+		return
+	}
+	atts, err := a.svc.List(r.Context(), uid, txID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"attachments": atts})
+}
+
+func (a *AttachmentsAPI) Download(w http.ResponseWriter, r *http.Request) {
+	uid, txID, id, ok := attachmentParams(w, r)
+	if !ok {
+		return
+	}
+	dto, body, err := a.svc.Open(r.Context(), uid, txID, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	defer func() { _ = body.Close() }()
+	w.Header().Set("Content-Type", dto.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(dto.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": dto.Filename}))
+	_, _ = io.Copy(w, body)
+}
+
+func (a *AttachmentsAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	uid, txID, id, ok := attachmentParams(w, r)
+	if !ok {
+		return
+	}
+	if err := a.svc.Delete(r.Context(), uid, txID, id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// transactionParams reads the caller and the {id} path parameter, writing
+// the error response itself when either is missing or invalid.
+func transactionParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	txID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || txID <= 0 {
+		http.Error(w, "invalid transaction id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uid, txID, true
+}
+
+func attachmentParams(w http.ResponseWriter, r *http.Request) (int64, int64, int64, bool) {
+	uid, txID, ok := transactionParams(w, r)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "attachmentID"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid attachment id", http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	return uid, txID, id, true
+}
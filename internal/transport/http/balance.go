@@ -1,8 +1,11 @@
 package httptransport
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
@@ -13,14 +16,34 @@ import (
 type BalanceAPI struct {
 	q   *sqlc.Queries
 	svc *service.BalanceService
+	fx  *service.FXService
 }
 
-func NewBalanceAPI(q *sqlc.Queries, s *service.BalanceService) *BalanceAPI {
-	return &BalanceAPI{q: q, svc: s}
+func NewBalanceAPI(q *sqlc.Queries, s *service.BalanceService, fx *service.FXService) *BalanceAPI {
+	return &BalanceAPI{q: q, svc: s, fx: fx}
 }
 
 func (b *BalanceAPI) Routes(r chi.Router) {
 	r.Get("/accounts/{id}/balance", b.GetCurrent)
+	r.Get("/accounts/{id}/balance/history", b.GetHistory)
+	r.Get("/net-worth", b.GetNetWorth)
+	r.Get("/net-worth/history", b.GetNetWorthHistory)
+	r.Get("/accounts/{id}/limits", b.GetLimits)
+	r.Put("/accounts/{id}/limits", b.PutLimits)
+}
+
+// typedBalance is a balance with the account's type. Balances stay
+// debit-positive; normal_balance says which sign is usual for the account.
+type typedBalance struct {
+	service.BalanceResult
+	Type          string `json:"type"`
+	NormalBalance string `json:"normal_balance"`
+}
+
+type typedPointInTime struct {
+	service.PointInTimeBalance
+	Type          string `json:"type"`
+	NormalBalance string `json:"normal_balance"`
 }
 
 func (b *BalanceAPI) GetCurrent(w http.ResponseWriter, r *http.Request) {
@@ -35,14 +58,306 @@ func (b *BalanceAPI) GetCurrent(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid account id", http.StatusBadRequest)
 		return
 	}
+	acc, err := ownedAccount(r.Context(), b.q, uid, accID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	in, ok := parseCurrency(w, r)
+	if !ok {
+		return
+	}
+	rollup, ok := parseRollup(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	if q.Get("as_of") != "" || q.Get("basis") != "" {
+		if rollup {
+			http.Error(w, "as_of and basis cannot be combined with rollup", http.StatusBadRequest)
+			return
+		}
+		b.getAsOf(w, r, acc, in)
+		return
+	}
+	now := time.Now()
+	if rollup {
+		b.getSubtree(w, r, acc, in, now)
+		return
+	}
+	res, err := b.svc.CurrentBalance(r.Context(), accID, now)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	typed := typedBalance{res, acc.Type, service.NormalBalance(acc.Type)}
+	if in == "" {
+		writeJSON(w, http.StatusOK, typed)
+		return
+	}
+	conv, err := b.fx.ConvertTotal(r.Context(), []service.CurrencyAmount{{Currency: acc.Currency, AmountMinor: res.Balance}}, in, now)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		typedBalance
+		Currency  string                 `json:"currency"`
+		Converted service.ConvertedTotal `json:"converted"`
+	}{typed, acc.Currency, conv})
+}
+
+// getAsOf answers ?as_of= and ?basis=occurred|recorded (default recorded,
+// like the current balance): the ledger balance at a past instant, converted
+// at that instant's rates with ?in=.
+func (b *BalanceAPI) getAsOf(w http.ResponseWriter, r *http.Request, acc sqlc.GetAccountRow, in string) {
+	asOf, ok := parseAsOf(w, r)
+	if !ok {
+		return
+	}
+	basis := r.URL.Query().Get("basis")
+	if basis == "" {
+		basis = service.BasisRecorded
+	}
+	res, err := b.svc.BalanceAsOf(r.Context(), acc.ID, asOf, basis)
+	if errors.Is(err, service.ErrBalanceBasis) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	typed := typedPointInTime{res, acc.Type, service.NormalBalance(acc.Type)}
+	if in == "" {
+		writeJSON(w, http.StatusOK, typed)
+		return
+	}
+	conv, err := b.fx.ConvertTotal(r.Context(), []service.CurrencyAmount{{Currency: acc.Currency, AmountMinor: res.Balance}}, in, asOf)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		typedPointInTime
+		Currency  string                 `json:"currency"`
+		Converted service.ConvertedTotal `json:"converted"`
+	}{typed, acc.Currency, conv})
+}
+
+// getSubtree answers ?rollup=true: the balance of the account and every
+// account below it, per currency.
+func (b *BalanceAPI) getSubtree(w http.ResponseWriter, r *http.Request, acc sqlc.GetAccountRow, in string, now time.Time) {
+	balances, err := b.svc.SubtreeBalances(r.Context(), acc.ID, now)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]any{
+		"account_id":     acc.ID,
+		"as_of":          now.UTC().Format(time.RFC3339),
+		"type":           acc.Type,
+		"normal_balance": service.NormalBalance(acc.Type),
+		"rollup":         true,
+		"balances":       balances,
+	}
+	if in != "" {
+		totals := make([]service.CurrencyAmount, 0, len(balances))
+		for _, sb := range balances {
+			totals = append(totals, service.CurrencyAmount{Currency: sb.Currency, AmountMinor: sb.Ledger})
+		}
+		conv, err := b.fx.ConvertTotal(r.Context(), totals, in, now)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		resp["converted"] = conv
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GetNetWorth sums the caller's account balances per currency; with ?in=XXX
+// the total is also converted at today's rates.
+func (b *BalanceAPI) GetNetWorth(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	in, ok := parseCurrency(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	nw, err := b.svc.NetWorth(r.Context(), uid, now)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	if in == "" {
+		writeJSON(w, http.StatusOK, nw)
+		return
+	}
+	conv, err := b.fx.ConvertTotal(r.Context(), nw.Totals, in, now)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		service.NetWorth
+		Converted service.ConvertedTotal `json:"converted"`
+	}{nw, conv})
+}
+
+// GetHistory returns the account's end-of-period balances over ?from=&to=
+// for ?interval=day|week|month, on ?basis=recorded|occurred.
+func (b *BalanceAPI) GetHistory(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || accID <= 0 {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
 	if _, err := ownedAccount(r.Context(), b.q, uid, accID); err != nil {
 		writeServiceError(w, err)
 		return
 	}
-	res, err := b.svc.CurrentBalance(r.Context(), accID, time.Now())
+	in, ok := parseHistoryInput(w, r)
+	if !ok {
+		return
+	}
+	h, err := b.svc.History(r.Context(), accID, in)
+	if errors.Is(err, service.ErrBalanceBasis) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h)
+}
+
+// GetNetWorthHistory is GetHistory summed over the caller's asset and
+// liability accounts per currency.
+func (b *BalanceAPI) GetNetWorthHistory(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	in, ok := parseHistoryInput(w, r)
+	if !ok {
+		return
+	}
+	h, err := b.svc.NetWorthHistory(r.Context(), uid, in)
+	if errors.Is(err, service.ErrBalanceBasis) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, h)
+}
+
+func parseHistoryInput(w http.ResponseWriter, r *http.Request) (service.HistoryInput, bool) {
+	from, to, ok := parseStatementRange(w, r)
+	if !ok {
+		return service.HistoryInput{}, false
+	}
+	q := r.URL.Query()
+	return service.HistoryInput{From: from, To: to, Interval: q.Get("interval"), Basis: q.Get("basis")}, true
+}
+
+func (b *BalanceAPI) GetLimits(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || accID <= 0 {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+	if _, err := ownedAccount(r.Context(), b.q, uid, accID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	l, err := b.svc.Limits(r.Context(), accID)
 	if err != nil {
 		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, http.StatusOK, res)
+	writeJSON(w, http.StatusOK, l)
+}
+
+// PutLimits replaces the account's balance constraints; omitted or null
+// fields are cleared.
+func (b *BalanceAPI) PutLimits(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || accID <= 0 {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+	var body service.Limits
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	acc, err := ownedAccount(r.Context(), b.q, uid, accID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	if acc.Kind != "normal" {
+		http.Error(w, "limits can only be set on normal accounts", http.StatusUnprocessableEntity)
+		return
+	}
+	l, err := b.svc.SetLimits(r.Context(), accID, body)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, l)
+}
+
+// parseRollup reads the optional ?rollup= flag. It writes a 400 and returns
+// false when the value is not a boolean.
+func parseRollup(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	v := r.URL.Query().Get("rollup")
+	if v == "" {
+		return false, true
+	}
+	rollup, err := strconv.ParseBool(v)
+	if err != nil {
+		http.Error(w, "rollup must be true or false", http.StatusBadRequest)
+		return false, false
+	}
+	return rollup, true
+}
+
+// parseCurrency reads the optional ?in= reporting currency. It writes a 400
+// and returns false when the value is not a three-letter code.
+func parseCurrency(w http.ResponseWriter, r *http.Request) (string, bool) {
+	in := strings.ToUpper(r.URL.Query().Get("in"))
+	if in == "" {
+		return "", true
+	}
+	if len(in) != 3 || strings.Trim(in, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		http.Error(w, "in must be a three-letter currency code", http.StatusBadRequest)
+		return "", false
+	}
+	return in, true
 }
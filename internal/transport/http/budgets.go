@@ -0,0 +1,162 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type BudgetsAPI struct{ svc *service.BudgetService }
+
+func NewBudgetsAPI(svc *service.BudgetService) *BudgetsAPI {
+	return &BudgetsAPI{svc: svc}
+}
+
+func (a *BudgetsAPI) Routes(r chi.Router) {
+	r.Post("/budgets", a.Create)
+	r.Get("/budgets", a.Month)
+	r.Get("/budgets/{id}", a.Get)
+	r.Put("/budgets/{id}", a.Update)
+	r.Delete("/budgets/{id}", a.Delete)
+}
+
+// Create adds a monthly budget to a category; start_month (YYYY-MM) defaults
+// to the current month.
+func (a *BudgetsAPI) Create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		CategoryID int64  `json:"category_id"`
+		Currency   string `json:"currency"`
+		Amount     int64  `json:"amount_minor"`
+		Rollover   bool   `json:"rollover"`
+		StartMonth string `json:"start_month"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if body.CategoryID == 0 || body.Currency == "" {
+		http.Error(w, "category_id and currency are required", http.StatusUnprocessableEntity)
+		return
+	}
+	start := time.Now()
+	if body.StartMonth != "" {
+		var err error
+		if start, err = time.Parse("2006-01", body.StartMonth); err != nil {
+			http.Error(w, "start_month must be YYYY-MM", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+	dto, err := a.svc.Create(r.Context(), service.BudgetInput{
+		UserID:      uid,
+		CategoryID:  body.CategoryID,
+		Currency:    body.Currency,
+		AmountMinor: body.Amount,
+		Rollover:    body.Rollover,
+		StartMonth:  start,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+// Month reports budgeted / spent / remaining for every budget in effect in
+// ?month=YYYY-MM (default: the current month).
+func (a *BudgetsAPI) Month(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	month := time.Now()
+	if s := r.URL.Query().Get("month"); s != "" {
+		var err error
+		if month, err = time.Parse("2006-01", s); err != nil {
+			http.Error(w, "month must be YYYY-MM", http.StatusBadRequest)
+			return
+		}
+	}
+	budgets, err := a.svc.Month(r.Context(), uid, month)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"month":   month.UTC().Format("2006-01"),
+		"budgets": budgets,
+	})
+}
+
+func (a *BudgetsAPI) Get(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := budgetParams(w, r)
+	if !ok {
+		return
+	}
+	dto, err := a.svc.Get(r.Context(), uid, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// Update replaces amount and rollover; category, currency and start month
+// are fixed at creation.
+func (a *BudgetsAPI) Update(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := budgetParams(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Amount   int64 `json:"amount_minor"`
+		Rollover bool  `json:"rollover"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	dto, err := a.svc.Update(r.Context(), uid, id, body.Amount, body.Rollover)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+func (a *BudgetsAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := budgetParams(w, r)
+	if !ok {
+		return
+	}
+	if err := a.svc.Delete(r.Context(), uid, id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// budgetParams reads the caller and the {id} path parameter, writing the
+// error response itself when either is missing or invalid.
+func budgetParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid budget id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uid, id, true
+}
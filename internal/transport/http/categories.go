@@ -0,0 +1,121 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type CategoriesAPI struct{ svc *service.CategoryService }
+
+func NewCategoriesAPI(svc *service.CategoryService) *CategoriesAPI {
+	return &CategoriesAPI{svc: svc}
+}
+
+func (a *CategoriesAPI) Routes(r chi.Router) {
+	r.Post("/categories", a.Create)
+	r.Get("/categories", a.List)
+	r.Get("/categories/{id}", a.Get)
+	r.Put("/categories/{id}", a.Update)
+	r.Delete("/categories/{id}", a.Delete)
+}
+
+type categoryBody struct {
+	Name     string `json:"name"`
+	ParentID int64  `json:"parent_id"`
+}
+
+func (a *CategoriesAPI) Create(w http.ResponseWriter, r *http.Request) {
+	var body categoryBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	dto, err := a.svc.Create(r.Context(), uid, body.Name, body.ParentID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+func (a *CategoriesAPI) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	cats, err := a.svc.List(r.Context(), uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"categories": cats})
+}
+
+func (a *CategoriesAPI) Get(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := categoryParams(w, r)
+	if !ok {
+		return
+	}
+	dto, err := a.svc.Get(r.Context(), uid, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// Update renames and/or moves a category; parent_id 0 or absent makes it
+// top-level.
+func (a *CategoriesAPI) Update(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := categoryParams(w, r)
+	if !ok {
+		return
+	}
+	var body categoryBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	dto, err := a.svc.Update(r.Context(), uid, id, body.Name, body.ParentID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+func (a *CategoriesAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := categoryParams(w, r)
+	if !ok {
+		return
+	}
+	if err := a.svc.Delete(r.Context(), uid, id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func categoryParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid category id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uid, id, true
+}
@@ -1,16 +1,19 @@
 package httptransport
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+	"github.com/EftikharAzim/ledgerx/internal/service"
 	"github.com/EftikharAzim/ledgerx/internal/storage"
 	"github.com/EftikharAzim/ledgerx/internal/worker"
 	"github.com/go-chi/chi/v5"
 	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type ExportsAPI struct {
@@ -20,13 +23,16 @@ type ExportsAPI struct {
 }
 
 type exportDTO struct {
-	ID        int64     `json:"id"`
-	UserID    int64     `json:"user_id"`
-	Month     string    `json:"month"`
-	Status    string    `json:"status"`
-	FilePath  string    `json:"file_path,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        int64      `json:"id"`
+	UserID    int64      `json:"user_id"`
+	Month     string     `json:"month"`
+	Kind      string     `json:"kind"`           // postings | balance_sheet | income_statement
+	From      *time.Time `json:"from,omitempty"` // report exports only
+	To        *time.Time `json:"to,omitempty"`
+	Status    string     `json:"status"`
+	FilePath  string     `json:"file_path,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
 }
 
 func toExportDTO(e sqlc.Export) exportDTO {
@@ -34,8 +40,15 @@ func toExportDTO(e sqlc.Export) exportDTO {
 		ID:     e.ID,
 		UserID: e.UserID,
 		Month:  e.Month.Format("2006-01"),
+		Kind:   e.Kind,
 		Status: e.Status,
 	}
+	if e.RangeStart.Valid {
+		dto.From = &e.RangeStart.Time
+	}
+	if e.RangeEnd.Valid {
+		dto.To = &e.RangeEnd.Time
+	}
 	if e.FilePath.Valid {
 		dto.FilePath = e.FilePath.String
 	}
@@ -60,6 +73,7 @@ func NewExportsAPI(q *sqlc.Queries, redisAddr string, store storage.Storage) *Ex
 // checks that the export belongs to the caller.
 func (e *ExportsAPI) Routes(r chi.Router) {
 	r.Post("/exports", e.CreateExport)
+	r.Post("/reports/{report}/exports", e.CreateReportExport)
 	r.Get("/exports/{id}/status", e.GetStatus)
 	r.Get("/exports/{id}/download", e.Download)
 }
@@ -98,6 +112,67 @@ func (e *ExportsAPI) CreateExport(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusAccepted, toExportDTO(exp))
 }
 
+// CreateReportExport queues a CSV of a financial report: balance-sheet
+// (?as_of=) or income-statement (?from=&to=, up to
+// service.MaxStatementMonths). It is downloaded like any other export.
+func (e *ExportsAPI) CreateReportExport(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	payload := worker.ExportReportPayload{UserID: uid}
+	var from, to time.Time
+	switch chi.URLParam(r, "report") {
+	case "balance-sheet":
+		payload.Kind = "balance_sheet"
+		if to, ok = parseAsOf(w, r); !ok {
+			return
+		}
+		// month is the one the last included instant falls in.
+		from = to.Add(-time.Nanosecond)
+	case "income-statement":
+		payload.Kind = "income_statement"
+		if from, to, ok = parseStatementRange(w, r); !ok {
+			return
+		}
+		if !to.After(from) {
+			writeServiceError(w, fmt.Errorf("%w: to must be after from", service.ErrReportRange))
+			return
+		}
+		if n := len(service.StatementMonths(from, to)); n > service.MaxStatementMonths {
+			writeServiceError(w, fmt.Errorf("%w: %d months exceeds the limit of %d", service.ErrReportRange, n, service.MaxStatementMonths))
+			return
+		}
+		payload.From = from.UTC().Format(time.RFC3339Nano)
+	default:
+		http.Error(w, "unknown report", http.StatusNotFound)
+		return
+	}
+	payload.To = to.UTC().Format(time.RFC3339Nano)
+
+	params := sqlc.CreateReportExportParams{
+		UserID:   uid,
+		Month:    time.Date(from.UTC().Year(), from.UTC().Month(), 1, 0, 0, 0, 0, time.UTC),
+		Kind:     payload.Kind,
+		RangeEnd: pgtype.Timestamptz{Time: to, Valid: true},
+	}
+	if payload.From != "" {
+		params.RangeStart = pgtype.Timestamptz{Time: from, Valid: true}
+	}
+	exp, err := e.q.CreateReportExport(r.Context(), params)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	payload.ExportID = exp.ID
+	if _, err := e.client.Enqueue(asynq.NewTask(worker.TypeExportReport, worker.MustJSON(payload))); err != nil {
+		http.Error(w, "enqueue failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, toExportDTO(exp))
+}
+
 // ownedExport loads an export and verifies the caller owns it.
 func (e *ExportsAPI) ownedExport(w http.ResponseWriter, r *http.Request) (sqlc.Export, bool) {
 	uid, ok := r.Context().Value(UserIDKey).(int64)
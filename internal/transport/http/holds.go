@@ -0,0 +1,159 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type HoldsAPI struct {
+	q   *sqlc.Queries
+	svc *service.HoldService
+}
+
+func NewHoldsAPI(q *sqlc.Queries, svc *service.HoldService) *HoldsAPI {
+	return &HoldsAPI{q: q, svc: svc}
+}
+
+func (a *HoldsAPI) Routes(r chi.Router) {
+	r.Post("/holds", a.PlaceHold)
+	r.Post("/holds/{id}/capture", a.CaptureHold)
+	r.Post("/holds/{id}/void", a.VoidHold)
+	r.Get("/accounts/{id}/holds", a.ListHolds)
+}
+
+func (a *HoldsAPI) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		AccountID int64     `json:"account_id"`
+		Amount    int64     `json:"amount_minor"`
+		Currency  string    `json:"currency"`
+		Note      string    `json:"note"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if body.AccountID == 0 || body.Currency == "" {
+		http.Error(w, "account_id and currency are required", http.StatusUnprocessableEntity)
+		return
+	}
+
+	dto, err := a.svc.Place(r.Context(), service.PlaceHoldInput{
+		UserID:      uid,
+		AccountID:   body.AccountID,
+		AmountMinor: body.Amount,
+		Currency:    body.Currency,
+		Note:        body.Note,
+		ExpiresAt:   body.ExpiresAt,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+// CaptureHold posts the hold (or part of it) to the ledger. The body is
+// optional; an empty one captures the full amount now.
+func (a *HoldsAPI) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	holdID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || holdID <= 0 {
+		http.Error(w, "invalid hold id", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		Amount     int64     `json:"amount_minor"`
+		OccurredAt time.Time `json:"occurred_at"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+	}
+
+	res, err := a.svc.Capture(r.Context(), service.CaptureHoldInput{
+		UserID:         uid,
+		HoldID:         holdID,
+		AmountMinor:    body.Amount,
+		OccurredAt:     body.OccurredAt,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (a *HoldsAPI) VoidHold(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	holdID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || holdID <= 0 {
+		http.Error(w, "invalid hold id", http.StatusBadRequest)
+		return
+	}
+	dto, err := a.svc.Void(r.Context(), uid, holdID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// ListHolds lists an account's holds, newest first; ?status= narrows to one
+// of pending, captured, voided or expired.
+func (a *HoldsAPI) ListHolds(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || accID <= 0 {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+	if _, err := ownedAccount(r.Context(), a.q, uid, accID); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", "pending", "captured", "voided", "expired":
+	default:
+		http.Error(w, "status must be one of pending, captured, voided, expired", http.StatusBadRequest)
+		return
+	}
+	limit := parseInt(r, "limit", 50)
+	if limit > 200 {
+		limit = 200
+	}
+
+	holds, err := a.svc.List(r.Context(), accID, status, int32(limit))
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"holds": holds})
+}
@@ -0,0 +1,83 @@
+package httptransport
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type PeriodsAPI struct{ svc *service.PeriodService }
+
+func NewPeriodsAPI(svc *service.PeriodService) *PeriodsAPI {
+	return &PeriodsAPI{svc: svc}
+}
+
+func (a *PeriodsAPI) Routes(r chi.Router) {
+	r.Get("/periods", a.List)
+	r.Post("/periods/{month}/close", a.Close)
+	r.Post("/periods/{month}/reopen", a.Reopen)
+}
+
+// List returns the caller's closed months (their own and global ones) and
+// the close/reopen history.
+func (a *PeriodsAPI) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	closed, history, err := a.svc.List(r.Context(), uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"closed": closed, "history": history})
+}
+
+func (a *PeriodsAPI) Close(w http.ResponseWriter, r *http.Request) {
+	uid, month, ok := periodParams(w, r)
+	if !ok {
+		return
+	}
+	p, err := a.svc.Close(r.Context(), uid, month, service.UserActor(uid))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (a *PeriodsAPI) Reopen(w http.ResponseWriter, r *http.Request) {
+	uid, month, ok := periodParams(w, r)
+	if !ok {
+		return
+	}
+	if err := a.svc.Reopen(r.Context(), uid, month, service.UserActor(uid)); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// periodParams reads the caller and the {month} (YYYY-MM) path parameter,
+// writing the error response itself when either is missing or invalid.
+func periodParams(w http.ResponseWriter, r *http.Request) (int64, time.Time, bool) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, time.Time{}, false
+	}
+	month, ok := monthParam(w, r)
+	return uid, month, ok
+}
+
+func monthParam(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	month, err := time.Parse("2006-01", chi.URLParam(r, "month"))
+	if err != nil {
+		http.Error(w, "month must be YYYY-MM", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	return month, true
+}
@@ -0,0 +1,240 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxBankStatementUpload caps an uploaded bank statement.
+const maxBankStatementUpload = 4 << 20
+
+type ReconciliationsAPI struct {
+	svc *service.ReconciliationService
+}
+
+func NewReconciliationsAPI(svc *service.ReconciliationService) *ReconciliationsAPI {
+	return &ReconciliationsAPI{svc: svc}
+}
+
+func (a *ReconciliationsAPI) Routes(r chi.Router) {
+	r.Post("/accounts/{id}/reconciliations", a.Create)
+	r.Get("/accounts/{id}/reconciliations", a.List)
+	r.Get("/reconciliations/{id}", a.Get)
+	r.Delete("/reconciliations/{id}", a.Delete)
+	r.Post("/reconciliations/{id}/auto-match", a.AutoMatch)
+	r.Post("/reconciliations/{id}/complete", a.Complete)
+	r.Put("/reconciliations/{id}/lines/{line_id}/match", a.Match)
+	r.Delete("/reconciliations/{id}/lines/{line_id}/match", a.Unmatch)
+}
+
+// Create uploads a bank statement and auto-matches its lines within
+// ?window_days= (default 3). The body is JSON, or with a CSV Content-Type
+// lines of date,amount_minor[,description] with ?statement_date= and
+// ?closing_balance_minor= in the query.
+func (a *ReconciliationsAPI) Create(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || accountID <= 0 {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+	in := service.ReconciliationInput{
+		UserID:     uid,
+		AccountID:  accountID,
+		WindowDays: parseInt(r, "window_days", service.DefaultMatchWindowDays),
+	}
+	var statementDate, closing string
+	body := io.LimitReader(r.Body, maxBankStatementUpload)
+	if strings.Contains(r.Header.Get("Content-Type"), "csv") {
+		statementDate = r.URL.Query().Get("statement_date")
+		closing = r.URL.Query().Get("closing_balance_minor")
+		if in.Lines, err = service.ParseBankLinesCSV(body); err != nil {
+			http.Error(w, "invalid statement file: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	} else {
+		var req struct {
+			StatementDate string      `json:"statement_date"`
+			Closing       json.Number `json:"closing_balance_minor"`
+			Lines         []struct {
+				Date        string `json:"date"`
+				AmountMinor int64  `json:"amount_minor"`
+				Description string `json:"description"`
+			} `json:"lines"`
+		}
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		statementDate, closing = req.StatementDate, req.Closing.String()
+		for i, l := range req.Lines {
+			d, err := time.Parse("2006-01-02", l.Date)
+			if err != nil {
+				http.Error(w, "line "+strconv.Itoa(i+1)+": date must be YYYY-MM-DD", http.StatusUnprocessableEntity)
+				return
+			}
+			in.Lines = append(in.Lines, service.BankLine{Date: d, AmountMinor: l.AmountMinor, Description: l.Description})
+		}
+	}
+	if in.StatementDate, err = time.Parse("2006-01-02", statementDate); err != nil {
+		http.Error(w, "statement_date must be YYYY-MM-DD", http.StatusUnprocessableEntity)
+		return
+	}
+	if in.ClosingBalance, err = strconv.ParseInt(closing, 10, 64); err != nil {
+		http.Error(w, "closing_balance_minor must be an integer", http.StatusUnprocessableEntity)
+		return
+	}
+
+	report, err := a.svc.Create(r.Context(), in)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, report)
+}
+
+func (a *ReconciliationsAPI) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || accountID <= 0 {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+	out, err := a.svc.List(r.Context(), uid, accountID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (a *ReconciliationsAPI) Get(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := reconciliationParams(w, r)
+	if !ok {
+		return
+	}
+	report, err := a.svc.Get(r.Context(), uid, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (a *ReconciliationsAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := reconciliationParams(w, r)
+	if !ok {
+		return
+	}
+	if err := a.svc.Delete(r.Context(), uid, id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *ReconciliationsAPI) AutoMatch(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := reconciliationParams(w, r)
+	if !ok {
+		return
+	}
+	report, err := a.svc.AutoMatch(r.Context(), uid, id, parseInt(r, "window_days", service.DefaultMatchWindowDays))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (a *ReconciliationsAPI) Complete(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := reconciliationParams(w, r)
+	if !ok {
+		return
+	}
+	report, err := a.svc.Complete(r.Context(), uid, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// Match takes {"posting_id"} for the line.
+func (a *ReconciliationsAPI) Match(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := reconciliationParams(w, r)
+	if !ok {
+		return
+	}
+	lineID, ok := lineParam(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		PostingID int64 `json:"posting_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.PostingID <= 0 {
+		http.Error(w, "posting_id is required", http.StatusBadRequest)
+		return
+	}
+	report, err := a.svc.Match(r.Context(), uid, id, lineID, body.PostingID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (a *ReconciliationsAPI) Unmatch(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := reconciliationParams(w, r)
+	if !ok {
+		return
+	}
+	lineID, ok := lineParam(w, r)
+	if !ok {
+		return
+	}
+	report, err := a.svc.Unmatch(r.Context(), uid, id, lineID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func reconciliationParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid reconciliation id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uid, id, true
+}
+
+func lineParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "line_id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid line id", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
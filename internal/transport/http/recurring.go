@@ -0,0 +1,214 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type RecurringAPI struct{ svc *service.RecurringService }
+
+func NewRecurringAPI(svc *service.RecurringService) *RecurringAPI {
+	return &RecurringAPI{svc: svc}
+}
+
+func (a *RecurringAPI) Routes(r chi.Router) {
+	r.Post("/recurring-rules", a.Create)
+	r.Get("/recurring-rules", a.List)
+	r.Get("/recurring-rules/{id}", a.Get)
+	r.Put("/recurring-rules/{id}", a.Update)
+	r.Delete("/recurring-rules/{id}", a.Delete)
+	r.Post("/recurring-rules/{id}/pause", a.pauseHandler(true))
+	r.Post("/recurring-rules/{id}/resume", a.pauseHandler(false))
+	r.Post("/recurring-rules/{id}/skip", a.Skip)
+}
+
+// ruleBody is the JSON shape of a rule; dates are YYYY-MM-DD.
+type ruleBody struct {
+	Kind        string `json:"kind"`
+	AccountID   int64  `json:"account_id"`
+	ToAccountID int64  `json:"to_account_id"`
+	Amount      int64  `json:"amount_minor"`
+	Currency    string `json:"currency"`
+	Note        string `json:"note"`
+	Frequency   string `json:"frequency"`
+	Interval    int    `json:"interval"`
+	DayOfMonth  int    `json:"day_of_month"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+}
+
+// input converts the body, writing a 400/422 and returning false when a
+// date does not parse.
+func (b ruleBody) input(w http.ResponseWriter, uid int64) (service.RuleInput, bool) {
+	start, err := time.Parse("2006-01-02", b.StartDate)
+	if err != nil {
+		http.Error(w, "start_date must be YYYY-MM-DD", http.StatusUnprocessableEntity)
+		return service.RuleInput{}, false
+	}
+	in := service.RuleInput{
+		UserID:      uid,
+		Kind:        b.Kind,
+		AccountID:   b.AccountID,
+		ToAccountID: b.ToAccountID,
+		AmountMinor: b.Amount,
+		Currency:    b.Currency,
+		Note:        b.Note,
+		Schedule: service.Schedule{
+			Frequency:  b.Frequency,
+			Interval:   b.Interval,
+			DayOfMonth: b.DayOfMonth,
+			Start:      start,
+		},
+	}
+	if in.Schedule.Interval == 0 {
+		in.Schedule.Interval = 1
+	}
+	if b.EndDate != "" {
+		end, err := time.Parse("2006-01-02", b.EndDate)
+		if err != nil {
+			http.Error(w, "end_date must be YYYY-MM-DD", http.StatusUnprocessableEntity)
+			return service.RuleInput{}, false
+		}
+		in.EndDate = &end
+	}
+	return in, true
+}
+
+func (a *RecurringAPI) Create(w http.ResponseWriter, r *http.Request) {
+	var body ruleBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if body.AccountID == 0 || body.Currency == "" || body.Frequency == "" {
+		http.Error(w, "account_id, currency, frequency and start_date are required", http.StatusUnprocessableEntity)
+		return
+	}
+	in, ok := body.input(w, uid)
+	if !ok {
+		return
+	}
+	dto, err := a.svc.Create(r.Context(), in, time.Now())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+func (a *RecurringAPI) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	rules, err := a.svc.List(r.Context(), uid)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"rules": rules})
+}
+
+func (a *RecurringAPI) Get(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	dto, err := a.svc.Get(r.Context(), uid, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// Update replaces amount, note, schedule and end date; kind, accounts and
+// currency in the body are ignored.
+func (a *RecurringAPI) Update(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	var body ruleBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	in, ok := body.input(w, uid)
+	if !ok {
+		return
+	}
+	dto, err := a.svc.Update(r.Context(), id, in, time.Now())
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+func (a *RecurringAPI) Delete(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	if err := a.svc.Delete(r.Context(), uid, id); err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *RecurringAPI) pauseHandler(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, id, ok := ruleParams(w, r)
+		if !ok {
+			return
+		}
+		dto, err := a.svc.SetPaused(r.Context(), uid, id, paused, time.Now())
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, dto)
+	}
+}
+
+func (a *RecurringAPI) Skip(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := ruleParams(w, r)
+	if !ok {
+		return
+	}
+	dto, err := a.svc.Skip(r.Context(), uid, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// ruleParams reads the caller and the {id} path parameter, writing the
+// error response itself when either is missing or invalid.
+func ruleParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid rule id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uid, id, true
+}
@@ -0,0 +1,166 @@
+package httptransport
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/go-chi/chi/v5"
+)
+
+type ReportsAPI struct{ svc *service.ReportService }
+
+func NewReportsAPI(svc *service.ReportService) *ReportsAPI { return &ReportsAPI{svc: svc} }
+
+func (a *ReportsAPI) Routes(r chi.Router) {
+	r.Get("/reports/trial-balance", a.TrialBalance)
+	r.Get("/reports/balance-sheet", a.BalanceSheet)
+	r.Get("/reports/income-statement", a.IncomeStatement)
+	r.Get("/reports/cash-flow", a.CashFlow)
+}
+
+// inlineStatementMonths caps income statements served as JSON; longer ranges
+// go through a CSV export.
+const inlineStatementMonths = 24
+
+// TrialBalance reports every account as of ?as_of= (a date includes that
+// whole day; default now). A ledger that does not net to zero is served as
+// a 500 with the report, so the offending currency is visible.
+func (a *ReportsAPI) TrialBalance(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	asOf, ok := parseAsOf(w, r)
+	if !ok {
+		return
+	}
+	tb, err := a.svc.TrialBalance(r.Context(), uid, asOf)
+	if errors.Is(err, service.ErrLedgerImbalance) {
+		writeJSON(w, http.StatusInternalServerError, tb)
+		return
+	}
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, tb)
+}
+
+// BalanceSheet reports assets, liabilities and equity as of ?as_of=, with
+// the same cutoff rules and imbalance handling as the trial balance.
+func (a *ReportsAPI) BalanceSheet(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	asOf, ok := parseAsOf(w, r)
+	if !ok {
+		return
+	}
+	bs, err := a.svc.BalanceSheet(r.Context(), uid, asOf)
+	if errors.Is(err, service.ErrLedgerImbalance) {
+		writeJSON(w, http.StatusInternalServerError, bs)
+		return
+	}
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, bs)
+}
+
+// IncomeStatement reports income less expenses over ?from=&to= with one
+// column per month. Ranges over inlineStatementMonths are refused with a
+// pointer to the CSV export.
+func (a *ReportsAPI) IncomeStatement(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	from, to, ok := parseStatementRange(w, r)
+	if !ok {
+		return
+	}
+	if n := len(service.StatementMonths(from, to)); n > inlineStatementMonths {
+		http.Error(w, fmt.Sprintf("%d months is too long to serve inline (limit %d); use POST /v1/reports/income-statement/exports",
+			n, inlineStatementMonths), http.StatusUnprocessableEntity)
+		return
+	}
+	is, err := a.svc.IncomeStatement(r.Context(), uid, from, to)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, is)
+}
+
+// CashFlow reports money in and out per ?interval=month|week over
+// ?from=&to=, across all of the caller's asset and liability accounts or
+// those given as account_id (repeatable or comma-separated). Transfers
+// between the caller's own accounts are not counted.
+func (a *ReportsAPI) CashFlow(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	from, to, ok := parseStatementRange(w, r)
+	if !ok {
+		return
+	}
+	ids, err := parseAccountIDs(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cf, err := a.svc.CashFlow(r.Context(), service.CashFlowInput{
+		UserID: uid, From: from, To: to, Interval: r.URL.Query().Get("interval"), AccountIDs: ids,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cf)
+}
+
+// parseStatementRange reads ?from= (required) and ?to= (default now) as the
+// half-open range [from, to); a date as to covers that whole day. It writes a
+// 400 and returns false when either does not parse.
+func parseStatementRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+	q := r.URL.Query()
+	from, err := parseTimeParam(q.Get("from"), false)
+	if err != nil || from.IsZero() {
+		http.Error(w, "from is required as YYYY-MM-DD or RFC 3339", http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := parseTimeParam(q.Get("to"), true)
+	if err != nil {
+		http.Error(w, "to must be YYYY-MM-DD or RFC 3339", http.StatusBadRequest)
+		return time.Time{}, time.Time{}, false
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	return from, to, true
+}
+
+// parseAsOf reads ?as_of= as an exclusive cutoff: a date covers that whole
+// day, RFC 3339 is taken as is, and empty means now. It writes a 400 and
+// returns false when the value does not parse.
+func parseAsOf(w http.ResponseWriter, r *http.Request) (time.Time, bool) {
+	asOf, err := parseTimeParam(r.URL.Query().Get("as_of"), true)
+	if err != nil {
+		http.Error(w, "as_of must be YYYY-MM-DD or RFC 3339", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	return asOf, true
+}
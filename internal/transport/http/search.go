@@ -0,0 +1,171 @@
+package httptransport
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+)
+
+// SearchTransactions lists the caller's transactions across all accounts,
+// with their postings: newest first, or by relevance when q is given. See
+// parseSearchFilter for the query parameters; pages continue via
+// next_cursor.
+func (a *TransactionsAPI) SearchTransactions(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	f, err := parseSearchFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.UserID = uid
+
+	txs, err := a.svc.Search(r.Context(), f)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	next := ""
+	if len(txs) == int(f.Limit) {
+		last := txs[len(txs)-1]
+		if f.Query != "" {
+			next = encodeSearchCursor(last.Rank, last.OccurredAt, last.ID)
+		} else {
+			next = encodeCursor(last.OccurredAt, last.ID)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transactions": txs, "next_cursor": next})
+}
+
+// parseSearchFilter reads the search query string:
+//
+//	from, to          YYYY-MM-DD (to inclusive) or RFC 3339 (to exclusive)
+//	account_id        repeatable, or comma-separated
+//	min_amount, max_amount   absolute amount in minor units
+//	category_id       includes sub-categories and split lines
+//	reversal          reversed | reversal | none
+//	note              case-insensitive substring
+//	q                 full-text words over payee and note, prefix-matched
+//	limit, cursor     page size (default 20, max 100) and keyset cursor
+func parseSearchFilter(r *http.Request) (service.SearchFilter, error) {
+	q := r.URL.Query()
+	var f service.SearchFilter
+	var err error
+
+	if f.From, err = parseTimeParam(q.Get("from"), false); err != nil {
+		return f, errors.New("from must be YYYY-MM-DD or RFC 3339")
+	}
+	if f.To, err = parseTimeParam(q.Get("to"), true); err != nil {
+		return f, errors.New("to must be YYYY-MM-DD or RFC 3339")
+	}
+	if f.AccountIDs, err = parseAccountIDs(q); err != nil {
+		return f, err
+	}
+	for key, dst := range map[string]**int64{"min_amount": &f.MinAmount, "max_amount": &f.MaxAmount} {
+		if s := q.Get(key); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || n < 0 {
+				return f, errors.New(key + " must be a non-negative integer")
+			}
+			*dst = &n
+		}
+	}
+	if s := q.Get("category_id"); s != "" {
+		if f.CategoryID, err = strconv.ParseInt(s, 10, 64); err != nil || f.CategoryID <= 0 {
+			return f, errors.New("invalid category_id")
+		}
+	}
+	switch f.Reversal = q.Get("reversal"); f.Reversal {
+	case "", "reversed", "reversal", "none":
+	default:
+		return f, errors.New("reversal must be one of reversed, reversal, none")
+	}
+	f.Note = strings.TrimSpace(q.Get("note"))
+	f.Query = strings.TrimSpace(q.Get("q"))
+
+	limit := parseInt(r, "limit", 20)
+	if limit > 100 {
+		limit = 100
+	}
+	if limit == 0 {
+		limit = 20
+	}
+	f.Limit = int32(limit)
+	if f.CursorRank, f.CursorOccurredAt, f.CursorID, err = decodeSearchCursor(q.Get("cursor")); err != nil {
+		return f, errors.New("invalid cursor")
+	}
+	return f, nil
+}
+
+// encodeSearchCursor extends the plain cursor with the last hit's rank for
+// relevance-ordered pages. The rank is formatted as a float32 so it reads
+// back bit-identical for the keyset comparison.
+func encodeSearchCursor(rank float32, t time.Time, id int64) string {
+	raw := fmt.Sprintf("%s~%s|%d", strconv.FormatFloat(float64(rank), 'g', -1, 32), t.UTC().Format(time.RFC3339Nano), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeSearchCursor reads both cursor forms. A plain cursor has rank 0;
+// the empty cursor ranks above every row.
+func decodeSearchCursor(s string) (float32, time.Time, int64, error) {
+	if s == "" {
+		t, id, err := decodeCursor("")
+		return float32(math.Inf(1)), t, id, err
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, time.Time{}, 0, err
+	}
+	var rank float32
+	if before, rest, ok := strings.Cut(string(raw), "~"); ok {
+		r, err := strconv.ParseFloat(before, 32)
+		if err != nil {
+			return 0, time.Time{}, 0, err
+		}
+		rank, s = float32(r), base64.RawURLEncoding.EncodeToString([]byte(rest))
+	}
+	t, id, err := decodeCursor(s)
+	return rank, t, id, err
+}
+
+// parseTimeParam accepts RFC 3339 or a plain date (UTC midnight). With
+// endOfDay a plain date means the whole day, so the next midnight is
+// returned for use as an exclusive bound. Empty yields the zero time.
+func parseTimeParam(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.Parse("2006-01-02", s); err == nil {
+		if endOfDay {
+			d = d.AddDate(0, 0, 1)
+		}
+		return d, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// parseAccountIDs reads account_id, repeatable or comma-separated.
+func parseAccountIDs(q url.Values) ([]int64, error) {
+	var ids []int64
+	for _, v := range q["account_id"] {
+		for _, s := range strings.Split(v, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil || id <= 0 {
+				return nil, errors.New("invalid account_id")
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,69 @@
+package httptransport
+
+import (
+	"math"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSearchFilter(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/transactions?from=2026-03-01&to=2026-03-31&account_id=4,5&account_id=9"+
+		"&min_amount=100&max_amount=5000&category_id=7&reversal=none&note=+amazon+&q=refund+march&limit=500", nil)
+	f, err := parseSearchFilter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.From.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)) || !f.To.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("date range = [%v, %v); a plain to date must include the whole day", f.From, f.To)
+	}
+	if !reflect.DeepEqual(f.AccountIDs, []int64{4, 5, 9}) {
+		t.Fatalf("account ids = %v", f.AccountIDs)
+	}
+	if f.MinAmount == nil || *f.MinAmount != 100 || f.MaxAmount == nil || *f.MaxAmount != 5000 {
+		t.Fatalf("amount range = %v, %v", f.MinAmount, f.MaxAmount)
+	}
+	if f.CategoryID != 7 || f.Reversal != "none" || f.Note != "amazon" || f.Query != "refund march" || f.Limit != 100 {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+
+	rfc := httptest.NewRequest("GET", "/v1/transactions?to=2026-03-31T12:00:00Z", nil)
+	if f, err := parseSearchFilter(rfc); err != nil || !f.To.Equal(time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("RFC 3339 to must be used as-is, got %v, %v", f.To, err)
+	}
+}
+
+func TestParseSearchFilterRejectsBadInput(t *testing.T) {
+	for _, qs := range []string{
+		"from=yesterday",
+		"account_id=abc",
+		"account_id=-1",
+		"min_amount=-5",
+		"category_id=0",
+		"reversal=maybe",
+		"cursor=!!!",
+	} {
+		r := httptest.NewRequest("GET", "/v1/transactions?"+qs, nil)
+		if _, err := parseSearchFilter(r); err == nil {
+			t.Errorf("%s must be rejected", qs)
+		}
+	}
+}
+
+func TestSearchCursorRoundTrip(t *testing.T) {
+	at := time.Date(2026, 3, 14, 8, 0, 0, 0, time.UTC)
+	rank := float32(0.0607927)
+	gotRank, gotT, gotID, err := decodeSearchCursor(encodeSearchCursor(rank, at, 42))
+	if err != nil || gotRank != rank || !gotT.Equal(at) || gotID != 42 {
+		t.Fatalf("got (%v, %v, %d, %v)", gotRank, gotT, gotID, err)
+	}
+
+	// Plain cursors from unranked pages still decode, at rank 0.
+	if gotRank, _, gotID, err := decodeSearchCursor(encodeCursor(at, 42)); err != nil || gotRank != 0 || gotID != 42 {
+		t.Fatalf("plain cursor: (%v, %d, %v)", gotRank, gotID, err)
+	}
+	if gotRank, _, _, _ := decodeSearchCursor(""); !math.IsInf(float64(gotRank), 1) {
+		t.Fatalf("first page must rank above every row, got %v", gotRank)
+	}
+}
@@ -0,0 +1,165 @@
+package httptransport
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/EftikharAzim/ledgerx/internal/worker"
+	"github.com/go-chi/chi/v5"
+	"github.com/hibiken/asynq"
+)
+
+type StatementsAPI struct {
+	svc    *service.StatementService
+	client *asynq.Client
+}
+
+func NewStatementsAPI(svc *service.StatementService, redisAddr string) *StatementsAPI {
+	return &StatementsAPI{
+		svc:    svc,
+		client: asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr}),
+	}
+}
+
+func (a *StatementsAPI) Routes(r chi.Router) {
+	r.Post("/accounts/{id}/statements", a.Create)
+	r.Get("/statements", a.List)
+	r.Get("/statements/{id}", a.Get)
+	r.Get("/statements/{id}/download", a.Download)
+}
+
+// Create queues a statement of the account for ?month=YYYY-MM or for the
+// days ?from=YYYY-MM-DD to ?to=YYYY-MM-DD inclusive. Requesting a period
+// again regenerates it.
+func (a *StatementsAPI) Create(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	accountID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || accountID <= 0 {
+		http.Error(w, "invalid account id", http.StatusBadRequest)
+		return
+	}
+	q := r.URL.Query()
+	var start, end time.Time
+	if m := q.Get("month"); m != "" {
+		if start, err = time.Parse("2006-01", m); err != nil {
+			http.Error(w, "month must be YYYY-MM", http.StatusBadRequest)
+			return
+		}
+		end = start.AddDate(0, 1, -1)
+	} else {
+		start, err = time.Parse("2006-01-02", q.Get("from"))
+		if err != nil {
+			http.Error(w, "month (YYYY-MM) or from and to (YYYY-MM-DD) are required", http.StatusBadRequest)
+			return
+		}
+		if end, err = time.Parse("2006-01-02", q.Get("to")); err != nil {
+			http.Error(w, "to must be YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	st, err := a.svc.Request(r.Context(), uid, accountID, start, end)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	task := worker.NewTaskStatementGenerate(worker.StatementGeneratePayload{StatementID: st.ID})
+	if _, err := a.client.Enqueue(task); err != nil {
+		http.Error(w, "enqueue failed", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, st)
+}
+
+// List returns the caller's statements, newest period first; ?account_id=
+// narrows to one account.
+func (a *StatementsAPI) List(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var accountID int64
+	if v := r.URL.Query().Get("account_id"); v != "" {
+		var err error
+		if accountID, err = strconv.ParseInt(v, 10, 64); err != nil || accountID <= 0 {
+			http.Error(w, "invalid account_id", http.StatusBadRequest)
+			return
+		}
+	}
+	limit := parseInt(r, "limit", 20)
+	offset := parseInt(r, "offset", 0)
+	out, err := a.svc.List(r.Context(), uid, accountID, int32(limit), int32(offset))
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (a *StatementsAPI) Get(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := statementParams(w, r)
+	if !ok {
+		return
+	}
+	st, err := a.svc.Get(r.Context(), uid, id)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+// Download streams a generated statement as ?format=csv (default) or html.
+// The HTML is laid out for printing, so a browser's "Save as PDF" gives the
+// PDF rendering.
+func (a *StatementsAPI) Download(w http.ResponseWriter, r *http.Request) {
+	uid, id, ok := statementParams(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	contentType := "text/csv"
+	switch format {
+	case "", service.StatementCSV:
+		format = service.StatementCSV
+	case service.StatementHTML:
+		contentType = "text/html; charset=utf-8"
+	default:
+		http.Error(w, "format must be csv or html", http.StatusBadRequest)
+		return
+	}
+	body, err := a.svc.Open(r.Context(), uid, id, format)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	defer func() { _ = body.Close() }()
+	w.Header().Set("Content-Type", contentType)
+	if format == service.StatementCSV {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=statement_%d.csv", id))
+	}
+	_, _ = io.Copy(w, body)
+}
+
+func statementParams(w http.ResponseWriter, r *http.Request) (int64, int64, bool) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid statement id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return uid, id, true
+}
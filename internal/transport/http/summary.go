@@ -13,14 +13,37 @@ import (
 type SummaryAPI struct {
 	q   *sqlc.Queries
 	svc *service.SummaryService
+	fx  *service.FXService
 }
 
-func NewSummaryAPI(q *sqlc.Queries, svc *service.SummaryService) *SummaryAPI {
-	return &SummaryAPI{q: q, svc: svc}
+func NewSummaryAPI(q *sqlc.Queries, svc *service.SummaryService, fx *service.FXService) *SummaryAPI {
+	return &SummaryAPI{q: q, svc: svc, fx: fx}
 }
 
 func (a *SummaryAPI) Routes(r chi.Router) {
 	r.Get("/accounts/{id}/summary", a.GetSummary)
+	r.Get("/summary/categories", a.GetCategorySummary)
+}
+
+// GetCategorySummary totals ?month=YYYY-MM per line category and currency
+// across all of the caller's accounts.
+func (a *SummaryAPI) GetCategorySummary(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	month, err := time.Parse("2006-01", r.URL.Query().Get("month"))
+	if err != nil {
+		http.Error(w, "month must be YYYY-MM", http.StatusBadRequest)
+		return
+	}
+	totals, err := a.svc.GetCategorySummary(r.Context(), uid, month)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"month": month.Format("2006-01"), "categories": totals})
 }
 
 func (a *SummaryAPI) GetSummary(w http.ResponseWriter, r *http.Request) {
@@ -40,15 +63,82 @@ func (a *SummaryAPI) GetSummary(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "month must be YYYY-MM", http.StatusBadRequest)
 		return
 	}
-	if _, err := ownedAccount(r.Context(), a.q, uid, id); err != nil {
+	acc, err := ownedAccount(r.Context(), a.q, uid, id)
+	if err != nil {
 		writeServiceError(w, err)
 		return
 	}
+	in, ok := parseCurrency(w, r)
+	if !ok {
+		return
+	}
+	rollup, ok := parseRollup(w, r)
+	if !ok {
+		return
+	}
+	if rollup {
+		a.getSubtreeSummary(w, r, id, month, in)
+		return
+	}
 
 	s, err := a.svc.GetMonthlySummary(r.Context(), id, month)
 	if err != nil {
 		http.Error(w, "db error", http.StatusInternalServerError)
 		return
 	}
-	writeJSON(w, http.StatusOK, s)
+	if in == "" {
+		writeJSON(w, http.StatusOK, s)
+		return
+	}
+
+	// A month's flows convert at the rate effective on its last day (or
+	// today, for the current month).
+	rateDay := month.AddDate(0, 1, -1)
+	if now := time.Now().UTC(); rateDay.After(now) {
+		rateDay = now
+	}
+	conv, err := a.fx.ConvertSummary(r.Context(), s, acc.Currency, in, rateDay)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		service.MonthlySummary
+		Currency  string                   `json:"currency"`
+		Converted service.ConvertedSummary `json:"converted"`
+	}{s, acc.Currency, conv})
+}
+
+// getSubtreeSummary answers ?rollup=true: the month's flows over the account
+// and every account below it, per currency. With ?in= the net flows are
+// also converted and totalled.
+func (a *SummaryAPI) getSubtreeSummary(w http.ResponseWriter, r *http.Request, id int64, month time.Time, in string) {
+	sums, err := a.svc.GetSubtreeSummary(r.Context(), id, month)
+	if err != nil {
+		http.Error(w, "db error", http.StatusInternalServerError)
+		return
+	}
+	resp := map[string]any{
+		"account_id": id,
+		"month":      month.Format("2006-01"),
+		"rollup":     true,
+		"currencies": sums,
+	}
+	if in != "" {
+		rateDay := month.AddDate(0, 1, -1)
+		if now := time.Now().UTC(); rateDay.After(now) {
+			rateDay = now
+		}
+		nets := make([]service.CurrencyAmount, 0, len(sums))
+		for _, cs := range sums {
+			nets = append(nets, service.CurrencyAmount{Currency: cs.Currency, AmountMinor: cs.Net})
+		}
+		conv, err := a.fx.ConvertTotal(r.Context(), nets, in, rateDay)
+		if err != nil {
+			writeServiceError(w, err)
+			return
+		}
+		resp["converted_net"] = conv
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
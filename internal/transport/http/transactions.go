@@ -27,8 +27,15 @@ func NewTransactionsAPI(q *sqlc.Queries, svc *service.TransactionService) *Trans
 
 func (a *TransactionsAPI) Routes(r chi.Router) {
 	r.Post("/transactions", a.CreateTransaction)
+	r.Get("/transactions", a.SearchTransactions)
+	r.Get("/transactions/{id}", a.GetTransaction)
 	r.Post("/transactions/{id}/reverse", a.ReverseTransaction)
+	r.Post("/transactions/{id}/amend", a.AmendTransaction)
+	r.Post("/transactions/{id}/refund", a.RefundTransaction)
+	r.Put("/transactions/{id}/category", a.SetCategory)
+	r.Get("/transactions/{id}/category-history", a.CategoryHistory)
 	r.Post("/transfers", a.CreateTransfer)
+	r.Post("/journal-entries", a.CreateJournalEntry)
 	r.Get("/accounts/{id}/transactions", a.ListAccountEntries)
 }
 
@@ -55,13 +62,148 @@ func (a *TransactionsAPI) ReverseTransaction(w http.ResponseWriter, r *http.Requ
 	writeJSON(w, http.StatusCreated, dto)
 }
 
+// AmendTransaction reverses a transaction and books its corrected
+// replacement atomically. Omitted fields keep the original's values.
+func (a *TransactionsAPI) AmendTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, txID, ok := transactionParams(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Legs        []service.Leg `json:"legs"`
+		AmountMinor int64         `json:"amount_minor"`
+		OccurredAt  time.Time     `json:"occurred_at"`
+		Note        *string       `json:"note"`
+		Payee       *string       `json:"payee"`
+		CategoryID  *int64        `json:"category_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	res, err := a.svc.Amend(r.Context(), service.AmendInput{
+		UserID:         uid,
+		TransactionID:  txID,
+		Legs:           body.Legs,
+		AmountMinor:    body.AmountMinor,
+		OccurredAt:     body.OccurredAt,
+		Note:           body.Note,
+		Payee:          body.Payee,
+		CategoryID:     body.CategoryID,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, res)
+}
+
+// RefundTransaction reverses part of a transaction: amount_minor for the
+// simple cases, or explicit lines per posting.
+func (a *TransactionsAPI) RefundTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, txID, ok := transactionParams(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		AmountMinor int64                `json:"amount_minor"`
+		Lines       []service.RefundLine `json:"lines"`
+		Note        string               `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	dto, err := a.svc.Refund(r.Context(), service.RefundInput{
+		UserID:         uid,
+		TransactionID:  txID,
+		AmountMinor:    body.AmountMinor,
+		Lines:          body.Lines,
+		Note:           body.Note,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+// GetTransaction returns one transaction with its postings, reversal link,
+// category, attachments and the outbox events it emitted.
+func (a *TransactionsAPI) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	uid, txID, ok := transactionParams(w, r)
+	if !ok {
+		return
+	}
+	d, err := a.svc.Detail(r.Context(), uid, txID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// SetCategory re-categorizes a transaction; {"category_id": null} or 0
+// clears it. Every change is kept in the transaction's category history.
+func (a *TransactionsAPI) SetCategory(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	txID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || txID <= 0 {
+		http.Error(w, "invalid transaction id", http.StatusBadRequest)
+		return
+	}
+	var body struct {
+		CategoryID int64 `json:"category_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	change, err := a.svc.Recategorize(r.Context(), service.RecategorizeInput{
+		UserID: uid, TransactionID: txID, CategoryID: body.CategoryID,
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, change)
+}
+
+func (a *TransactionsAPI) CategoryHistory(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	txID, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil || txID <= 0 {
+		http.Error(w, "invalid transaction id", http.StatusBadRequest)
+		return
+	}
+	changes, err := a.svc.CategoryHistory(r.Context(), uid, txID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"changes": changes})
+}
+
 func (a *TransactionsAPI) CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		AccountID  int64     `json:"account_id"`
-		Amount     int64     `json:"amount_minor"`
-		Currency   string    `json:"currency"`
-		OccurredAt time.Time `json:"occurred_at"`
-		Note       string    `json:"note"`
+		AccountID  int64           `json:"account_id"`
+		Amount     int64           `json:"amount_minor"`
+		Currency   string          `json:"currency"`
+		OccurredAt time.Time       `json:"occurred_at"`
+		Note       string          `json:"note"`
+		Payee      string          `json:"payee"`
+		CategoryID int64           `json:"category_id"`
+		Splits     []service.Split `json:"splits"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -84,6 +226,9 @@ func (a *TransactionsAPI) CreateTransaction(w http.ResponseWriter, r *http.Reque
 		Currency:       body.Currency,
 		OccurredAt:     body.OccurredAt,
 		Note:           body.Note,
+		Payee:          body.Payee,
+		CategoryID:     body.CategoryID,
+		Splits:         body.Splits,
 		IdempotencyKey: r.Header.Get("Idempotency-Key"),
 	})
 	if err != nil {
@@ -99,8 +244,11 @@ func (a *TransactionsAPI) CreateTransfer(w http.ResponseWriter, r *http.Request)
 		ToAccountID   int64     `json:"to_account_id"`
 		Amount        int64     `json:"amount_minor"`
 		Currency      string    `json:"currency"`
+		ToCurrency    string    `json:"to_currency"`
+		FXRate        string    `json:"fx_rate"`
 		OccurredAt    time.Time `json:"occurred_at"`
 		Note          string    `json:"note"`
+		CategoryID    int64     `json:"category_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
@@ -122,8 +270,55 @@ func (a *TransactionsAPI) CreateTransfer(w http.ResponseWriter, r *http.Request)
 		ToAccountID:    body.ToAccountID,
 		AmountMinor:    body.Amount,
 		Currency:       body.Currency,
+		ToCurrency:     body.ToCurrency,
+		FXRate:         body.FXRate,
+		OccurredAt:     body.OccurredAt,
+		Note:           body.Note,
+		CategoryID:     body.CategoryID,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+	})
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+func (a *TransactionsAPI) CreateJournalEntry(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Legs       []service.Leg `json:"legs"`
+		Currency   string        `json:"currency"`
+		OccurredAt time.Time     `json:"occurred_at"`
+		Note       string        `json:"note"`
+		CategoryID int64         `json:"category_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+	uid, ok := r.Context().Value(UserIDKey).(int64)
+	if !ok || uid == 0 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if len(body.Legs) == 0 || body.Currency == "" || body.OccurredAt.IsZero() {
+		http.Error(w, "legs, currency and occurred_at are required", http.StatusUnprocessableEntity)
+		return
+	}
+	for _, l := range body.Legs {
+		if l.AccountID == 0 {
+			http.Error(w, "every leg needs an account_id", http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	dto, err := a.svc.Journal(r.Context(), service.JournalInput{
+		UserID:         uid,
+		Legs:           body.Legs,
+		Currency:       body.Currency,
 		OccurredAt:     body.OccurredAt,
 		Note:           body.Note,
+		CategoryID:     body.CategoryID,
 		IdempotencyKey: r.Header.Get("Idempotency-Key"),
 	})
 	if err != nil {
@@ -204,8 +399,9 @@ func (a *TransactionsAPI) ListAccountEntries(w http.ResponseWriter, r *http.Requ
 	writeJSON(w, http.StatusOK, map[string]any{"entries": entries, "next_cursor": next})
 }
 
-// Cursor is base64("<RFC3339Nano occurred_at>|<posting id>"); an empty cursor
-// means the first (newest) page.
+// Cursor is base64("<RFC3339Nano occurred_at>|<row id>") — a posting id for
+// account entries, a transaction id for search; an empty cursor means the
+// first (newest) page.
 func encodeCursor(t time.Time, id int64) string {
 	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s|%d", t.UTC().Format(time.RFC3339Nano), id)))
 }
@@ -238,18 +434,62 @@ func decodeCursor(s string) (time.Time, int64, error) {
 func writeServiceError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, service.ErrAccountNotFound),
-		errors.Is(err, service.ErrTxNotFound):
+		errors.Is(err, service.ErrTxNotFound),
+		errors.Is(err, service.ErrHoldNotFound),
+		errors.Is(err, service.ErrRuleNotFound),
+		errors.Is(err, service.ErrCategoryNotFound),
+		errors.Is(err, service.ErrBudgetNotFound),
+		errors.Is(err, service.ErrAttachmentNotFound),
+		errors.Is(err, service.ErrStatementNotFound),
+		errors.Is(err, service.ErrReconciliationNotFound):
 		http.Error(w, err.Error(), http.StatusNotFound)
 	case errors.Is(err, service.ErrAlreadyReversed),
-		errors.Is(err, service.ErrIsReversal):
+		errors.Is(err, service.ErrAmendRefunded),
+		errors.Is(err, service.ErrIsReversal),
+		errors.Is(err, service.ErrHoldNotPending),
+		errors.Is(err, service.ErrCategoryExists),
+		errors.Is(err, service.ErrCategoryInUse),
+		errors.Is(err, service.ErrBudgetExists),
+		errors.Is(err, service.ErrPeriodClosed),
+		errors.Is(err, service.ErrPeriodAlreadyClosed),
+		errors.Is(err, service.ErrPeriodNotClosed),
+		errors.Is(err, service.ErrAccountExists),
+		errors.Is(err, service.ErrStatementNotReady),
+		errors.Is(err, service.ErrReconciliationCompleted),
+		errors.Is(err, service.ErrPostingMatched):
 		http.Error(w, err.Error(), http.StatusConflict)
 	case errors.Is(err, service.ErrForbidden):
 		http.Error(w, "forbidden", http.StatusForbidden)
 	case errors.Is(err, service.ErrAccountInactive),
 		errors.Is(err, service.ErrCurrencyMismatch),
 		errors.Is(err, service.ErrUnbalanced),
-		errors.Is(err, service.ErrZeroAmount):
+		errors.Is(err, service.ErrZeroAmount),
+		errors.Is(err, service.ErrSplitMismatch),
+		errors.Is(err, service.ErrInvalidRate),
+		errors.Is(err, service.ErrRateNotFound),
+		errors.Is(err, service.ErrHoldAmount),
+		errors.Is(err, service.ErrHoldAccount),
+		errors.Is(err, service.ErrHoldExpiry),
+		errors.Is(err, service.ErrInsufficientFunds),
+		errors.Is(err, service.ErrInvalidLimits),
+		errors.Is(err, service.ErrInvalidRule),
+		errors.Is(err, service.ErrInvalidSchedule),
+		errors.Is(err, service.ErrCategoryCycle),
+		errors.Is(err, service.ErrCategoryName),
+		errors.Is(err, service.ErrInvalidBudget),
+		errors.Is(err, service.ErrAttachmentEmpty),
+		errors.Is(err, service.ErrOverRefund),
+		errors.Is(err, service.ErrRefundPosting),
+		errors.Is(err, service.ErrAccountType),
+		errors.Is(err, service.ErrAccountParent),
+		errors.Is(err, service.ErrAccountCycle),
+		errors.Is(err, service.ErrReportRange),
+		errors.Is(err, service.ErrInvalidReconciliation),
+		errors.Is(err, service.ErrSearchQuery),
+		errors.Is(err, service.ErrReconciliationDifference):
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, service.ErrAttachmentType):
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
 	case errors.Is(err, service.ErrIdempotencyConflict):
 		http.Error(w, err.Error(), http.StatusConflict)
 	case errors.Is(err, service.ErrIdempotencyInFlight):
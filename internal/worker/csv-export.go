@@ -37,7 +37,7 @@ func (s *Server) handleExportCSV(ctx context.Context, t *asynq.Task) error {
 	// seekable buffer is also what the S3 client needs for signing.
 	var buf bytes.Buffer
 	w := csv.NewWriter(&buf)
-	_ = w.Write([]string{"transaction_id", "account_id", "account_name", "category_id", "amount_minor", "currency", "occurred_at", "note"})
+	_ = w.Write([]string{"transaction_id", "account_id", "account_name", "account_type", "category_id", "amount_minor", "currency", "occurred_at", "note", "attachment_ids"})
 	for _, tr := range rows {
 		cat := ""
 		if tr.CategoryID.Valid {
@@ -51,11 +51,13 @@ func (s *Server) handleExportCSV(ctx context.Context, t *asynq.Task) error {
 			fmt.Sprint(tr.TransactionID),
 			fmt.Sprint(tr.AccountID),
 			tr.AccountName,
+			tr.AccountType,
 			cat,
 			fmt.Sprint(tr.AmountMinor),
 			tr.Currency,
 			tr.OccurredAt.Format(time.RFC3339),
 			note,
+			tr.AttachmentIds,
 		})
 	}
 	w.Flush()
@@ -0,0 +1,15 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// handleHoldsExpire flips every pending hold past its expires_at to
+// 'expired'. The update is a single statement, so rerunning it is harmless.
+func (s *Server) handleHoldsExpire(ctx context.Context, _ *asynq.Task) error {
+	_, err := s.q.ExpireHolds(ctx, time.Now().UTC())
+	return err
+}
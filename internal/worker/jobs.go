@@ -12,7 +12,16 @@ const (
 	TypeSnapshotAccount = "snapshot:account"
 )
 const (
-	TypeExportCSV = "export:csv"
+	TypeExportCSV    = "export:csv"
+	TypeExportReport = "export:report"
+)
+const (
+	TypeHoldsExpire  = "holds:expire"
+	TypeRecurringRun = "recurring:run"
+)
+const (
+	TypeStatementGenerate = "statement:generate"
+	TypeStatementsMonthly = "statements:monthly"
 )
 
 type SnapshotAllPayload struct {
@@ -27,6 +36,20 @@ type ExportCSVPayload struct {
 	UserID   int64  `json:"user_id"`
 	Month    string `json:"month"` // YYYY-MM-01
 }
+type ExportReportPayload struct {
+	ExportID int64  `json:"export_id"`
+	UserID   int64  `json:"user_id"`
+	Kind     string `json:"kind"`           // balance_sheet | income_statement
+	From     string `json:"from,omitempty"` // RFC 3339; income statements only
+	To       string `json:"to"`             // RFC 3339, exclusive
+}
+
+type StatementGeneratePayload struct {
+	StatementID int64 `json:"statement_id"`
+}
+type StatementsMonthlyPayload struct {
+	Month string `json:"month"` // YYYY-MM; empty = last month (UTC)
+}
 
 func MustJSON(v any) []byte {
 	b, err := json.Marshal(v)
@@ -42,3 +65,6 @@ func NewTaskSnapshotAll(p SnapshotAllPayload) *asynq.Task {
 func NewTaskSnapshotAccount(p SnapshotAccountPayload) *asynq.Task {
 	return asynq.NewTask(TypeSnapshotAccount, MustJSON(p))
 }
+func NewTaskStatementGenerate(p StatementGeneratePayload) *asynq.Task {
+	return asynq.NewTask(TypeStatementGenerate, MustJSON(p))
+}
@@ -0,0 +1,16 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// handleRecurringRun books every recurring-rule occurrence due today or
+// earlier. Occurrences carry deterministic idempotency keys, so a retry
+// after a partial run books nothing twice.
+func (s *Server) handleRecurringRun(ctx context.Context, _ *asynq.Task) error {
+	_, err := s.recurring.RunDue(ctx, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// handleExportReport renders a balance sheet or income statement as CSV and
+// stores it like a postings export, so it is fetched from the same download
+// endpoint.
+func (s *Server) handleExportReport(ctx context.Context, t *asynq.Task) error {
+	var p ExportReportPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+	to, err := time.Parse(time.RFC3339, p.To)
+	if err != nil {
+		s.failExport(ctx, p.ExportID)
+		return err
+	}
+
+	reports := service.NewReportService(s.q)
+	var buf bytes.Buffer
+	switch p.Kind {
+	case "balance_sheet":
+		// An imbalanced sheet is still written; its rows show the gap.
+		bs, err := reports.BalanceSheet(ctx, p.UserID, to)
+		if err == nil || errors.Is(err, service.ErrLedgerImbalance) {
+			err = bs.WriteCSV(&buf)
+		}
+		if err != nil {
+			s.failExport(ctx, p.ExportID)
+			return err
+		}
+	case "income_statement":
+		from, err := time.Parse(time.RFC3339, p.From)
+		if err != nil {
+			s.failExport(ctx, p.ExportID)
+			return err
+		}
+		is, err := reports.IncomeStatement(ctx, p.UserID, from, to)
+		if err == nil {
+			err = is.WriteCSV(&buf)
+		}
+		if err != nil {
+			s.failExport(ctx, p.ExportID)
+			return err
+		}
+	default:
+		s.failExport(ctx, p.ExportID)
+		return fmt.Errorf("unknown report kind %q", p.Kind)
+	}
+
+	key := fmt.Sprintf("export_%d.csv", p.ExportID)
+	if err := s.store.Save(ctx, key, bytes.NewReader(buf.Bytes())); err != nil {
+		s.failExport(ctx, p.ExportID)
+		return err
+	}
+	return s.q.UpdateExportStatus(ctx, sqlc.UpdateExportStatusParams{
+		ID:       p.ExportID,
+		Status:   "done",
+		FilePath: pgtype.Text{String: key, Valid: true},
+	})
+}
@@ -25,6 +25,24 @@ func (sch *Scheduler) Start() error {
 	if err != nil {
 		return err
 	}
+	// Sweep lapsed holds every five minutes. Balances already ignore holds
+	// past expires_at; the sweep just makes their status say so.
+	_, err = sch.s.Register("*/5 * * * *", asynq.NewTask(TypeHoldsExpire, nil))
+	if err != nil {
+		return err
+	}
+	// Book due recurring transactions at 00:15 UTC, after the snapshot run
+	// has been enqueued. Missed days are caught up on the next run.
+	_, err = sch.s.Register("15 0 * * *", asynq.NewTask(TypeRecurringRun, nil))
+	if err != nil {
+		return err
+	}
+	// Last month's statements at 01:00 UTC on the 1st, once the month's
+	// recurring bookings and snapshots are in.
+	_, err = sch.s.Register("0 1 1 * *", asynq.NewTask(TypeStatementsMonthly, MustJSON(StatementsMonthlyPayload{})))
+	if err != nil {
+		return err
+	}
 	return sch.s.Start()
 }
 
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	sqlc "github.com/EftikharAzim/ledgerx/internal/repo/sqlc"
+	"github.com/EftikharAzim/ledgerx/internal/service"
 	"github.com/EftikharAzim/ledgerx/internal/storage"
 	"github.com/hibiken/asynq"
 )
@@ -16,20 +17,26 @@ type Server struct {
 	mux       *asynq.ServeMux
 	q         *sqlc.Queries
 	store     storage.Storage
+	recurring *service.RecurringService
 	redisAddr string
 }
 
-func NewServer(redisAddr string, q *sqlc.Queries, store storage.Storage) *Server {
+func NewServer(redisAddr string, q *sqlc.Queries, store storage.Storage, recurring *service.RecurringService) *Server {
 	srv := asynq.NewServer(
 		asynq.RedisClientOpt{Addr: redisAddr},
 		asynq.Config{Concurrency: 10, Queues: map[string]int{"default": 10}},
 	)
 	mux := asynq.NewServeMux()
-	ws := &Server{srv: srv, mux: mux, q: q, store: store, redisAddr: redisAddr}
+	ws := &Server{srv: srv, mux: mux, q: q, store: store, recurring: recurring, redisAddr: redisAddr}
 
 	mux.HandleFunc(TypeSnapshotAll, ws.handleSnapshotAll)
 	mux.HandleFunc(TypeSnapshotAccount, ws.handleSnapshotAccount)
 	mux.HandleFunc(TypeExportCSV, ws.handleExportCSV)
+	mux.HandleFunc(TypeExportReport, ws.handleExportReport)
+	mux.HandleFunc(TypeHoldsExpire, ws.handleHoldsExpire)
+	mux.HandleFunc(TypeRecurringRun, ws.handleRecurringRun)
+	mux.HandleFunc(TypeStatementGenerate, ws.handleStatementGenerate)
+	mux.HandleFunc(TypeStatementsMonthly, ws.handleStatementsMonthly)
 
 	return ws
 }
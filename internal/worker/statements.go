@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/EftikharAzim/ledgerx/internal/service"
+	"github.com/hibiken/asynq"
+)
+
+// handleStatementGenerate renders one requested statement. The service marks
+// the row failed itself when generation errors.
+func (s *Server) handleStatementGenerate(ctx context.Context, t *asynq.Task) error {
+	var p StatementGeneratePayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+	return service.NewStatementService(s.q, s.store).Generate(ctx, p.StatementID)
+}
+
+// handleStatementsMonthly requests a month's statement for every asset and
+// liability account and enqueues one generation task each. TaskID dedupes
+// the enqueues when the run is retried; any other enqueue error fails the
+// run so asynq retries it.
+func (s *Server) handleStatementsMonthly(ctx context.Context, t *asynq.Task) error {
+	var p StatementsMonthlyPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return err
+	}
+	// The month before the current one, from its first day: AddDate on
+	// the 31st would normalize back into the current month.
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	if p.Month != "" {
+		var err error
+		if month, err = time.Parse("2006-01", p.Month); err != nil {
+			return err
+		}
+	}
+	ids, err := service.NewStatementService(s.q, s.store).RequestMonthEnd(ctx, month)
+	if err != nil {
+		return err
+	}
+	client := s.newClient()
+	defer func() {
+		_ = client.Close()
+	}()
+	for _, id := range ids {
+		task := NewTaskStatementGenerate(StatementGeneratePayload{StatementID: id})
+		_, err := client.EnqueueContext(ctx, task, asynq.Queue("default"),
+			asynq.TaskID(fmt.Sprintf("statement:%d:%s", id, month.Format("2006-01"))))
+		if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			return fmt.Errorf("enqueue statement %d: %w", id, err)
+		}
+	}
+	return nil
+}
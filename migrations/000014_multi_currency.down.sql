@@ -0,0 +1,46 @@
+-- Cross-currency transactions cannot be represented once postings lose their
+-- currency. Refuse to roll back rather than delete ledger data; reverse or
+-- remove them by hand first.
+DO $$
+DECLARE
+  n BIGINT;
+BEGIN
+  SELECT count(*) INTO n FROM (
+    SELECT transaction_id FROM postings GROUP BY transaction_id HAVING count(DISTINCT currency) > 1
+  ) x;
+  IF n > 0 THEN
+    RAISE EXCEPTION 'cannot roll back multi_currency: % cross-currency transactions exist', n;
+  END IF;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION check_transaction_balanced() RETURNS trigger AS $$
+DECLARE
+  tx_id BIGINT;
+  total BIGINT;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    tx_id := OLD.transaction_id;
+  ELSE
+    tx_id := NEW.transaction_id;
+  END IF;
+  SELECT COALESCE(SUM(amount_minor), 0) INTO total FROM postings WHERE transaction_id = tx_id;
+  IF total <> 0 THEN
+    RAISE EXCEPTION 'transaction % postings sum to %, must be 0', tx_id, total;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DELETE FROM accounts WHERE kind = 'fx';
+DROP INDEX IF EXISTS accounts_one_fx_per_user;
+ALTER TABLE accounts DROP CONSTRAINT accounts_kind_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_kind_check CHECK (kind IN ('normal', 'external'));
+
+ALTER TABLE transactions DROP COLUMN fx_rate;
+ALTER TABLE postings DROP COLUMN currency;
+
+-- Snapshots are derived data taken under the multi-currency schema — drop
+-- them rather than carry them across, and let the nightly snapshot job
+-- rebuild them. Until it runs, balances are summed from all postings.
+TRUNCATE balance_snapshots;
@@ -0,0 +1,46 @@
+-- Multi-currency postings.
+-- Each posting carries its own currency so one transaction can move value
+-- between currencies. The zero-sum invariant now holds per currency: a
+-- USD -> EUR transfer balances because a per-user 'fx' trading account takes
+-- the opposite side of each currency's legs.
+
+-- 1) Posting currency, backfilled from the header (every existing
+--    transaction is single-currency).
+ALTER TABLE postings ADD COLUMN currency TEXT;
+UPDATE postings p SET currency = t.currency FROM transactions t WHERE t.id = p.transaction_id;
+ALTER TABLE postings ALTER COLUMN currency SET NOT NULL;
+
+-- 2) The exchange rate a cross-currency entry was booked at: units of the
+--    destination currency per one unit of the header currency.
+ALTER TABLE transactions ADD COLUMN fx_rate NUMERIC(20, 10) CHECK (fx_rate > 0);
+
+-- 3) FX trading account: like 'external', one per user and currency-agnostic.
+ALTER TABLE accounts DROP CONSTRAINT accounts_kind_check;
+ALTER TABLE accounts ADD CONSTRAINT accounts_kind_check CHECK (kind IN ('normal', 'external', 'fx'));
+CREATE UNIQUE INDEX accounts_one_fx_per_user
+  ON accounts(user_id) WHERE kind = 'fx';
+
+-- 4) Zero-sum per currency, still checked at COMMIT.
+CREATE OR REPLACE FUNCTION check_transaction_balanced() RETURNS trigger AS $$
+DECLARE
+  tx_id BIGINT;
+  cur   TEXT;
+  total BIGINT;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    tx_id := OLD.transaction_id;
+  ELSE
+    tx_id := NEW.transaction_id;
+  END IF;
+  SELECT currency, SUM(amount_minor) INTO cur, total
+  FROM postings
+  WHERE transaction_id = tx_id
+  GROUP BY currency
+  HAVING SUM(amount_minor) <> 0
+  LIMIT 1;
+  IF FOUND THEN
+    RAISE EXCEPTION 'transaction % % postings sum to %, must be 0', tx_id, cur, total;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
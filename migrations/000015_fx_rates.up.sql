@@ -0,0 +1,12 @@
+-- Exchange rates used for reporting in a currency other than an account's
+-- own. One row per (base, quote, day): `rate` units of quote per one unit of
+-- base, effective from rate_date until the next row for the pair.
+CREATE TABLE fx_rates (
+  base       TEXT NOT NULL,
+  quote      TEXT NOT NULL,
+  rate_date  DATE NOT NULL,
+  rate       NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+  source     TEXT NOT NULL DEFAULT 'import',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (base, quote, rate_date)
+);
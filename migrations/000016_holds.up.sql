@@ -0,0 +1,25 @@
+-- Holds (pending authorizations) reserve funds on an account without
+-- touching the ledger. A pending hold lowers the available balance until it
+-- is captured into a real transaction, voided, or expires.
+CREATE TABLE holds (
+  id             BIGSERIAL PRIMARY KEY,
+  user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  account_id     BIGINT NOT NULL REFERENCES accounts(id),
+  amount_minor   BIGINT NOT NULL CHECK (amount_minor > 0),
+  currency       TEXT   NOT NULL,
+  note           TEXT,
+  status         TEXT   NOT NULL DEFAULT 'pending'
+                 CHECK (status IN ('pending', 'captured', 'voided', 'expired')),
+  expires_at     TIMESTAMPTZ NOT NULL,
+  -- Set on capture: the transaction the hold became and the amount taken,
+  -- which may be less than amount_minor (partial capture).
+  transaction_id BIGINT REFERENCES transactions(id),
+  captured_minor BIGINT CHECK (captured_minor > 0 AND captured_minor <= amount_minor),
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_holds_account ON holds(account_id, id DESC);
+-- The available-balance sum and the expiry sweep only look at pending holds.
+CREATE INDEX idx_holds_pending_account ON holds(account_id) WHERE status = 'pending';
+CREATE INDEX idx_holds_pending_expiry ON holds(expires_at) WHERE status = 'pending';
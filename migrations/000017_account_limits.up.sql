@@ -0,0 +1,11 @@
+-- Optional per-account balance floors. Every set constraint applies; the
+-- effective floor is the highest of them. The row doubles as the account's
+-- lock for floor checks: posting paths take it FOR UPDATE so concurrent
+-- debits against the same account serialize.
+CREATE TABLE account_limits (
+  account_id            BIGINT PRIMARY KEY REFERENCES accounts(id) ON DELETE CASCADE,
+  min_balance_minor     BIGINT,
+  overdraft_limit_minor BIGINT CHECK (overdraft_limit_minor >= 0),
+  never_negative        BOOLEAN NOT NULL DEFAULT FALSE,
+  updated_at            TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,31 @@
+-- Recurring rules (standing orders). The worker books every due occurrence
+-- through the normal transaction service with the idempotency key
+-- 'recurring:<rule id>:<occurrence date>', so a rerun can never double-book.
+CREATE TABLE recurring_rules (
+  id             BIGSERIAL PRIMARY KEY,
+  user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind           TEXT   NOT NULL CHECK (kind IN ('create', 'transfer')),
+  account_id     BIGINT NOT NULL REFERENCES accounts(id),
+  to_account_id  BIGINT REFERENCES accounts(id),
+  amount_minor   BIGINT NOT NULL CHECK (amount_minor <> 0),
+  currency       TEXT   NOT NULL,
+  note           TEXT,
+  frequency      TEXT   NOT NULL CHECK (frequency IN ('monthly', 'weekly', 'last_business_day')),
+  interval_count INT    NOT NULL DEFAULT 1 CHECK (interval_count >= 1),
+  day_of_month   INT    CHECK (day_of_month BETWEEN 1 AND 31),
+  start_date     DATE   NOT NULL,
+  end_date       DATE,
+  next_run_date  DATE   NOT NULL,
+  paused         BOOLEAN NOT NULL DEFAULT FALSE,
+  last_error     TEXT,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT recurring_rules_transfer_target CHECK (
+    (kind = 'transfer' AND to_account_id IS NOT NULL AND to_account_id <> account_id AND amount_minor > 0)
+    OR (kind = 'create' AND to_account_id IS NULL)
+  )
+);
+
+CREATE INDEX idx_recurring_rules_user ON recurring_rules(user_id, id);
+CREATE INDEX idx_recurring_rules_due ON recurring_rules(next_run_date) WHERE NOT paused;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transactions_category;
+DROP TABLE IF EXISTS transaction_category_changes;
+DROP INDEX IF EXISTS categories_unique_sibling_name;
+DROP INDEX IF EXISTS idx_categories_parent;
+DROP INDEX IF EXISTS idx_categories_user;
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
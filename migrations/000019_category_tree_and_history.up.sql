@@ -0,0 +1,21 @@
+-- Categories become a per-user tree, and re-categorizing a transaction is
+-- recorded append-only: the header's category_id holds the current value,
+-- transaction_category_changes holds every change.
+ALTER TABLE categories ADD COLUMN parent_id BIGINT REFERENCES categories(id);
+CREATE INDEX idx_categories_user ON categories(user_id, id);
+CREATE INDEX idx_categories_parent ON categories(parent_id);
+-- Sibling names are unique; the same name may appear under different parents.
+CREATE UNIQUE INDEX categories_unique_sibling_name
+  ON categories(user_id, COALESCE(parent_id, 0), lower(name));
+
+CREATE TABLE transaction_category_changes (
+  id              BIGSERIAL PRIMARY KEY,
+  transaction_id  BIGINT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+  user_id         BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  old_category_id BIGINT REFERENCES categories(id),
+  new_category_id BIGINT REFERENCES categories(id),
+  changed_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_category_changes_tx ON transaction_category_changes(transaction_id, id);
+CREATE INDEX idx_transactions_category ON transactions(category_id) WHERE category_id IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Monthly category budgets. A budget on a category also covers every
+-- category below it. With rollover, the unspent part of each month (never an
+-- overspend) is added to the next month's amount, starting at start_month.
+CREATE TABLE budgets (
+  id           BIGSERIAL PRIMARY KEY,
+  user_id      BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  category_id  BIGINT NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+  currency     TEXT   NOT NULL,
+  amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+  rollover     BOOLEAN NOT NULL DEFAULT FALSE,
+  start_month  DATE   NOT NULL CHECK (start_month = date_trunc('month', start_month)::date),
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+  CONSTRAINT budgets_unique_category UNIQUE (category_id, currency)
+);
+
+CREATE INDEX idx_budgets_user ON budgets(user_id, id);
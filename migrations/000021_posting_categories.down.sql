@@ -0,0 +1 @@
+ALTER TABLE postings DROP COLUMN IF EXISTS category_id;
@@ -0,0 +1,5 @@
+-- Split transactions: a posting may carry its own category, overriding the
+-- header's. Reports group by the line category,
+-- COALESCE(postings.category_id, transactions.category_id).
+ALTER TABLE postings ADD COLUMN category_id BIGINT REFERENCES categories(id);
+CREATE INDEX idx_postings_category ON postings(category_id) WHERE category_id IS NOT NULL;
@@ -0,0 +1,16 @@
+-- Receipts and other documents attached to a transaction. The bytes live in
+-- storage.Storage under storage_key; this table keeps what was checked on
+-- upload: the sniffed content type, the size and a SHA-256 of the content.
+CREATE TABLE attachments (
+  id             BIGSERIAL PRIMARY KEY,
+  transaction_id BIGINT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+  user_id        BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  filename       TEXT   NOT NULL,
+  content_type   TEXT   NOT NULL CHECK (content_type IN ('application/pdf', 'image/jpeg', 'image/png')),
+  size_bytes     BIGINT NOT NULL CHECK (size_bytes > 0),
+  sha256         TEXT   NOT NULL,
+  storage_key    TEXT   NOT NULL UNIQUE,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_attachments_transaction ON attachments(transaction_id, id);
@@ -0,0 +1,2 @@
+-- User-wide transaction search pages newest first on (occurred_at, id).
+CREATE INDEX idx_transactions_user_occurred ON transactions(user_id, occurred_at DESC, id DESC);
@@ -0,0 +1,2 @@
+-- Transaction detail lists the outbox events emitted for one transaction.
+CREATE INDEX idx_outbox_transaction ON outbox (((payload->>'transaction_id')::bigint));
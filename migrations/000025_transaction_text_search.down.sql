@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_search_vector;
+ALTER TABLE transactions DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE transactions DROP COLUMN IF EXISTS payee;
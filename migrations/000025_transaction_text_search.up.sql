@@ -0,0 +1,12 @@
+-- Who the money went to or came from, free text like the note.
+ALTER TABLE transactions ADD COLUMN payee TEXT;
+
+-- Full-text search over payee and note. Payee matches rank above note
+-- matches; both are stemmed as English so "refunds" finds "refund".
+ALTER TABLE transactions ADD COLUMN search_vector tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(payee, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(note, '')), 'B')
+  ) STORED;
+
+CREATE INDEX idx_transactions_search_vector ON transactions USING GIN (search_vector);
@@ -0,0 +1,5 @@
+-- An amendment reverses a transaction and books its replacement in one go.
+-- The replacement points back at the transaction it corrects; UNIQUE means
+-- a transaction is replaced at most once (amend the replacement instead).
+ALTER TABLE transactions
+  ADD COLUMN amends BIGINT UNIQUE REFERENCES transactions(id);
@@ -0,0 +1,6 @@
+-- Fails if any transaction has more than one reversal; those must be
+-- resolved by hand before going back to at most one.
+DROP INDEX IF EXISTS idx_postings_reverses_posting;
+ALTER TABLE postings DROP COLUMN reverses_posting_id;
+DROP INDEX IF EXISTS idx_transactions_reversal_of;
+ALTER TABLE transactions ADD CONSTRAINT transactions_reversal_of_key UNIQUE (reversal_of);
@@ -0,0 +1,37 @@
+-- Partial reversals (refunds): a transaction may now be reversed several
+-- times, each reversal negating part of what is left. Every reversing
+-- posting names the posting it negates, so the amount still reversible is
+-- tracked per posting; the service locks the original while checking it.
+ALTER TABLE transactions DROP CONSTRAINT transactions_reversal_of_key;
+CREATE INDEX idx_transactions_reversal_of ON transactions(reversal_of)
+  WHERE reversal_of IS NOT NULL;
+
+ALTER TABLE postings ADD COLUMN reverses_posting_id BIGINT REFERENCES postings(id);
+CREATE INDEX idx_postings_reverses_posting ON postings(reverses_posting_id)
+  WHERE reverses_posting_id IS NOT NULL;
+
+-- Backfill: pair each existing full reversal's postings with the original's
+-- by account, currency and negated amount; identical postings pair in id
+-- order.
+WITH orig AS (
+  SELECT op.id, rt.id AS reversal_id, op.account_id, op.currency, op.amount_minor,
+         row_number() OVER (PARTITION BY rt.id, op.account_id, op.currency, op.amount_minor ORDER BY op.id) AS n
+  FROM transactions rt
+  JOIN postings op ON op.transaction_id = rt.reversal_of
+),
+rev AS (
+  SELECT rp.id, rp.transaction_id AS reversal_id, rp.account_id, rp.currency, rp.amount_minor,
+         row_number() OVER (PARTITION BY rp.transaction_id, rp.account_id, rp.currency, rp.amount_minor ORDER BY rp.id) AS n
+  FROM postings rp
+  JOIN transactions rt ON rt.id = rp.transaction_id
+  WHERE rt.reversal_of IS NOT NULL
+)
+UPDATE postings p
+SET reverses_posting_id = orig.id
+FROM rev
+JOIN orig ON orig.reversal_id = rev.reversal_id
+         AND orig.account_id = rev.account_id
+         AND orig.currency = rev.currency
+         AND orig.amount_minor = -rev.amount_minor
+         AND orig.n = rev.n
+WHERE p.id = rev.id;
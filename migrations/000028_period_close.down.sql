@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS period_close_audit;
+DROP TABLE IF EXISTS closed_periods;
@@ -0,0 +1,25 @@
+-- Closed accounting periods. A closed month refuses new postings dated in
+-- it; corrections go into the current open period instead. user_id NULL is
+-- a global close made by an admin, covering every user.
+CREATE TABLE closed_periods (
+  id         BIGSERIAL PRIMARY KEY,
+  user_id    BIGINT REFERENCES users(id) ON DELETE CASCADE,
+  month      DATE NOT NULL CHECK (month = date_trunc('month', month)::date),
+  closed_by  TEXT NOT NULL,
+  closed_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX closed_periods_user_month ON closed_periods(user_id, month) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX closed_periods_global_month ON closed_periods(month) WHERE user_id IS NULL;
+
+-- Every close and reopen, kept after the closed_periods row is gone.
+CREATE TABLE period_close_audit (
+  id         BIGSERIAL PRIMARY KEY,
+  user_id    BIGINT REFERENCES users(id) ON DELETE CASCADE,
+  month      DATE NOT NULL,
+  action     TEXT NOT NULL CHECK (action IN ('close', 'reopen')),
+  actor      TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_period_close_audit_user ON period_close_audit(user_id, id);
@@ -0,0 +1,23 @@
+-- Fold the income/expense counterparts back into the catch-all external
+-- account. A user with counterparts but no catch-all keeps the oldest
+-- counterpart as their catch-all.
+UPDATE accounts a SET type = 'equity', name = 'External'
+WHERE a.kind = 'external'
+  AND NOT EXISTS (
+    SELECT 1 FROM accounts e WHERE e.user_id = a.user_id AND e.kind = 'external' AND e.type = 'equity'
+  )
+  AND a.id = (SELECT min(x.id) FROM accounts x WHERE x.user_id = a.user_id AND x.kind = 'external');
+
+UPDATE postings p SET account_id = e.id
+FROM accounts a
+JOIN accounts e ON e.user_id = a.user_id AND e.kind = 'external' AND e.type = 'equity'
+WHERE p.account_id = a.id AND a.kind = 'external' AND a.type <> 'equity';
+
+DELETE FROM accounts WHERE kind = 'external' AND type <> 'equity';
+
+ALTER TABLE accounts DROP CONSTRAINT accounts_external_type_check;
+DROP INDEX accounts_one_external_per_type;
+CREATE UNIQUE INDEX accounts_one_external_per_user
+  ON accounts(user_id) WHERE kind = 'external';
+ALTER TABLE accounts DROP CONSTRAINT accounts_type_check;
+ALTER TABLE accounts DROP COLUMN type;
@@ -0,0 +1,16 @@
+-- Chart of accounts: every account gets an accounting type, which fixes its
+-- normal balance (debit for assets and expenses, credit for the rest).
+-- Existing user accounts are assets; the catch-all external and the FX
+-- trading accounts are equity.
+ALTER TABLE accounts ADD COLUMN type TEXT NOT NULL DEFAULT 'asset';
+ALTER TABLE accounts ADD CONSTRAINT accounts_type_check
+  CHECK (type IN ('asset', 'liability', 'equity', 'income', 'expense'));
+UPDATE accounts SET type = 'equity' WHERE kind IN ('external', 'fx');
+
+-- Besides the catch-all, a user may keep one external income and one
+-- external expense account as default counterparts for income and spending.
+DROP INDEX accounts_one_external_per_user;
+CREATE UNIQUE INDEX accounts_one_external_per_type
+  ON accounts(user_id, type) WHERE kind = 'external';
+ALTER TABLE accounts ADD CONSTRAINT accounts_external_type_check
+  CHECK (kind <> 'external' OR type IN ('equity', 'income', 'expense'));
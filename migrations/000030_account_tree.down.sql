@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_accounts_parent;
+ALTER TABLE accounts DROP CONSTRAINT accounts_parent_not_self;
+ALTER TABLE accounts DROP COLUMN parent_id;
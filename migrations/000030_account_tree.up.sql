@@ -0,0 +1,6 @@
+-- Hierarchical accounts ("Assets:Bank:Checking"). A parent is one of the
+-- user's normal accounts of the same type; the service enforces that and
+-- refuses cycles.
+ALTER TABLE accounts ADD COLUMN parent_id BIGINT REFERENCES accounts(id);
+ALTER TABLE accounts ADD CONSTRAINT accounts_parent_not_self CHECK (parent_id <> id);
+CREATE INDEX idx_accounts_parent ON accounts(parent_id) WHERE parent_id IS NOT NULL;
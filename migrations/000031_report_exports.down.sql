@@ -0,0 +1,4 @@
+DELETE FROM exports WHERE kind <> 'postings';
+ALTER TABLE exports DROP COLUMN range_end;
+ALTER TABLE exports DROP COLUMN range_start;
+ALTER TABLE exports DROP COLUMN kind;
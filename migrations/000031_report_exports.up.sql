@@ -0,0 +1,7 @@
+-- Exports also carry financial reports. kind picks the CSV layout; the
+-- range columns record what a report export covers (a balance sheet has
+-- only range_end, its as-of cutoff). month stays the first month covered.
+ALTER TABLE exports ADD COLUMN kind TEXT NOT NULL DEFAULT 'postings'
+    CHECK (kind IN ('postings', 'balance_sheet', 'income_statement'));
+ALTER TABLE exports ADD COLUMN range_start TIMESTAMPTZ;
+ALTER TABLE exports ADD COLUMN range_end TIMESTAMPTZ;
@@ -0,0 +1,26 @@
+-- Bank-style account statements. A row is requested (on demand or by the
+-- month-end job) as pending; the worker fills in the figures and stores the
+-- rendered CSV and HTML. One statement per account and period: asking again
+-- regenerates it.
+CREATE TABLE statements (
+  id            BIGSERIAL PRIMARY KEY,
+  user_id       BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  account_id    BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  period_start  DATE   NOT NULL,
+  period_end    DATE   NOT NULL, -- inclusive
+  currency      TEXT   NOT NULL,
+  status        TEXT   NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done', 'error')),
+  opening_minor BIGINT NOT NULL DEFAULT 0,
+  closing_minor BIGINT NOT NULL DEFAULT 0,
+  inflow_minor  BIGINT NOT NULL DEFAULT 0,
+  outflow_minor BIGINT NOT NULL DEFAULT 0,
+  entry_count   INT    NOT NULL DEFAULT 0,
+  csv_path      TEXT,
+  html_path     TEXT,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT statements_period CHECK (period_end >= period_start),
+  CONSTRAINT statements_unique UNIQUE (account_id, period_start, period_end)
+);
+
+CREATE INDEX idx_statements_user ON statements(user_id, period_start DESC, id DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reconciled_postings;
+DROP TABLE IF EXISTS reconciliation_lines;
+DROP TABLE IF EXISTS reconciliations;
@@ -0,0 +1,44 @@
+-- Bank reconciliation. A session holds the lines of one uploaded bank
+-- statement, each matched to at most one posting on the account. Completing
+-- the session records its matched postings in reconciled_postings, where a
+-- posting can appear only once.
+CREATE TABLE reconciliations (
+  id                    BIGSERIAL PRIMARY KEY,
+  user_id               BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  account_id            BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+  statement_date        DATE   NOT NULL,
+  closing_balance_minor BIGINT NOT NULL,
+  currency              TEXT   NOT NULL,
+  status                TEXT   NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'completed')),
+  cleared_balance_minor BIGINT, -- set on completion
+  difference_minor      BIGINT, -- set on completion
+  created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+  completed_at          TIMESTAMPTZ
+);
+
+CREATE INDEX idx_reconciliations_account ON reconciliations(account_id, statement_date DESC, id DESC);
+
+CREATE TABLE reconciliation_lines (
+  id                BIGSERIAL PRIMARY KEY,
+  reconciliation_id BIGINT NOT NULL REFERENCES reconciliations(id) ON DELETE CASCADE,
+  line_no           INT    NOT NULL,
+  occurred_on       DATE   NOT NULL,
+  amount_minor      BIGINT NOT NULL,
+  description       TEXT   NOT NULL DEFAULT '',
+  posting_id        BIGINT,
+  matched_by        TEXT CHECK (matched_by IN ('auto', 'manual')),
+  CONSTRAINT reconciliation_lines_posting_fk FOREIGN KEY (posting_id)
+    REFERENCES postings(id) ON DELETE SET NULL (posting_id, matched_by),
+  CONSTRAINT reconciliation_lines_match CHECK ((posting_id IS NULL) = (matched_by IS NULL)),
+  CONSTRAINT reconciliation_lines_posting UNIQUE (reconciliation_id, posting_id)
+);
+
+CREATE INDEX idx_reconciliation_lines_session ON reconciliation_lines(reconciliation_id, line_no);
+
+CREATE TABLE reconciled_postings (
+  posting_id        BIGINT PRIMARY KEY REFERENCES postings(id) ON DELETE CASCADE,
+  reconciliation_id BIGINT NOT NULL REFERENCES reconciliations(id) ON DELETE CASCADE,
+  reconciled_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_reconciled_postings_session ON reconciled_postings(reconciliation_id);